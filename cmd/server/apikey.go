@@ -0,0 +1,46 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/banking/audit-compliance/internal/auth"
+)
+
+// apikeyCommand implements `server apikey -id <id> [-subject s] [-roles r1,r2] [-ttl d]`.
+// It prints a new service API key once and the config entry holding only its hash.
+func apikeyCommand(args []string) error {
+	fs := flag.NewFlagSet("apikey", flag.ContinueOnError)
+	id := fs.String("id", "", "key ID, unique among active keys (required)")
+	subject := fs.String("subject", "", "calling service recorded in access logs (default service:<id>)")
+	roles := fs.String("roles", "SERVICE", "comma-separated roles")
+	ttl := fs.Duration("ttl", 0, "lifetime of the key; 0 never expires")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *id == "" {
+		return fmt.Errorf("-id is required")
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return fmt.Errorf("failed to generate key: %w", err)
+	}
+	key := base64.RawURLEncoding.EncodeToString(raw)
+
+	fmt.Printf("API key (shown once; send as %s): %s\n\n", auth.APIKeyHeader, key)
+	fmt.Println("auth:\n  service_api_keys:")
+	fmt.Printf("    - id: %s\n      sha256: %s\n", *id, auth.HashAPIKey(key))
+	if *subject != "" {
+		fmt.Printf("      subject: %s\n", *subject)
+	}
+	fmt.Printf("      roles: [%s]\n", strings.Join(strings.Split(*roles, ","), ", "))
+	if *ttl > 0 {
+		fmt.Printf("      expires_at: %s\n", time.Now().Add(*ttl).UTC().Format(time.RFC3339))
+	}
+	return nil
+}
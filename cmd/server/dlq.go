@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/banking/audit-compliance/internal/domain"
+	"github.com/banking/audit-compliance/internal/events"
+	"github.com/banking/audit-compliance/internal/repository/postgres"
+	"github.com/google/uuid"
+)
+
+// dlqCommand implements `server dlq list [-class C] [-pending] [-limit N]` and
+// `server dlq replay -ids ID,ID,...`
+func dlqCommand(args []string, repo *postgres.DLQRepository, replayer *events.Replayer) error {
+	if len(args) == 0 {
+		return errors.New("usage: server dlq list|replay [flags]")
+	}
+
+	switch args[0] {
+	case "list":
+		fs := flag.NewFlagSet("dlq list", flag.ContinueOnError)
+		class := fs.String("class", "", "only entries of this error class (MALFORMED, UNMAPPED, QUARANTINED, CONFLICT, PERSISTENCE)")
+		pending := fs.Bool("pending", false, "only entries not yet replayed")
+		limit := fs.Int("limit", 100, "maximum number of entries")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+
+		filter := domain.DeadLetterFilter{PendingOnly: *pending, Limit: *limit}
+		if *class != "" {
+			c := domain.DeadLetterClass(strings.ToUpper(*class))
+			filter.ErrorClass = &c
+		}
+		letters, err := repo.ListDeadLetters(context.Background(), filter)
+		if err != nil {
+			return err
+		}
+		for _, dl := range letters {
+			replayed := "pending"
+			if dl.ReplayedAt != nil {
+				replayed = "replayed " + dl.ReplayedAt.Format("2006-01-02T15:04:05Z07:00")
+			}
+			fmt.Printf("%s  %s/%d/%d  %-11s  %s  %s  %s\n",
+				dl.DLQID, dl.SourceTopic, dl.SourcePartition, dl.SourceOffset, dl.ErrorClass,
+				dl.FailedAt.Format("2006-01-02T15:04:05Z07:00"), replayed, dl.ErrorMessage)
+			for _, v := range dl.Violations {
+				fmt.Printf("    %s: %s\n", v.Field, v.Message)
+			}
+		}
+		return nil
+
+	case "replay":
+		fs := flag.NewFlagSet("dlq replay", flag.ContinueOnError)
+		idList := fs.String("ids", "", "comma-separated dlq_ids to replay")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		if *idList == "" {
+			return errors.New("-ids is required")
+		}
+
+		var ids []uuid.UUID
+		for _, s := range strings.Split(*idList, ",") {
+			id, err := uuid.Parse(strings.TrimSpace(s))
+			if err != nil {
+				return fmt.Errorf("invalid dlq_id %q", s)
+			}
+			ids = append(ids, id)
+		}
+		results, err := replayer.Replay(context.Background(), ids)
+		if err != nil {
+			return err
+		}
+		failed := 0
+		for _, r := range results {
+			line := fmt.Sprintf("%s  %s", r.DLQID, r.Status)
+			if r.EventID != nil {
+				line += "  event " + r.EventID.String()
+			}
+			if r.Error != "" {
+				line += "  " + r.Error
+				failed++
+			}
+			fmt.Println(line)
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d of %d dead letters failed to replay", failed, len(results))
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("unknown dlq command %q", args[0])
+	}
+}
@@ -2,8 +2,10 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -11,18 +13,23 @@ import (
 	"time"
 
 	"github.com/banking/audit-compliance/internal/api"
+	"github.com/banking/audit-compliance/internal/auth"
 	"github.com/banking/audit-compliance/internal/config"
 	"github.com/banking/audit-compliance/internal/crypto"
 	"github.com/banking/audit-compliance/internal/events"
+	"github.com/banking/audit-compliance/internal/grpcserver"
 	"github.com/banking/audit-compliance/internal/repository/elasticsearch"
 	"github.com/banking/audit-compliance/internal/repository/postgres"
 	"github.com/banking/audit-compliance/internal/repository/s3"
+	"github.com/banking/audit-compliance/internal/schema"
 	"github.com/banking/audit-compliance/internal/service"
-	"github.com/golang-jwt/jwt/v5"
-	echojwt "github.com/labstack/echo-jwt/v4"
+	"github.com/banking/audit-compliance/internal/timestamp"
+	"github.com/banking/audit-compliance/schemas"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
 func main() {
@@ -37,8 +44,33 @@ func main() {
 	defer logger.Sync()
 	sugar := logger.Sugar()
 
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := migrateCommand(cfg, os.Args[2:], logger); err != nil {
+			sugar.Fatalf("Migration failed: %v", err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "partitions" {
+		if err := partitionsCommand(cfg, os.Args[2:], logger); err != nil {
+			sugar.Fatalf("Partition maintenance failed: %v", err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "apikey" {
+		if err := apikeyCommand(os.Args[2:]); err != nil {
+			sugar.Fatalf("API key generation failed: %v", err)
+		}
+		return
+	}
+
 	sugar.Info("Starting Audit & Compliance Service...")
 
+	if cfg.Database.AutoMigrate {
+		if _, err := runMigrations(context.Background(), cfg.Database, false, logger); err != nil {
+			sugar.Fatalf("Failed to apply database migrations: %v", err)
+		}
+	}
+
 	// 3. Crypto / Security
 	encryptor, err := crypto.NewFieldEncryptor(
 		cfg.Encryption.EncryptionKeysBase64,
@@ -49,6 +81,20 @@ func main() {
 		sugar.Fatalf("Failed to initialize encryptor: %v", err)
 	}
 
+	keyring, err := crypto.NewKeyring(cfg.Encryption, encryptor)
+	if err != nil {
+		sugar.Fatalf("Failed to initialize signing keyring: %v", err)
+	}
+	sugar.Infof("Signing audit records with %s (key %s)", keyring.Active().Algorithm(), keyring.Active().KeyID())
+
+	tsaClient, err := timestamp.NewClient(cfg.Timestamp)
+	if err != nil {
+		sugar.Fatalf("Failed to initialize timestamp authority client: %v", err)
+	}
+	if tsaClient == nil {
+		sugar.Warn("No timestamp authority configured; ledger checkpoints will not be independently timestamped")
+	}
+
 	// 4. Repositories
 	pgRepo, err := postgres.NewAuditRepository(cfg.Database, encryptor)
 	if err != nil {
@@ -56,6 +102,23 @@ func main() {
 	}
 	defer pgRepo.Close()
 
+	// Refuse to run with a role that could rewrite the ledger
+	if err := pgRepo.CheckAppendOnly(context.Background()); err != nil {
+		sugar.Fatalf("Database immutability self-check failed: %v", err)
+	}
+
+	// Refuse to reject the HMAC-signed history of a ledger switched to Ed25519
+	lastHMAC, err := pgRepo.LastSequenceSignedWith(context.Background(), crypto.AlgorithmHMACSHA256)
+	if err != nil {
+		sugar.Fatalf("Failed to read the legacy HMAC history: %v", err)
+	}
+	if err := keyring.CheckLegacyHMAC(lastHMAC); err != nil {
+		sugar.Fatalf("Signing configuration self-check failed: %v", err)
+	}
+
+	checkpointRepo := postgres.NewCheckpointRepository(pgRepo.Pool())
+	accessLogRepo := postgres.NewAccessLogRepository(pgRepo.Pool())
+
 	esRepo, err := elasticsearch.NewSearchRepository(cfg.Elasticsearch)
 	if err != nil {
 		sugar.Warnf("Failed to connect to Elasticsearch: %v (Search capabilities will be limited)", err)
@@ -70,10 +133,38 @@ func main() {
 	}
 
 	// 5. Services
-	auditService := service.NewAuditService(pgRepo, esRepo, s3Repo, encryptor, logger)
+	auditService := service.NewAuditService(pgRepo, checkpointRepo, accessLogRepo, esRepo, s3Repo, encryptor, keyring, tsaClient, logger)
+
+	// Submitted payloads are validated against the versioned schemas in schemas/
+	schemaRegistry, err := schema.NewFSRegistry(schemas.FS)
+	if err != nil {
+		sugar.Fatalf("Failed to load event schemas: %v", err)
+	}
+
+	// Records are validated and mapped per topic; replays use the same mappers as live
+	// consumption
+	mappers, err := events.NewDefaultMappers(cfg.Kafka, schemaRegistry)
+	if err != nil {
+		sugar.Fatalf("Failed to register event mappers: %v", err)
+	}
+	dlqRepo := postgres.NewDLQRepository(pgRepo.Pool())
+	replayer := events.NewReplayer(dlqRepo, auditService, mappers, logger)
+	if len(os.Args) > 1 && os.Args[1] == "dlq" {
+		if err := dlqCommand(os.Args[2:], dlqRepo, replayer); err != nil {
+			sugar.Fatalf("Dead letter command failed: %v", err)
+		}
+		return
+	}
 
 	// 6. Kafka Consumer
-	consumer, err := events.NewAuditConsumer(cfg.Kafka, auditService, logger)
+	// Events that cannot be recorded are dead-lettered rather than dropped
+	dlq, err := events.NewDeadLetterQueue(cfg.Kafka, dlqRepo, logger)
+	if err != nil {
+		sugar.Fatalf("Failed to create dead-letter queue: %v", err)
+	}
+	defer dlq.Close()
+
+	consumer, err := events.NewAuditConsumer(cfg.Kafka, auditService, dlq, mappers, logger)
 	if err != nil {
 		sugar.Fatalf("Failed to create Kafka consumer: %v", err)
 	}
@@ -82,13 +173,35 @@ func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	consumerDone := make(chan struct{})
 	go func() {
+		defer close(consumerDone)
 		sugar.Info("Starting Kafka consumer loop...")
 		if err := consumer.Start(ctx); err != nil {
 			sugar.Errorf("Kafka consumer failed: %v", err)
 		}
 	}()
-	defer consumer.Close()
+
+	// Scheduled ledger integrity verification
+	go auditService.RunIntegrityVerification(ctx, cfg.Compliance.IntegrityCheckInterval, cfg.Compliance.IntegrityCheckWindow)
+
+	// Periodic signed Merkle checkpoints over the ledger
+	go auditService.RunCheckpointing(ctx, cfg.Compliance.CheckpointInterval)
+
+	// Expiry of idempotency keys for synchronous ingestion
+	go auditService.RunIdempotencyKeyExpiry(ctx, cfg.Compliance.IdempotencyCleanupInterval, cfg.Compliance.IdempotencyKeyTTL)
+
+	// Partition maintenance normally runs as `server partitions` from a scheduled job, so
+	// the server holds no owner credentials. Setting an interval runs it in process instead.
+	if cfg.Compliance.PartitionInterval > 0 {
+		partitionService, ownerPool, err := newPartitionService(ctx, cfg, logger)
+		if err != nil {
+			sugar.Fatalf("Failed to initialize partition maintenance: %v", err)
+		}
+		defer ownerPool.Close()
+
+		go partitionService.RunMaintenance(ctx, cfg.Compliance.PartitionInterval)
+	}
 
 	// 7. API Server
 	e := echo.New()
@@ -97,51 +210,103 @@ func main() {
 	e.Use(middleware.Recover())
 	e.Use(middleware.CORS())
 
-	auditHandler := api.NewAuditHandler(auditService)
+	policy, err := auth.NewPolicy(cfg.Auth.RBAC)
+	if err != nil {
+		sugar.Fatalf("Invalid RBAC policy: %v", err)
+	}
+	eventSchema, err := schemaRegistry.Validator("audit_event.v1.json")
+	if err != nil {
+		sugar.Fatalf("Failed to load event schema: %v", err)
+	}
+	auditHandler := api.NewAuditHandler(auditService, policy, eventSchema)
 
 	apiGroup := e.Group("/audit")
 
-	// Security: Add JWT Authentication
-	keyData, err := os.ReadFile(cfg.Auth.JWTPublicKeyPath)
-	var signingKey interface{}
-	if err == nil {
-		signingKey, err = jwt.ParseRSAPublicKeyFromPEM(keyData)
-		if err != nil {
-			sugar.Warnf("Failed to parse JWT public key: %v", err)
+	// Security: every /audit request must authenticate with a JWT, service API key or
+	// client certificate. Startup fails when none is configured, unless dev mode is set.
+	var tokens auth.TokenVerifier
+	claimsPolicy := auth.ClaimsPolicyFromConfig(cfg.Auth)
+	if cfg.Auth.JWKSURL != "" {
+		jwks := auth.NewJWKSVerifier(cfg.Auth.JWKSURL, claimsPolicy, nil, logger)
+		if err := jwks.Refresh(ctx); err != nil {
+			sugar.Warnf("Initial JWKS fetch failed, tokens are rejected until it succeeds: %v", err)
 		}
+		go jwks.Run(ctx, cfg.Auth.JWKSRefreshInterval)
+		tokens = jwks
+		sugar.Infof("JWT authentication enabled with keys from %s", cfg.Auth.JWKSURL)
+	} else if verifier, err := auth.NewStaticKeyVerifier(cfg.Auth.JWTPublicKeyPath, claimsPolicy); err != nil {
+		sugar.Warnf("JWT authentication unavailable: %v", err)
 	} else {
-		sugar.Warnf("JWT public key not found at %s: %v", cfg.Auth.JWTPublicKeyPath, err)
+		tokens = verifier
 	}
-
-	if signingKey != nil {
-		config := echojwt.Config{
-			SigningKey:    signingKey,
-			SigningMethod: "RS256",
-			NewClaimsFunc: func(c echo.Context) jwt.Claims {
-				return new(jwt.MapClaims)
-			},
-		}
-		apiGroup.Use(echojwt.WithConfig(config))
-		sugar.Info("JWT Authentication enabled for /audit/*")
-	} else {
-		sugar.Warn("JWT Authentication DISABLED - Missing Public Key (Security Risk)")
+	authenticator, err := auth.NewAuthenticator(cfg.Auth, tokens)
+	if err != nil {
+		sugar.Fatalf("Refusing to start without authentication: %v", err)
 	}
+	if authenticator.DevMode() {
+		sugar.Warnf("INSECURE DEV MODE - requests without credentials are served as %s; never enable in production", cfg.Auth.DevRole)
+	}
+
+	// Every read under /audit is recorded against the caller's identity and stated purpose
+	purposes := auth.NewPurposePolicy(cfg.Auth.Purpose)
+	apiGroup.Use(api.AuthMiddleware(authenticator, policy))
+	apiGroup.Use(api.PurposeMiddleware(purposes))
 
 	auditHandler.RegisterRoutes(apiGroup)
+	api.NewDLQHandler(replayer, policy, logger).RegisterRoutes(apiGroup)
+	auditHandler.RegisterPublicRoutes(e)
 
 	// Health Check
 	e.GET("/health", func(c echo.Context) error {
 		return c.JSON(http.StatusOK, map[string]string{"status": "ok"})
 	})
 
+	var tlsConfig *tls.Config
+	if cfg.Server.TLS.CertFile != "" {
+		tlsConfig, err = serverTLSConfig(cfg.Server.TLS)
+		if err != nil {
+			sugar.Fatalf("Invalid TLS configuration: %v", err)
+		}
+	}
+
 	// Start Server
 	go func() {
 		addr := fmt.Sprintf(":%d", cfg.Server.Port)
+		if tlsConfig != nil {
+			e.TLSServer.Addr = addr
+			e.TLSServer.TLSConfig = tlsConfig
+			err := e.StartServer(e.TLSServer)
+			if err != nil && err != http.ErrServerClosed {
+				sugar.Fatalf("Shutting down the server: %v", err)
+			}
+			return
+		}
 		if err := e.Start(addr); err != nil && err != http.ErrServerClosed {
 			sugar.Fatalf("Shutting down the server: %v", err)
 		}
 	}()
 
+	// gRPC API for synchronous, acknowledged writes and streaming queries
+	var grpcServer *grpc.Server
+	if cfg.Server.GRPCPort > 0 {
+		var opts []grpc.ServerOption
+		if tlsConfig != nil {
+			opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+		}
+		grpcServer = grpcserver.NewGRPCServer(grpcserver.NewServer(auditService, policy, logger), authenticator, purposes, opts...)
+
+		lis, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.Server.GRPCPort))
+		if err != nil {
+			sugar.Fatalf("Failed to listen for gRPC: %v", err)
+		}
+		go func() {
+			if err := grpcServer.Serve(lis); err != nil {
+				sugar.Fatalf("gRPC server stopped: %v", err)
+			}
+		}()
+		sugar.Infof("gRPC API listening on :%d", cfg.Server.GRPCPort)
+	}
+
 	// Graceful Shutdown
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
@@ -152,6 +317,31 @@ func main() {
 	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer shutdownCancel()
 
+	// Stop taking Kafka records, let each partition finish the one in flight, then commit
+	// the offsets of everything that reached the ledger or the dead-letter queue
+	cancel()
+	select {
+	case <-consumerDone:
+	case <-shutdownCtx.Done():
+		sugar.Warn("Timed out draining the Kafka consumer; unmarked records will be redelivered")
+	}
+	if err := consumer.Close(); err != nil {
+		sugar.Errorf("Failed to close Kafka consumer: %v", err)
+	}
+
+	if grpcServer != nil {
+		stopped := make(chan struct{})
+		go func() {
+			grpcServer.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-shutdownCtx.Done():
+			grpcServer.Stop()
+		}
+	}
+
 	if err := e.Shutdown(shutdownCtx); err != nil {
 		sugar.Fatal(err)
 	}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/banking/audit-compliance/internal/config"
+	"github.com/banking/audit-compliance/internal/migrate"
+	"github.com/banking/audit-compliance/internal/repository/postgres"
+	"github.com/banking/audit-compliance/migrations"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+// runMigrations applies the embedded migrations as the schema owner. With dryRun set it
+// verifies checksums of applied migrations and reports what is pending without applying.
+func runMigrations(ctx context.Context, cfg config.DatabaseConfig, dryRun bool, logger *zap.Logger) ([]migrate.Migration, error) {
+	loaded, err := migrate.Load(migrations.FS)
+	if err != nil {
+		return nil, err
+	}
+
+	pool, err := pgxpool.New(ctx, cfg.OwnerDSN())
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect as schema owner: %w", err)
+	}
+	defer pool.Close()
+
+	return migrate.NewRunner(pool, loaded, logger).
+		AfterMigration(postgres.ChainBackfillMigration, postgres.BackfillHashChain).
+		Up(ctx, dryRun)
+}
+
+// migrateCommand implements `server migrate [-dry-run]`
+func migrateCommand(cfg *config.Config, args []string, logger *zap.Logger) error {
+	fs := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "verify applied migrations and list pending ones without applying them")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	result, err := runMigrations(context.Background(), cfg.Database, *dryRun, logger)
+	if err != nil {
+		return err
+	}
+
+	verb := "Applied"
+	if *dryRun {
+		verb = "Pending"
+	}
+	if len(result) == 0 {
+		fmt.Println("Schema is up to date")
+	}
+	for _, m := range result {
+		fmt.Printf("%s %03d_%s (sha256 %s)\n", verb, m.Version, m.Name, m.Checksum)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/banking/audit-compliance/internal/config"
+	"github.com/banking/audit-compliance/internal/repository/postgres"
+	"github.com/banking/audit-compliance/internal/repository/s3"
+	"github.com/banking/audit-compliance/internal/service"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+// newPartitionService connects as the schema owner, since partition maintenance runs DDL.
+// The returned pool must be closed by the caller.
+func newPartitionService(ctx context.Context, cfg *config.Config, logger *zap.Logger) (*service.PartitionService, *pgxpool.Pool, error) {
+	ownerPool, err := pgxpool.New(ctx, cfg.Database.OwnerDSN())
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect as schema owner: %w", err)
+	}
+
+	var archive *s3.ArchiveRepository
+	if cfg.Compliance.EnableAutoArchive {
+		archive, err = s3.NewArchiveRepository(ctx, cfg.S3)
+		if err != nil {
+			ownerPool.Close()
+			return nil, nil, err
+		}
+	}
+
+	partitionService := service.NewPartitionService(postgres.NewPartitionManager(ownerPool), archive,
+		cfg.Compliance.PartitionMonthsAhead, cfg.Compliance.PartitionArchiveAfterMonths, logger)
+	return partitionService, ownerPool, nil
+}
+
+// partitionsCommand implements `server partitions`: one maintenance pass that creates
+// upcoming months, archives closed ones and detaches them. It holds owner credentials, so
+// it is meant to run as a scheduled job beside the server rather than inside it.
+func partitionsCommand(cfg *config.Config, args []string, logger *zap.Logger) error {
+	fs := flag.NewFlagSet("partitions", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	partitionService, ownerPool, err := newPartitionService(ctx, cfg, logger)
+	if err != nil {
+		return err
+	}
+	defer ownerPool.Close()
+
+	return partitionService.Maintain(ctx)
+}
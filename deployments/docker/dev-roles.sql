@@ -0,0 +1,20 @@
+-- Local development bootstrap, run once by the postgres superuser at initdb.
+-- Creates the owner and app roles with dev passwords and hands the database to the owner,
+-- which then applies migrations (`server migrate` or database.auto_migrate). Export the
+-- passwords below as AUDIT_DATABASE_PASSWORD and AUDIT_DATABASE_OWNER_PASSWORD.
+-- Production roles and credentials are provisioned by the platform, never from this file.
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT FROM pg_roles WHERE rolname = 'audit_owner') THEN
+        CREATE ROLE audit_owner LOGIN NOSUPERUSER NOCREATEDB NOCREATEROLE NOINHERIT;
+    END IF;
+    IF NOT EXISTS (SELECT FROM pg_roles WHERE rolname = 'audit_app') THEN
+        CREATE ROLE audit_app LOGIN NOSUPERUSER NOCREATEDB NOCREATEROLE NOINHERIT;
+    END IF;
+    EXECUTE format('ALTER DATABASE %I OWNER TO audit_owner', current_database());
+END
+$$;
+
+ALTER ROLE audit_owner WITH PASSWORD 'audit_owner';
+ALTER ROLE audit_app WITH PASSWORD 'audit_app';
+GRANT CREATE ON SCHEMA public TO audit_owner;
@@ -1,10 +1,15 @@
 package api
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/banking/audit-compliance/internal/auth"
 	"github.com/banking/audit-compliance/internal/domain"
+	"github.com/banking/audit-compliance/internal/schema"
 	"github.com/banking/audit-compliance/internal/service"
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
@@ -12,33 +17,139 @@ import (
 
 type AuditHandler struct {
 	auditService *service.AuditService
+	policy       *auth.Policy
+	eventSchema  *schema.Validator
 }
 
-func NewAuditHandler(auditService *service.AuditService) *AuditHandler {
+func NewAuditHandler(auditService *service.AuditService, policy *auth.Policy, eventSchema *schema.Validator) *AuditHandler {
 	return &AuditHandler{
 		auditService: auditService,
+		policy:       policy,
+		eventSchema:  eventSchema,
 	}
 }
 
+// role returns the caller's RBAC role, set by AuthMiddleware
+func role(c echo.Context) string {
+	id, _ := auth.FromContext(c.Request().Context())
+	return id.Role
+}
+
+// redactPage returns page with each event as role may see it, leaving page untouched
+func (h *AuditHandler) redactPage(role string, page *domain.AuditEventPage) *domain.AuditEventPage {
+	redacted := *page
+	redacted.Events = make([]*domain.AuditEvent, len(page.Events))
+	for i, event := range page.Events {
+		redacted.Events[i] = h.policy.Redact(role, event)
+	}
+	return &redacted
+}
+
 // GetAuditTrail handles GET /audit/transactions/:transaction_id
 func (h *AuditHandler) GetAuditTrail(c echo.Context) error {
-	txIDStr := c.Param("transaction_id")
-	txID, err := uuid.Parse(txIDStr)
+	txID, err := uuid.Parse(c.Param("transaction_id"))
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid transaction_id"})
 	}
+	return h.listEvents(c, func(f *domain.AuditEventFilter) { f.TransactionID = &txID })
+}
+
+// GetUserEvents handles GET /audit/users/:user_id/events
+func (h *AuditHandler) GetUserEvents(c echo.Context) error {
+	userID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid user_id"})
+	}
+	return h.listEvents(c, func(f *domain.AuditEventFilter) { f.UserID = &userID })
+}
 
-	filter := domain.AuditEventFilter{
-		TransactionID: &txID,
-		Limit:         100, // Defualt limit
+// GetActorEvents handles GET /audit/actors/:actor_id/events
+func (h *AuditHandler) GetActorEvents(c echo.Context) error {
+	actorID, err := uuid.Parse(c.Param("actor_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid actor_id"})
+	}
+	return h.listEvents(c, func(f *domain.AuditEventFilter) { f.ActorID = &actorID })
+}
+
+// GetResourceEvents handles GET /audit/resources/:resource_type/:resource_id/events
+func (h *AuditHandler) GetResourceEvents(c echo.Context) error {
+	resourceType := domain.ResourceType(strings.ToUpper(c.Param("resource_type")))
+	if !resourceType.IsValid() {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid resource_type"})
+	}
+	resourceID := c.Param("resource_id")
+	return h.listEvents(c, func(f *domain.AuditEventFilter) {
+		f.ResourceTypes = []domain.ResourceType{resourceType}
+		f.ResourceID = &resourceID
+	})
+}
+
+// ListEvents handles GET /audit/events, filtering on any AuditEventFilter field
+func (h *AuditHandler) ListEvents(c echo.Context) error {
+	return h.listEvents(c, nil)
+}
+
+// listEvents parses the filter query parameters, applies the route's scope on top so
+// it cannot be widened, and returns one page of verified events
+func (h *AuditHandler) listEvents(c echo.Context, scope func(*domain.AuditEventFilter)) error {
+	var filter domain.AuditEventFilter
+	if err := parseEventFilter(c, &filter); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	if scope != nil {
+		scope(&filter)
+	}
+	if err := h.policy.ScopeFilter(role(c), &filter); err != nil {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": err.Error()})
 	}
 
 	page, err := h.auditService.GetAuditTrail(c.Request().Context(), filter)
 	if err != nil {
+		if errors.Is(err, service.ErrInvalidFilter) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to retrieve audit trail"})
 	}
 
-	return c.JSON(http.StatusOK, page)
+	return c.JSON(http.StatusOK, h.redactPage(role(c), page))
+}
+
+// GetEvent handles GET /audit/events/:event_id
+func (h *AuditHandler) GetEvent(c echo.Context) error {
+	eventID, err := uuid.Parse(c.Param("event_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid event_id"})
+	}
+
+	event, err := h.auditService.GetEvent(c.Request().Context(), eventID)
+	if err != nil {
+		if errors.Is(err, service.ErrEventNotFound) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "event not found"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to retrieve event"})
+	}
+	// Out-of-scope events are reported as missing so their existence is not disclosed
+	if !h.policy.CanAccessResource(role(c), event.ResourceType) {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "event not found"})
+	}
+
+	return c.JSON(http.StatusOK, h.policy.Redact(role(c), event))
+}
+
+// SearchAccessLogs handles GET /audit/access-logs, e.g. ?purpose_code=CASE&purpose_reference=FRAUD-1234
+func (h *AuditHandler) SearchAccessLogs(c echo.Context) error {
+	var filter domain.AccessLogFilter
+	if err := parseAccessLogFilter(c, &filter); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	entries, err := h.auditService.SearchAccessLogs(c.Request().Context(), filter)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to search access logs"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"access_logs": entries})
 }
 
 // SearchEvents handles GET /audit/search
@@ -59,11 +170,177 @@ func (h *AuditHandler) SearchEvents(c echo.Context) error {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "search failed"})
 	}
 
-	return c.JSON(http.StatusOK, page)
+	// Free-text search cannot be scoped up front, so out-of-scope hits are dropped
+	scoped := *page
+	scoped.Events = nil
+	for _, event := range page.Events {
+		if h.policy.CanAccessResource(role(c), event.ResourceType) {
+			scoped.Events = append(scoped.Events, event)
+		}
+	}
+	return c.JSON(http.StatusOK, h.redactPage(role(c), &scoped))
+}
+
+// VerifyLedger handles GET /audit/integrity/verify
+func (h *AuditHandler) VerifyLedger(c echo.Context) error {
+	to := time.Now().UTC()
+	from := time.Time{}
+
+	if v := c.QueryParam("from"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid 'from', expected RFC3339"})
+		}
+		from = t
+	}
+	if v := c.QueryParam("to"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid 'to', expected RFC3339"})
+		}
+		to = t
+	}
+	if from.After(to) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "'from' must not be after 'to'"})
+	}
+
+	report, err := h.auditService.VerifyLedger(c.Request().Context(), from, to)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "ledger verification failed"})
+	}
+
+	return c.JSON(http.StatusOK, report)
 }
 
-// RegisterRoutes registers the API routes
+// GetEventAttestation handles GET /audit/events/:event_id/attestation
+func (h *AuditHandler) GetEventAttestation(c echo.Context) error {
+	eventID, err := uuid.Parse(c.Param("event_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid event_id"})
+	}
+	// The signed payload carries every field, so it cannot be shown to a redacted role
+	if h.policy.Redacts(role(c)) {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "role may not export unredacted events"})
+	}
+
+	attestation, err := h.auditService.GetEventAttestation(c.Request().Context(), eventID)
+	if err != nil {
+		if errors.Is(err, service.ErrEventNotFound) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "event not found"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to build attestation"})
+	}
+	if !h.policy.CanAccessResource(role(c), attestation.Event.ResourceType) {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "event not found"})
+	}
+
+	return c.JSON(http.StatusOK, attestation)
+}
+
+// GetCheckpoint handles GET /audit/checkpoints/latest and GET /audit/checkpoints/:tree_size
+func (h *AuditHandler) GetCheckpoint(c echo.Context) error {
+	var treeSize int64
+	if v := c.Param("tree_size"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n <= 0 {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid tree_size"})
+		}
+		treeSize = n
+	}
+
+	cp, err := h.auditService.GetCheckpoint(c.Request().Context(), treeSize)
+	if err != nil {
+		return proofError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, cp)
+}
+
+// GetInclusionProof handles GET /audit/proofs/inclusion/:event_id?tree_size=
+func (h *AuditHandler) GetInclusionProof(c echo.Context) error {
+	eventID, err := uuid.Parse(c.Param("event_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid event_id"})
+	}
+
+	var treeSize int64
+	if v := c.QueryParam("tree_size"); v != "" {
+		treeSize, err = strconv.ParseInt(v, 10, 64)
+		if err != nil || treeSize <= 0 {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid tree_size"})
+		}
+	}
+
+	proof, err := h.auditService.GetInclusionProof(c.Request().Context(), eventID, treeSize)
+	if err != nil {
+		return proofError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, proof)
+}
+
+// GetConsistencyProof handles GET /audit/proofs/consistency?first=&second=
+func (h *AuditHandler) GetConsistencyProof(c echo.Context) error {
+	first, err1 := strconv.ParseInt(c.QueryParam("first"), 10, 64)
+	second, err2 := strconv.ParseInt(c.QueryParam("second"), 10, 64)
+	if err1 != nil || err2 != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "'first' and 'second' tree sizes are required"})
+	}
+
+	proof, err := h.auditService.GetConsistencyProof(c.Request().Context(), first, second)
+	if err != nil {
+		return proofError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, proof)
+}
+
+// proofError maps checkpoint and proof errors to HTTP responses
+func proofError(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, service.ErrEventNotFound), errors.Is(err, service.ErrCheckpointNotFound):
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidProofRequest):
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	default:
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to build proof"})
+	}
+}
+
+// GetSigningKeys handles GET /.well-known/audit-signing-keys
+func (h *AuditHandler) GetSigningKeys(c echo.Context) error {
+	return c.JSON(http.StatusOK, h.auditService.PublicSigningKeys())
+}
+
+// RegisterPublicRoutes registers unauthenticated routes
+func (h *AuditHandler) RegisterPublicRoutes(e *echo.Echo) {
+	e.GET("/.well-known/audit-signing-keys", h.GetSigningKeys)
+}
+
+// RegisterRoutes registers the API routes, each behind the permission it requires.
+// The group must run AuthMiddleware first.
 func (h *AuditHandler) RegisterRoutes(e *echo.Group) {
-	e.GET("/transactions/:transaction_id", h.GetAuditTrail)
-	e.GET("/search", h.SearchEvents)
+	write := requirePermission(h.policy, auth.PermissionWriteEvents)
+	read := requirePermission(h.policy, auth.PermissionReadEvents)
+	search := requirePermission(h.policy, auth.PermissionSearchEvents)
+	export := requirePermission(h.policy, auth.PermissionExportEvents)
+	verify := requirePermission(h.policy, auth.PermissionVerifyLedger)
+	accessLogs := requirePermission(h.policy, auth.PermissionReadAccessLogs)
+
+	e.POST("/events", h.RecordEvent, write)
+	e.POST("/events/batch", h.RecordEvents, write)
+	e.GET("/transactions/:transaction_id", h.GetAuditTrail, read)
+	e.GET("/users/:user_id/events", h.GetUserEvents, read)
+	e.GET("/actors/:actor_id/events", h.GetActorEvents, read)
+	e.GET("/resources/:resource_type/:resource_id/events", h.GetResourceEvents, read)
+	e.GET("/events", h.ListEvents, read)
+	e.GET("/events/:event_id", h.GetEvent, read)
+	e.GET("/access-logs", h.SearchAccessLogs, accessLogs)
+	e.GET("/search", h.SearchEvents, search)
+	e.GET("/integrity/verify", h.VerifyLedger, verify)
+	e.GET("/events/:event_id/attestation", h.GetEventAttestation, export)
+	e.GET("/checkpoints/latest", h.GetCheckpoint, verify)
+	e.GET("/checkpoints/:tree_size", h.GetCheckpoint, verify)
+	e.GET("/proofs/inclusion/:event_id", h.GetInclusionProof, verify)
+	e.GET("/proofs/consistency", h.GetConsistencyProof, verify)
 }
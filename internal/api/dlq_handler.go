@@ -0,0 +1,60 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/banking/audit-compliance/internal/auth"
+	"github.com/banking/audit-compliance/internal/events"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// maxReplayBatch bounds how many dead letters one request replays
+const maxReplayBatch = 100
+
+type DLQHandler struct {
+	replayer *events.Replayer
+	policy   *auth.Policy
+	logger   *zap.Logger
+}
+
+func NewDLQHandler(replayer *events.Replayer, policy *auth.Policy, logger *zap.Logger) *DLQHandler {
+	return &DLQHandler{
+		replayer: replayer,
+		policy:   policy,
+		logger:   logger,
+	}
+}
+
+// ReplayDeadLetters handles POST /audit/admin/dlq/replay with a body of {"dlq_ids": [...]}
+func (h *DLQHandler) ReplayDeadLetters(c echo.Context) error {
+	var req struct {
+		DLQIDs []uuid.UUID `json:"dlq_ids"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "body must be a JSON object with a 'dlq_ids' array of UUIDs"})
+	}
+	if len(req.DLQIDs) == 0 || len(req.DLQIDs) > maxReplayBatch {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("between 1 and %d dlq_ids are required", maxReplayBatch)})
+	}
+
+	id, _ := auth.FromContext(c.Request().Context())
+	h.logger.Info("Dead letter replay requested",
+		zap.String("subject", id.Subject),
+		zap.String("purpose", id.Purpose.String()),
+		zap.Int("count", len(req.DLQIDs)),
+	)
+
+	results, err := h.replayer.Replay(c.Request().Context(), req.DLQIDs)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to replay dead letters"})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"results": results})
+}
+
+// RegisterRoutes registers the admin routes. The group must run AuthMiddleware first.
+func (h *DLQHandler) RegisterRoutes(e *echo.Group) {
+	e.POST("/admin/dlq/replay", h.ReplayDeadLetters, requirePermission(h.policy, auth.PermissionReplayDLQ))
+}
@@ -0,0 +1,190 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/banking/audit-compliance/internal/domain"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	defaultPageSize = 100
+	maxPageSize     = 1000
+)
+
+// parseEventFilter maps query parameters onto filter. List parameters accept repeated
+// keys or comma-separated values. Offset paging is not exposed; clients follow next_cursor.
+func parseEventFilter(c echo.Context, filter *domain.AuditEventFilter) error {
+	var err error
+	if filter.EventID, err = uuidParam(c, "event_id"); err != nil {
+		return err
+	}
+	if filter.UserID, err = uuidParam(c, "user_id"); err != nil {
+		return err
+	}
+	if filter.ActorID, err = uuidParam(c, "actor_id"); err != nil {
+		return err
+	}
+	if filter.TransactionID, err = uuidParam(c, "transaction_id"); err != nil {
+		return err
+	}
+
+	for _, v := range listParam(c, "action_type") {
+		action := domain.ActionType(strings.ToUpper(v))
+		if !action.IsValid() {
+			return fmt.Errorf("invalid 'action_type' %q", v)
+		}
+		filter.ActionTypes = append(filter.ActionTypes, action)
+	}
+	for _, v := range listParam(c, "resource_type") {
+		resource := domain.ResourceType(strings.ToUpper(v))
+		if !resource.IsValid() {
+			return fmt.Errorf("invalid 'resource_type' %q", v)
+		}
+		filter.ResourceTypes = append(filter.ResourceTypes, resource)
+	}
+	if v := c.QueryParam("result"); v != "" {
+		result := domain.AuditResult(strings.ToUpper(v))
+		if !result.IsValid() {
+			return fmt.Errorf("invalid 'result' %q", v)
+		}
+		filter.Result = &result
+	}
+
+	filter.ResourceID = stringParam(c, "resource_id")
+	filter.ServiceSource = stringParam(c, "service_source")
+	filter.RetentionCategory = stringParam(c, "retention_category")
+	if filter.IPAddress = stringParam(c, "ip_address"); filter.IPAddress != nil && net.ParseIP(*filter.IPAddress) == nil {
+		return errors.New("invalid 'ip_address'")
+	}
+	filter.ComplianceFlags = listParam(c, "compliance_flag")
+
+	if v := c.QueryParam("metadata"); v != "" {
+		if err := json.Unmarshal([]byte(v), &filter.MetadataContains); err != nil || filter.MetadataContains == nil {
+			return errors.New("invalid 'metadata', expected a JSON object")
+		}
+	}
+	filter.MetadataPath = stringParam(c, "metadata_path")
+
+	if filter.StartTime, err = timeParam(c, "from"); err != nil {
+		return err
+	}
+	if filter.EndTime, err = timeParam(c, "to"); err != nil {
+		return err
+	}
+	if filter.StartTime != nil && filter.EndTime != nil && filter.StartTime.After(*filter.EndTime) {
+		return errors.New("'from' must not be after 'to'")
+	}
+
+	return parsePagination(c, filter)
+}
+
+// parsePagination applies the limit, cursor and count query parameters to filter
+func parsePagination(c echo.Context, filter *domain.AuditEventFilter) error {
+	filter.Limit = defaultPageSize
+	if v := c.QueryParam("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > maxPageSize {
+			return fmt.Errorf("invalid 'limit', expected 1-%d", maxPageSize)
+		}
+		filter.Limit = n
+	}
+	if v := c.QueryParam("cursor"); v != "" {
+		cursor, err := domain.DecodeEventCursor(v)
+		if err != nil {
+			return errors.New("invalid 'cursor'")
+		}
+		filter.Cursor = cursor
+	}
+	count, ok := domain.ParseCountMode(c.QueryParam("count"))
+	if !ok {
+		return errors.New("invalid 'count', expected none, exact or estimated")
+	}
+	filter.Count = count
+	return nil
+}
+
+// parseAccessLogFilter maps query parameters onto an access log search
+func parseAccessLogFilter(c echo.Context, filter *domain.AccessLogFilter) error {
+	var err error
+	if filter.AccessorID, err = uuidParam(c, "accessor_id"); err != nil {
+		return err
+	}
+	if v := stringParam(c, "access_type"); v != nil {
+		accessType := strings.ToUpper(*v)
+		filter.AccessType = &accessType
+	}
+	if v := stringParam(c, "purpose_code"); v != nil {
+		code := strings.ToUpper(*v)
+		filter.PurposeCode = &code
+	}
+	filter.PurposeReference = stringParam(c, "purpose_reference")
+	if filter.StartTime, err = timeParam(c, "from"); err != nil {
+		return err
+	}
+	if filter.EndTime, err = timeParam(c, "to"); err != nil {
+		return err
+	}
+
+	filter.Limit = defaultPageSize
+	if v := c.QueryParam("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > maxPageSize {
+			return fmt.Errorf("invalid 'limit', expected 1-%d", maxPageSize)
+		}
+		filter.Limit = n
+	}
+	return nil
+}
+
+func uuidParam(c echo.Context, name string) (*uuid.UUID, error) {
+	v := c.QueryParam(name)
+	if v == "" {
+		return nil, nil
+	}
+	id, err := uuid.Parse(v)
+	if err != nil {
+		return nil, fmt.Errorf("invalid '%s'", name)
+	}
+	return &id, nil
+}
+
+func timeParam(c echo.Context, name string) (*time.Time, error) {
+	v := c.QueryParam(name)
+	if v == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return nil, fmt.Errorf("invalid '%s', expected RFC3339", name)
+	}
+	return &t, nil
+}
+
+func stringParam(c echo.Context, name string) *string {
+	v := c.QueryParam(name)
+	if v == "" {
+		return nil
+	}
+	return &v
+}
+
+// listParam collects repeated and comma-separated values of a query parameter
+func listParam(c echo.Context, name string) []string {
+	var values []string
+	for _, v := range c.QueryParams()[name] {
+		for _, part := range strings.Split(v, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				values = append(values, part)
+			}
+		}
+	}
+	return values
+}
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/banking/audit-compliance/internal/domain"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func queryContext(query url.Values) echo.Context {
+	req := httptest.NewRequest("GET", "/audit/events?"+query.Encode(), nil)
+	return echo.New().NewContext(req, httptest.NewRecorder())
+}
+
+func TestParseEventFilter(t *testing.T) {
+	actorID := uuid.New()
+	c := queryContext(url.Values{
+		"actor_id":        {actorID.String()},
+		"action_type":     {"login,logout", "TRANSFER"},
+		"result":          {"failure"},
+		"ip_address":      {"10.0.0.1"},
+		"compliance_flag": {"SAR", "PEP"},
+		"metadata":        {`{"channel":"mobile"}`},
+		"metadata_path":   {"$.amount_cents > 1000000"},
+		"from":            {"2024-01-01T00:00:00Z"},
+		"limit":           {"50"},
+		"count":           {"estimated"},
+	})
+
+	var filter domain.AuditEventFilter
+	require.NoError(t, parseEventFilter(c, &filter))
+	assert.Equal(t, actorID, *filter.ActorID)
+	assert.Equal(t, []domain.ActionType{domain.ActionTypeLogin, domain.ActionTypeLogout, domain.ActionTypeTransfer}, filter.ActionTypes)
+	assert.Equal(t, domain.AuditResultFailure, *filter.Result)
+	assert.Equal(t, []string{"SAR", "PEP"}, filter.ComplianceFlags)
+	assert.Equal(t, map[string]interface{}{"channel": "mobile"}, filter.MetadataContains)
+	assert.Equal(t, "$.amount_cents > 1000000", *filter.MetadataPath)
+	assert.Nil(t, filter.EndTime)
+	assert.Equal(t, 50, filter.Limit)
+	assert.Equal(t, domain.CountEstimated, filter.Count)
+}
+
+func TestParseEventFilterRejectsInvalidValues(t *testing.T) {
+	for name, query := range map[string]url.Values{
+		"user id":       {"user_id": {"not-a-uuid"}},
+		"action type":   {"action_type": {"LOGIN,HACK"}},
+		"result":        {"result": {"MAYBE"}},
+		"ip address":    {"ip_address": {"localhost"}},
+		"metadata":      {"metadata": {`["not","an","object"]`}},
+		"time":          {"from": {"yesterday"}},
+		"reversed time": {"from": {"2024-02-01T00:00:00Z"}, "to": {"2024-01-01T00:00:00Z"}},
+		"limit cap":     {"limit": {"5000"}},
+		"cursor":        {"cursor": {"garbage"}},
+		"count":         {"count": {"all"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var filter domain.AuditEventFilter
+			assert.Error(t, parseEventFilter(queryContext(query), &filter))
+		})
+	}
+}
@@ -0,0 +1,204 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/banking/audit-compliance/internal/domain"
+	"github.com/banking/audit-compliance/internal/schema"
+	"github.com/banking/audit-compliance/internal/service"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// idempotencyKeyHeader makes a write safe to retry
+	idempotencyKeyHeader = "Idempotency-Key"
+	// replayedHeader marks a response that returns events recorded by an earlier request
+	replayedHeader = "Idempotent-Replayed"
+	// maxIdempotencyKeyLen matches the idempotency_keys column
+	maxIdempotencyKeyLen = 255
+
+	maxEventBodyBytes = 256 << 10
+	maxBatchBodyBytes = 8 << 20
+	maxBatchEvents    = 500
+)
+
+// recordEventRequest is an event as submitted, after validation against the
+// audit_event.v1 schema
+type recordEventRequest struct {
+	EventID           *uuid.UUID      `json:"event_id"`
+	TransactionID     *uuid.UUID      `json:"transaction_id"`
+	UserID            uuid.UUID       `json:"user_id"`
+	ActorID           *uuid.UUID      `json:"actor_id"`
+	ActionType        string          `json:"action_type"`
+	ResourceType      string          `json:"resource_type"`
+	ResourceID        string          `json:"resource_id"`
+	ServiceSource     string          `json:"service_source"`
+	Timestamp         *time.Time      `json:"timestamp"`
+	Result            string          `json:"result"`
+	FailureReason     *string         `json:"failure_reason"`
+	IPAddress         string          `json:"ip_address"`
+	Geolocation       *string         `json:"geolocation"`
+	UserAgent         *string         `json:"user_agent"`
+	RequestID         string          `json:"request_id"`
+	SessionID         *string         `json:"session_id"`
+	Metadata          json.RawMessage `json:"metadata"`
+	ComplianceFlags   []string        `json:"compliance_flags"`
+	RetentionCategory string          `json:"retention_category"`
+}
+
+// event maps the request onto the domain model with the same defaults as the gRPC
+// and Kafka paths: SUCCESS and STANDARD retention
+func (r *recordEventRequest) event() (*domain.AuditEvent, error) {
+	event := &domain.AuditEvent{
+		TransactionID:     r.TransactionID,
+		UserID:            r.UserID,
+		ActorID:           r.ActorID,
+		ActionType:        domain.ActionType(r.ActionType),
+		ResourceType:      domain.ResourceType(r.ResourceType),
+		ResourceID:        r.ResourceID,
+		ServiceSource:     r.ServiceSource,
+		Result:            domain.AuditResult(r.Result),
+		FailureReason:     r.FailureReason,
+		IPAddress:         r.IPAddress,
+		Geolocation:       r.Geolocation,
+		UserAgent:         r.UserAgent,
+		RequestID:         r.RequestID,
+		SessionID:         r.SessionID,
+		Metadata:          r.Metadata,
+		ComplianceFlags:   r.ComplianceFlags,
+		RetentionCategory: r.RetentionCategory,
+	}
+	if r.EventID != nil {
+		event.EventID = *r.EventID
+	}
+	if r.Timestamp != nil {
+		event.Timestamp = *r.Timestamp
+	}
+	if event.Result == "" {
+		event.Result = domain.AuditResultSuccess
+	}
+	if event.RetentionCategory == "" {
+		event.RetentionCategory = "STANDARD"
+	}
+	if err := event.Validate(); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+// parseEvent validates one submitted event against the schema and maps it
+func (h *AuditHandler) parseEvent(payload []byte) (*domain.AuditEvent, error) {
+	if err := h.eventSchema.Validate(payload); err != nil {
+		return nil, err
+	}
+	var req recordEventRequest
+	if err := json.Unmarshal(payload, &req); err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidEvent, err)
+	}
+	return req.event()
+}
+
+// RecordEvent handles POST /audit/events
+func (h *AuditHandler) RecordEvent(c echo.Context) error {
+	body, err := readBody(c, maxEventBodyBytes)
+	if err != nil {
+		return bodyError(c, err)
+	}
+	event, err := h.parseEvent(body)
+	if err != nil {
+		return invalidEvent(c, err)
+	}
+
+	return h.recordEvents(c, body, []*domain.AuditEvent{event}, func(recorded []*domain.AuditEvent) interface{} {
+		return recorded[0]
+	})
+}
+
+// RecordEvents handles POST /audit/events/batch with a body of {"events": [...]}. Events
+// are recorded in order; each is validated before any is stored.
+func (h *AuditHandler) RecordEvents(c echo.Context) error {
+	body, err := readBody(c, maxBatchBodyBytes)
+	if err != nil {
+		return bodyError(c, err)
+	}
+	var batch struct {
+		Events []json.RawMessage `json:"events"`
+	}
+	if err := json.Unmarshal(body, &batch); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "body must be a JSON object with an 'events' array"})
+	}
+	if len(batch.Events) == 0 || len(batch.Events) > maxBatchEvents {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("a batch must hold between 1 and %d events", maxBatchEvents)})
+	}
+
+	events := make([]*domain.AuditEvent, len(batch.Events))
+	for i, payload := range batch.Events {
+		if events[i], err = h.parseEvent(payload); err != nil {
+			return invalidEvent(c, fmt.Errorf("events[%d]: %w", i, err))
+		}
+	}
+
+	return h.recordEvents(c, body, events, func(recorded []*domain.AuditEvent) interface{} {
+		return map[string]interface{}{"events": recorded}
+	})
+}
+
+// recordEvents stores events under the request's idempotency key, if any, and responds
+// with response(recorded): 201, or 200 when the request replays an earlier one
+func (h *AuditHandler) recordEvents(c echo.Context, body []byte, events []*domain.AuditEvent, response func([]*domain.AuditEvent) interface{}) error {
+	key := c.Request().Header.Get(idempotencyKeyHeader)
+	if len(key) > maxIdempotencyKeyLen {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("%s must be at most %d characters", idempotencyKeyHeader, maxIdempotencyKeyLen)})
+	}
+	sum := sha256.Sum256(body)
+
+	recorded, replayed, err := h.auditService.RecordEvents(c.Request().Context(), key, hex.EncodeToString(sum[:]), events)
+	switch {
+	case err == nil:
+	case errors.Is(err, domain.ErrInvalidEvent):
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	case errors.Is(err, service.ErrIdempotencyKeyReused):
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": idempotencyKeyHeader + " was already used for a different request"})
+	case errors.Is(err, service.ErrDuplicateEvent):
+		return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+	default:
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to record event"})
+	}
+
+	if replayed {
+		c.Response().Header().Set(replayedHeader, "true")
+		return c.JSON(http.StatusOK, response(recorded))
+	}
+	return c.JSON(http.StatusCreated, response(recorded))
+}
+
+// invalidEvent reports a schema or field validation failure, listing schema violations
+func invalidEvent(c echo.Context, err error) error {
+	var verr *schema.ValidationError
+	if errors.As(err, &verr) {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error(), "violations": verr.Violations})
+	}
+	return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+}
+
+// readBody reads at most limit bytes of the request body
+func readBody(c echo.Context, limit int64) ([]byte, error) {
+	return io.ReadAll(http.MaxBytesReader(c.Response(), c.Request().Body, limit))
+}
+
+// bodyError reports a request body that could not be read
+func bodyError(c echo.Context, err error) error {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return c.JSON(http.StatusRequestEntityTooLarge, map[string]string{"error": fmt.Sprintf("request body exceeds %d bytes", tooLarge.Limit)})
+	}
+	return c.JSON(http.StatusBadRequest, map[string]string{"error": "failed to read request body"})
+}
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/banking/audit-compliance/internal/schema"
+	"github.com/banking/audit-compliance/schemas"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRecordEventValidation covers requests refused before anything is stored
+func TestRecordEventValidation(t *testing.T) {
+	validator, err := schema.NewValidator(schemas.FS, "audit_event.v1.json")
+	require.NoError(t, err)
+	h := NewAuditHandler(nil, nil, validator)
+
+	const valid = `{"user_id":"7f1c2e4a-9b3d-4c5e-8a6f-1b2c3d4e5f60","action_type":"LOGIN","resource_type":"USER","resource_id":"u-1"}`
+
+	tests := []struct {
+		name      string
+		handler   echo.HandlerFunc
+		body      string
+		status    int
+		violation string
+	}{
+		{"missing required field", h.RecordEvent, `{"action_type":"LOGIN","resource_type":"USER","resource_id":"u-1"}`, http.StatusBadRequest, ""},
+		{"unknown action", h.RecordEvent, strings.Replace(valid, "LOGIN", "TELEPORT", 1), http.StatusBadRequest, "/action_type"},
+		{"unknown field", h.RecordEvent, strings.Replace(valid, `"resource_id"`, `"colour":"red","resource_id"`, 1), http.StatusBadRequest, ""},
+		{"metadata not an object", h.RecordEvent, strings.Replace(valid, `}`, `,"metadata":[1]}`, 1), http.StatusBadRequest, "/metadata"},
+		{"not JSON", h.RecordEvent, `{"user_id":`, http.StatusBadRequest, ""},
+		{"body too large", h.RecordEvent, `{"resource_id":"` + strings.Repeat("x", maxEventBodyBytes) + `"}`, http.StatusRequestEntityTooLarge, ""},
+		{"batch without events", h.RecordEvents, `{"events":[]}`, http.StatusBadRequest, ""},
+		{"batch not an object", h.RecordEvents, `[` + valid + `]`, http.StatusBadRequest, ""},
+		{"batch with one invalid event", h.RecordEvents, `{"events":[` + valid + `,{"user_id":"nope"}]}`, http.StatusBadRequest, "/user_id"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/audit/events", strings.NewReader(tt.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			require.NoError(t, tt.handler(echo.New().NewContext(req, rec)))
+			assert.Equal(t, tt.status, rec.Code, rec.Body.String())
+
+			if tt.violation != "" {
+				var body struct {
+					Violations []schema.Violation `json:"violations"`
+				}
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+				fields := make([]string, len(body.Violations))
+				for i, v := range body.Violations {
+					fields[i] = v.Field
+				}
+				assert.Contains(t, fields, tt.violation)
+			}
+		})
+	}
+}
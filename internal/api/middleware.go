@@ -0,0 +1,109 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/banking/audit-compliance/internal/auth"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// purposeHeader carries the purpose of access, e.g. "CASE:FRAUD-1234"
+	purposeHeader = "X-Access-Purpose"
+	// maxPurposeBodyBytes bounds how much of a JSON body is read to find a purpose field
+	maxPurposeBodyBytes = 1 << 20
+)
+
+// AuthMiddleware authenticates every request and attaches the caller's identity, with its
+// role resolved by policy, to the request context so the service layer can record every
+// read of audit data against it. Requests without a valid credential are refused.
+func AuthMiddleware(authenticator *auth.Authenticator, policy *auth.Policy) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			id, err := authenticator.Authenticate(req)
+			if err != nil {
+				c.Response().Header().Set(echo.HeaderWWWAuthenticate, "Bearer")
+				return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthenticated"})
+			}
+			id.Role = policy.ResolveRole(id.Roles)
+			id.IPAddress = c.RealIP()
+
+			c.SetRequest(req.WithContext(auth.WithIdentity(req.Context(), id)))
+			return next(c)
+		}
+	}
+}
+
+// requirePermission refuses the route to callers whose role lacks perm
+func requirePermission(policy *auth.Policy, perm auth.Permission) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			id, ok := auth.FromContext(c.Request().Context())
+			if !ok {
+				return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthenticated"})
+			}
+			if !policy.Allows(id.Role, perm) {
+				return c.JSON(http.StatusForbidden, map[string]string{"error": "role may not " + string(perm)})
+			}
+			return next(c)
+		}
+	}
+}
+
+// PurposeMiddleware requires a purpose-of-access justification, from the X-Access-Purpose
+// header or a "purpose" field of a JSON body, and attaches it to the caller's identity.
+// Callers whose role is exempt may omit it. Must run after AuthMiddleware.
+func PurposeMiddleware(policy *auth.PurposePolicy) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			id, ok := auth.FromContext(req.Context())
+			if !ok {
+				return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthenticated"})
+			}
+
+			value := req.Header.Get(purposeHeader)
+			if value == "" {
+				value = bodyPurpose(req)
+			}
+			purpose, err := policy.Resolve(value, id.Role)
+			if errors.Is(err, auth.ErrPurposeRequired) {
+				return c.JSON(http.StatusForbidden, map[string]string{"error": "an " + purposeHeader + " header with a case or ticket reference is required"})
+			}
+			if err != nil {
+				return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+			}
+
+			id.Purpose = purpose
+			c.SetRequest(req.WithContext(auth.WithIdentity(req.Context(), id)))
+			return next(c)
+		}
+	}
+}
+
+// bodyPurpose reads the purpose field of a JSON request body and restores the body for
+// the handler
+func bodyPurpose(req *http.Request) string {
+	if req.Body == nil || !strings.HasPrefix(req.Header.Get(echo.HeaderContentType), echo.MIMEApplicationJSON) {
+		return ""
+	}
+	body, err := io.ReadAll(io.LimitReader(req.Body, maxPurposeBodyBytes))
+	req.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), req.Body))
+	if err != nil {
+		return ""
+	}
+
+	var payload struct {
+		Purpose string `json:"purpose"`
+	}
+	if json.Unmarshal(body, &payload) != nil {
+		return ""
+	}
+	return payload.Purpose
+}
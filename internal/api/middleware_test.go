@@ -0,0 +1,63 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/banking/audit-compliance/internal/auth"
+	"github.com/banking/audit-compliance/internal/config"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPurposeMiddleware(t *testing.T) {
+	policy := auth.NewPurposePolicy(config.PurposeConfig{Codes: []string{"CASE"}, ExemptRoles: []string{"SERVICE"}})
+
+	serve := func(role string, req *http.Request) (*httptest.ResponseRecorder, auth.Purpose) {
+		var seen auth.Purpose
+		handler := PurposeMiddleware(policy)(func(c echo.Context) error {
+			id, _ := auth.FromContext(c.Request().Context())
+			seen = id.Purpose
+			return c.NoContent(http.StatusOK)
+		})
+		req = req.WithContext(auth.WithIdentity(req.Context(), auth.Identity{Subject: "analyst", Role: role}))
+		rec := httptest.NewRecorder()
+		_ = handler(echo.New().NewContext(req, rec))
+		return rec, seen
+	}
+
+	t.Run("header", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/audit/events", nil)
+		req.Header.Set(purposeHeader, "CASE:FRAUD-1234")
+		rec, purpose := serve("AUDITOR", req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "CASE:FRAUD-1234", purpose.String())
+	})
+
+	t.Run("json body field", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/audit/events", strings.NewReader(`{"purpose":"CASE:FRAUD-9"}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec, purpose := serve("AUDITOR", req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "CASE:FRAUD-9", purpose.String())
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		rec, _ := serve("AUDITOR", httptest.NewRequest(http.MethodGet, "/audit/events", nil))
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+
+	t.Run("missing but exempt", func(t *testing.T) {
+		rec, _ := serve("SERVICE", httptest.NewRequest(http.MethodGet, "/audit/events", nil))
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("unknown code", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/audit/events", nil)
+		req.Header.Set(purposeHeader, "FUN:1")
+		rec, _ := serve("AUDITOR", req)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
@@ -0,0 +1,186 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/banking/audit-compliance/internal/config"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ErrUnauthenticated is returned when a request carries no valid credential
+var ErrUnauthenticated = errors.New("unauthenticated")
+
+// APIKeyHeader carries a service API key
+const APIKeyHeader = "X-API-Key"
+
+// TokenVerifier validates a bearer token and returns its claims
+type TokenVerifier interface {
+	Verify(token string) (jwt.MapClaims, error)
+}
+
+type serviceKey struct {
+	id        string
+	hash      []byte
+	subject   string
+	roles     []string
+	expiresAt time.Time // Zero never expires
+}
+
+// Authenticator establishes the caller of a request from a bearer token, a service API
+// key or a verified client certificate. It fails closed: without a valid credential the
+// request is refused, unless insecure dev mode is explicitly enabled.
+type Authenticator struct {
+	tokens  TokenVerifier
+	keys    []serviceKey
+	certs   map[string][]string // Certificate subject -> roles
+	devMode bool
+	devRole string
+	now     func() time.Time
+}
+
+// NewAuthenticator creates an authenticator. tokens may be nil when JWT authentication is
+// not configured; at least one method must be, unless cfg.InsecureDevMode is set.
+func NewAuthenticator(cfg config.AuthConfig, tokens TokenVerifier) (*Authenticator, error) {
+	a := &Authenticator{
+		tokens:  tokens,
+		certs:   map[string][]string{},
+		devMode: cfg.InsecureDevMode,
+		devRole: cfg.DevRole,
+		now:     time.Now,
+	}
+
+	keys := cfg.ServiceAPIKeys
+	if cfg.ServiceAPIKey != "" {
+		keys = append(keys, config.ServiceAPIKeyConfig{ID: "default", SHA256: cfg.ServiceAPIKey, Subject: "service", Roles: []string{"SERVICE"}})
+	}
+	seen := map[string]bool{}
+	for _, k := range keys {
+		if k.ID == "" || seen[k.ID] {
+			return nil, fmt.Errorf("service API key IDs must be unique and non-empty (got %q)", k.ID)
+		}
+		seen[k.ID] = true
+
+		hash, err := hex.DecodeString(k.SHA256)
+		if err != nil || len(hash) != sha256.Size {
+			return nil, fmt.Errorf("service API key %s: sha256 must be a hex SHA-256 digest, not the key itself", k.ID)
+		}
+		key := serviceKey{id: k.ID, hash: hash, subject: k.Subject, roles: k.Roles}
+		if key.subject == "" {
+			key.subject = "service:" + k.ID
+		}
+		if k.ExpiresAt != "" {
+			if key.expiresAt, err = time.Parse(time.RFC3339, k.ExpiresAt); err != nil {
+				return nil, fmt.Errorf("service API key %s: invalid expires_at: %w", k.ID, err)
+			}
+		}
+		a.keys = append(a.keys, key)
+	}
+
+	for _, ci := range cfg.ClientCertIdentities {
+		if ci.Subject == "" {
+			return nil, errors.New("client certificate identity without a subject")
+		}
+		a.certs[ci.Subject] = ci.Roles
+	}
+
+	if tokens == nil && len(a.keys) == 0 && len(a.certs) == 0 && !a.devMode {
+		return nil, errors.New("no authentication method configured; set auth.insecure_dev_mode only for local development")
+	}
+	return a, nil
+}
+
+// DevMode reports whether unauthenticated callers are let through
+func (a *Authenticator) DevMode() bool {
+	return a.devMode
+}
+
+// Credentials are what a caller presented, independent of transport
+type Credentials struct {
+	Authorization string               // Authorization header or metadata value
+	APIKey        string               // X-API-Key header or metadata value
+	TLS           *tls.ConnectionState // Nil on plaintext connections
+}
+
+// Authenticate returns the caller of an HTTP request
+func (a *Authenticator) Authenticate(r *http.Request) (Identity, error) {
+	return a.AuthenticateCredentials(Credentials{
+		Authorization: r.Header.Get("Authorization"),
+		APIKey:        r.Header.Get(APIKeyHeader),
+		TLS:           r.TLS,
+	})
+}
+
+// AuthenticateCredentials returns the caller's identity. A presented credential that
+// fails is an error even when another method could have succeeded.
+func (a *Authenticator) AuthenticateCredentials(creds Credentials) (Identity, error) {
+	if creds.Authorization != "" {
+		token, ok := strings.CutPrefix(creds.Authorization, "Bearer ")
+		if !ok || a.tokens == nil {
+			return Identity{}, fmt.Errorf("%w: unsupported authorization scheme", ErrUnauthenticated)
+		}
+		claims, err := a.tokens.Verify(strings.TrimSpace(token))
+		if err != nil {
+			return Identity{}, fmt.Errorf("%w: %v", ErrUnauthenticated, err)
+		}
+		return IdentityFromClaims(claims), nil
+	}
+
+	if creds.APIKey != "" {
+		return a.authenticateKey(creds.APIKey)
+	}
+
+	if creds.TLS != nil && len(creds.TLS.VerifiedChains) > 0 {
+		return a.authenticateCert(creds.TLS)
+	}
+
+	if a.devMode {
+		return Identity{Subject: "dev", AccessorID: AccessorID("dev"), Roles: []string{a.devRole}}, nil
+	}
+	return Identity{}, ErrUnauthenticated
+}
+
+func (a *Authenticator) authenticateKey(key string) (Identity, error) {
+	sum := sha256.Sum256([]byte(key))
+	now := a.now()
+
+	// Compare against every key so timing does not reveal which one nearly matched
+	var match *serviceKey
+	for i := range a.keys {
+		if subtle.ConstantTimeCompare(sum[:], a.keys[i].hash) == 1 {
+			match = &a.keys[i]
+		}
+	}
+	if match == nil {
+		return Identity{}, fmt.Errorf("%w: unknown API key", ErrUnauthenticated)
+	}
+	if !match.expiresAt.IsZero() && now.After(match.expiresAt) {
+		return Identity{}, fmt.Errorf("%w: API key %s expired", ErrUnauthenticated, match.id)
+	}
+	return Identity{Subject: match.subject, AccessorID: AccessorID(match.subject), Roles: match.roles}, nil
+}
+
+// authenticateCert maps a client certificate, already verified against the client CA
+// during the handshake, to an identity by common name or full subject DN
+func (a *Authenticator) authenticateCert(state *tls.ConnectionState) (Identity, error) {
+	leaf := state.VerifiedChains[0][0]
+	for _, subject := range []string{leaf.Subject.CommonName, leaf.Subject.String()} {
+		if roles, ok := a.certs[subject]; ok && subject != "" {
+			return Identity{Subject: "cert:" + subject, AccessorID: AccessorID("cert:" + subject), Roles: roles}, nil
+		}
+	}
+	return Identity{}, fmt.Errorf("%w: client certificate %q is not mapped to an identity", ErrUnauthenticated, leaf.Subject.String())
+}
+
+// HashAPIKey returns the hex SHA-256 digest configured for key
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,118 @@
+package auth_test
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/banking/audit-compliance/internal/auth"
+	"github.com/banking/audit-compliance/internal/config"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeVerifier map[string]jwt.MapClaims
+
+func (f fakeVerifier) Verify(token string) (jwt.MapClaims, error) {
+	if claims, ok := f[token]; ok {
+		return claims, nil
+	}
+	return nil, errors.New("bad signature")
+}
+
+func clientCertRequest(cn string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/audit/events", nil)
+	leaf := &x509.Certificate{Subject: pkix.Name{CommonName: cn, Organization: []string{"Bank"}}}
+	req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{leaf}}}
+	return req
+}
+
+func TestAuthenticator(t *testing.T) {
+	cfg := config.AuthConfig{
+		ServiceAPIKey: auth.HashAPIKey("legacy-key"),
+		ServiceAPIKeys: []config.ServiceAPIKeyConfig{
+			{ID: "ledger-2026a", SHA256: auth.HashAPIKey("old-key"), Subject: "ledger-service", Roles: []string{"SERVICE"}, ExpiresAt: time.Now().Add(-time.Hour).Format(time.RFC3339)},
+			{ID: "ledger-2026b", SHA256: auth.HashAPIKey("new-key"), Subject: "ledger-service", Roles: []string{"SERVICE"}},
+			{ID: "aml-1", SHA256: auth.HashAPIKey("aml-key"), Roles: []string{"AML_ANALYST"}},
+		},
+		ClientCertIdentities: []config.ClientCertIdentityConfig{
+			{Subject: "reporting.bank.internal", Roles: []string{"AUDITOR"}},
+		},
+	}
+	tokens := fakeVerifier{"good": {"sub": "alice", "role": "AUDITOR"}}
+	authenticator, err := auth.NewAuthenticator(cfg, tokens)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		req     func() *http.Request
+		subject string
+		roles   []string
+		fails   bool
+	}{
+		{name: "no credentials", req: func() *http.Request { return httptest.NewRequest(http.MethodGet, "/audit/events", nil) }, fails: true},
+		{name: "valid bearer", req: withHeader("Authorization", "Bearer good"), subject: "alice", roles: []string{"AUDITOR"}},
+		{name: "invalid bearer", req: withHeader("Authorization", "Bearer forged"), fails: true},
+		{name: "basic scheme", req: withHeader("Authorization", "Basic dXNlcjpwYXNz"), fails: true},
+		{name: "current rotated key", req: withHeader(auth.APIKeyHeader, "new-key"), subject: "ledger-service", roles: []string{"SERVICE"}},
+		{name: "expired key", req: withHeader(auth.APIKeyHeader, "old-key"), fails: true},
+		{name: "unknown key", req: withHeader(auth.APIKeyHeader, "guess"), fails: true},
+		{name: "key without subject", req: withHeader(auth.APIKeyHeader, "aml-key"), subject: "service:aml-1", roles: []string{"AML_ANALYST"}},
+		{name: "legacy single key", req: withHeader(auth.APIKeyHeader, "legacy-key"), subject: "service", roles: []string{"SERVICE"}},
+		{name: "mapped client cert", req: func() *http.Request { return clientCertRequest("reporting.bank.internal") }, subject: "cert:reporting.bank.internal", roles: []string{"AUDITOR"}},
+		{name: "unmapped client cert", req: func() *http.Request { return clientCertRequest("laptop") }, fails: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, err := authenticator.Authenticate(tt.req())
+			if tt.fails {
+				assert.ErrorIs(t, err, auth.ErrUnauthenticated)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.subject, id.Subject)
+			assert.Equal(t, tt.roles, id.Roles)
+			assert.Equal(t, auth.AccessorID(tt.subject), id.AccessorID)
+		})
+	}
+}
+
+func withHeader(key, value string) func() *http.Request {
+	return func() *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/audit/events", nil)
+		req.Header.Set(key, value)
+		return req
+	}
+}
+
+func TestAuthenticatorFailsClosed(t *testing.T) {
+	_, err := auth.NewAuthenticator(config.AuthConfig{}, nil)
+	assert.Error(t, err, "no method configured must refuse to start")
+
+	_, err = auth.NewAuthenticator(config.AuthConfig{ServiceAPIKey: "plaintext-key"}, nil)
+	assert.Error(t, err, "keys must be configured as hashes")
+
+	dup := config.ServiceAPIKeyConfig{ID: "a", SHA256: auth.HashAPIKey("k")}
+	_, err = auth.NewAuthenticator(config.AuthConfig{ServiceAPIKeys: []config.ServiceAPIKeyConfig{dup, dup}}, nil)
+	assert.Error(t, err, "key IDs must be unique")
+}
+
+func TestAuthenticatorDevMode(t *testing.T) {
+	authenticator, err := auth.NewAuthenticator(config.AuthConfig{InsecureDevMode: true, DevRole: "AUDITOR"}, nil)
+	require.NoError(t, err)
+	assert.True(t, authenticator.DevMode())
+
+	id, err := authenticator.Authenticate(httptest.NewRequest(http.MethodGet, "/audit/events", nil))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"AUDITOR"}, id.Roles)
+
+	// A presented credential is still checked
+	_, err = authenticator.Authenticate(withHeader(auth.APIKeyHeader, "guess")())
+	assert.ErrorIs(t, err, auth.ErrUnauthenticated)
+}
@@ -0,0 +1,68 @@
+package auth
+
+import (
+	"context"
+	"errors"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// ErrNoIdentity is returned when audit data is requested without an authenticated caller
+var ErrNoIdentity = errors.New("no caller identity in context")
+
+// subjectNamespace derives stable accessor IDs for token subjects that are not UUIDs
+var subjectNamespace = uuid.MustParse("6f0b6c1e-3c1a-5d2e-9a51-0c2f8e3b7d41")
+
+// Identity is the authenticated caller of a request, as recorded in access logs
+type Identity struct {
+	Subject    string    // Token subject as issued
+	AccessorID uuid.UUID // Subject as a UUID; derived deterministically when it is not one
+	Role       string    // Role granted by the RBAC policy; "" when none applies
+	Roles      []string  // Role claims as issued, in token order
+	IPAddress  string
+	Purpose    Purpose // Justification given for this request, if any
+}
+
+// System is the identity of the service's own scheduled jobs
+var System = Identity{Subject: "system", AccessorID: uuid.Nil, Role: "SYSTEM"}
+
+type identityKey struct{}
+
+// WithIdentity returns a context carrying id
+func WithIdentity(ctx context.Context, id Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, id)
+}
+
+// FromContext returns the identity attached with WithIdentity
+func FromContext(ctx context.Context) (Identity, bool) {
+	id, ok := ctx.Value(identityKey{}).(Identity)
+	return id, ok
+}
+
+// IdentityFromClaims reads the subject and role claims of a validated token: the "role"
+// claim followed by the entries of "roles". Role is left for the RBAC policy to resolve.
+func IdentityFromClaims(claims jwt.MapClaims) Identity {
+	sub, _ := claims.GetSubject()
+	id := Identity{Subject: sub, AccessorID: AccessorID(sub)}
+
+	if role, ok := claims["role"].(string); ok && role != "" {
+		id.Roles = append(id.Roles, role)
+	}
+	if roles, ok := claims["roles"].([]interface{}); ok {
+		for _, r := range roles {
+			if role, ok := r.(string); ok && role != "" {
+				id.Roles = append(id.Roles, role)
+			}
+		}
+	}
+	return id
+}
+
+// AccessorID maps a subject to the UUID stored in access logs
+func AccessorID(subject string) uuid.UUID {
+	if id, err := uuid.Parse(subject); err == nil {
+		return id
+	}
+	return uuid.NewSHA1(subjectNamespace, []byte(subject))
+}
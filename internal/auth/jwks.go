@@ -0,0 +1,247 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+)
+
+const (
+	// minJWKSRefreshInterval throttles refreshes triggered by tokens with an unknown kid
+	minJWKSRefreshInterval = 30 * time.Second
+	jwksFetchTimeout       = 10 * time.Second
+	maxJWKSBytes           = 1 << 20
+	minRSABits             = 2048
+)
+
+// jwksAlgorithms are the signing algorithms accepted from a JWKS
+var jwksAlgorithms = []string{"RS256", "ES256", "EdDSA"}
+
+// jsonWebKey is one entry of a JWKS document (RFC 7517)
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+type verificationKey struct {
+	alg string
+	key interface{}
+}
+
+// JWKSVerifier validates tokens against the keys published at a JWKS URL. Keys are
+// selected by kid and cached; the set is refreshed periodically by Run and on demand,
+// at most every 30 seconds, when a token names a kid that is not cached yet.
+type JWKSVerifier struct {
+	url    string
+	client *http.Client
+	claims ClaimsPolicy
+	logger *zap.Logger
+
+	mu          sync.RWMutex
+	keys        map[string]verificationKey
+	refreshMu   sync.Mutex
+	lastAttempt time.Time
+}
+
+// NewJWKSVerifier creates a verifier for the JWKS at url. No keys are fetched until
+// Refresh, Run or the first Verify.
+func NewJWKSVerifier(url string, claims ClaimsPolicy, client *http.Client, logger *zap.Logger) *JWKSVerifier {
+	if client == nil {
+		client = &http.Client{Timeout: jwksFetchTimeout}
+	}
+	return &JWKSVerifier{url: url, client: client, claims: claims, logger: logger, keys: map[string]verificationKey{}}
+}
+
+// Verify checks the token's signature against the key named by its kid, and its claims
+func (v *JWKSVerifier) Verify(token string) (jwt.MapClaims, error) {
+	claims := jwt.MapClaims{}
+	if _, err := jwt.ParseWithClaims(token, claims, v.keyFor, v.claims.parserOptions(jwksAlgorithms...)...); err != nil {
+		return nil, err
+	}
+	if err := requireClaims(claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+func (v *JWKSVerifier) keyFor(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		return nil, errors.New("token has no kid")
+	}
+
+	key, ok := v.lookup(kid)
+	if !ok {
+		ctx, cancel := context.WithTimeout(context.Background(), jwksFetchTimeout)
+		defer cancel()
+		if err := v.refresh(ctx, false); err != nil {
+			v.logger.Warn("Failed to refresh JWKS for unknown kid", zap.String("kid", kid), zap.Error(err))
+		}
+		if key, ok = v.lookup(kid); !ok {
+			return nil, fmt.Errorf("unknown signing key %q", kid)
+		}
+	}
+	if key.alg != token.Method.Alg() {
+		return nil, fmt.Errorf("signing key %q is for %s, token uses %s", kid, key.alg, token.Method.Alg())
+	}
+	return key.key, nil
+}
+
+func (v *JWKSVerifier) lookup(kid string) (verificationKey, bool) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	key, ok := v.keys[kid]
+	return key, ok
+}
+
+// Refresh fetches the key set now. On failure the cached keys are kept.
+func (v *JWKSVerifier) Refresh(ctx context.Context) error {
+	return v.refresh(ctx, true)
+}
+
+func (v *JWKSVerifier) refresh(ctx context.Context, force bool) error {
+	v.refreshMu.Lock()
+	defer v.refreshMu.Unlock()
+	if !force && time.Since(v.lastAttempt) < minJWKSRefreshInterval {
+		return nil
+	}
+	v.lastAttempt = time.Now()
+
+	keys, err := v.fetch(ctx)
+	if err != nil {
+		return err
+	}
+	v.mu.Lock()
+	v.keys = keys
+	v.mu.Unlock()
+	return nil
+}
+
+func (v *JWKSVerifier) fetch(ctx context.Context) (map[string]verificationKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build JWKS request: %w", err)
+	}
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch JWKS: status %d", resp.StatusCode)
+	}
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxJWKSBytes)).Decode(&set); err != nil {
+		return nil, fmt.Errorf("failed to decode JWKS: %w", err)
+	}
+
+	keys := map[string]verificationKey{}
+	for _, jwk := range set.Keys {
+		if jwk.Kid == "" || (jwk.Use != "" && jwk.Use != "sig") {
+			continue
+		}
+		key, err := parseJWK(jwk)
+		if err != nil {
+			v.logger.Warn("Skipping unusable JWKS key", zap.String("kid", jwk.Kid), zap.Error(err))
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("JWKS contains no usable signing keys")
+	}
+	return keys, nil
+}
+
+// Run refreshes the key set every interval until ctx is cancelled
+func (v *JWKSVerifier) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := v.Refresh(ctx); err != nil {
+				v.logger.Error("Failed to refresh JWKS; keeping cached keys", zap.Error(err))
+			}
+		}
+	}
+}
+
+// parseJWK converts a JWK into a verification key, deriving the algorithm from the key
+// type and rejecting a declared alg that disagrees with it
+func parseJWK(jwk jsonWebKey) (verificationKey, error) {
+	var key verificationKey
+	switch {
+	case jwk.Kty == "RSA":
+		n, err := decodeBigInt(jwk.N)
+		if err != nil {
+			return key, fmt.Errorf("invalid modulus: %w", err)
+		}
+		e, err := decodeBigInt(jwk.E)
+		if err != nil || !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+			return key, errors.New("invalid exponent")
+		}
+		if n.BitLen() < minRSABits {
+			return key, fmt.Errorf("RSA key of %d bits is too short", n.BitLen())
+		}
+		key = verificationKey{alg: "RS256", key: &rsa.PublicKey{N: n, E: int(e.Int64())}}
+	case jwk.Kty == "EC" && jwk.Crv == "P-256":
+		x, errX := decodeBigInt(jwk.X)
+		y, errY := decodeBigInt(jwk.Y)
+		if errX != nil || errY != nil || !elliptic.P256().IsOnCurve(x, y) {
+			return key, errors.New("invalid P-256 point")
+		}
+		key = verificationKey{alg: "ES256", key: &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}}
+	case jwk.Kty == "OKP" && jwk.Crv == "Ed25519":
+		x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return key, errors.New("invalid Ed25519 key")
+		}
+		key = verificationKey{alg: "EdDSA", key: ed25519.PublicKey(x)}
+	default:
+		return key, fmt.Errorf("unsupported key type %s %s", jwk.Kty, jwk.Crv)
+	}
+
+	if jwk.Alg != "" && jwk.Alg != key.alg {
+		return key, fmt.Errorf("alg %s does not match %s key", jwk.Alg, jwk.Kty)
+	}
+	return key, nil
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 {
+		return nil, errors.New("empty value")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
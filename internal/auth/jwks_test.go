@@ -0,0 +1,169 @@
+package auth_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/banking/audit-compliance/internal/auth"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type jwksServer struct {
+	*httptest.Server
+	mu   sync.Mutex
+	keys []map[string]string
+}
+
+func newJWKSServer(t *testing.T) *jwksServer {
+	s := &jwksServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"keys": s.keys})
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *jwksServer) publish(keys ...map[string]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = keys
+}
+
+func b64(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+
+func rsaJWK(kid string, key *rsa.PrivateKey) map[string]string {
+	return map[string]string{"kty": "RSA", "kid": kid, "use": "sig", "n": b64(key.N.Bytes()), "e": b64(big.NewInt(int64(key.E)).Bytes())}
+}
+
+func ecJWK(kid string, key *ecdsa.PrivateKey) map[string]string {
+	return map[string]string{"kty": "EC", "kid": kid, "crv": "P-256", "x": b64(key.X.FillBytes(make([]byte, 32))), "y": b64(key.Y.FillBytes(make([]byte, 32)))}
+}
+
+func edJWK(kid string, key ed25519.PrivateKey) map[string]string {
+	return map[string]string{"kty": "OKP", "kid": kid, "crv": "Ed25519", "x": b64(key.Public().(ed25519.PublicKey))}
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, kid string, key interface{}, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+	return signed
+}
+
+func validClaims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"sub": "alice",
+		"iss": "banking-auth-service",
+		"aud": "audit-compliance-service",
+		"exp": time.Now().Add(time.Hour).Unix(),
+		"iat": time.Now().Unix(),
+	}
+}
+
+func with(claims jwt.MapClaims, key string, value interface{}) jwt.MapClaims {
+	claims[key] = value
+	if value == nil {
+		delete(claims, key)
+	}
+	return claims
+}
+
+func TestJWKSVerifier(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	server := newJWKSServer(t)
+	server.publish(rsaJWK("rsa-1", rsaKey), ecJWK("ec-1", ecKey), edJWK("ed-1", edKey))
+
+	policy := auth.ClaimsPolicy{Issuer: "banking-auth-service", Audience: "audit-compliance-service", ClockSkew: 30 * time.Second}
+	verifier := auth.NewJWKSVerifier(server.URL, policy, server.Client(), zap.NewNop())
+
+	tests := []struct {
+		name  string
+		token string
+		valid bool
+	}{
+		{"RS256", sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, validClaims()), true},
+		{"ES256", sign(t, jwt.SigningMethodES256, "ec-1", ecKey, validClaims()), true},
+		{"EdDSA", sign(t, jwt.SigningMethodEdDSA, "ed-1", edKey, validClaims()), true},
+		{"expired within skew", sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, with(validClaims(), "exp", time.Now().Add(-10*time.Second).Unix())), true},
+		{"expired beyond skew", sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, with(validClaims(), "exp", time.Now().Add(-time.Minute).Unix())), false},
+		{"not yet valid", sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, with(validClaims(), "nbf", time.Now().Add(time.Minute).Unix())), false},
+		{"no expiry", sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, with(validClaims(), "exp", nil)), false},
+		{"no subject", sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, with(validClaims(), "sub", nil)), false},
+		{"wrong issuer", sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, with(validClaims(), "iss", "evil")), false},
+		{"wrong audience", sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, with(validClaims(), "aud", "other-service")), false},
+		{"audience list", sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, with(validClaims(), "aud", []string{"other-service", "audit-compliance-service"})), true},
+		{"kid of another key", sign(t, jwt.SigningMethodES256, "rsa-1", ecKey, validClaims()), false},
+		{"unknown kid", sign(t, jwt.SigningMethodRS256, "rsa-9", rsaKey, validClaims()), false},
+		{"no kid", sign(t, jwt.SigningMethodRS256, "", rsaKey, validClaims()), false},
+		{"HS256 with public key bytes", sign(t, jwt.SigningMethodHS256, "rsa-1", rsaKey.N.Bytes(), validClaims()), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := verifier.Verify(tt.token)
+			if !tt.valid {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "alice", claims["sub"])
+		})
+	}
+}
+
+func TestJWKSVerifierRotation(t *testing.T) {
+	oldKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	newKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	server := newJWKSServer(t)
+	server.publish(ecJWK("2026-09", oldKey))
+	verifier := auth.NewJWKSVerifier(server.URL, auth.ClaimsPolicy{}, server.Client(), zap.NewNop())
+
+	// The first token fetches the key set on demand
+	_, err = verifier.Verify(sign(t, jwt.SigningMethodES256, "2026-09", oldKey, validClaims()))
+	require.NoError(t, err)
+
+	// Both keys are published while issuers move over; the cache picks the new one up on refresh
+	server.publish(ecJWK("2026-09", oldKey), ecJWK("2026-10", newKey))
+	require.NoError(t, verifier.Refresh(context.Background()))
+	_, err = verifier.Verify(sign(t, jwt.SigningMethodES256, "2026-10", newKey, validClaims()))
+	require.NoError(t, err)
+
+	// Once the old key is withdrawn its tokens are rejected
+	server.publish(ecJWK("2026-10", newKey))
+	require.NoError(t, verifier.Refresh(context.Background()))
+	_, err = verifier.Verify(sign(t, jwt.SigningMethodES256, "2026-09", oldKey, validClaims()))
+	assert.Error(t, err)
+
+	// A failed refresh keeps the cached keys
+	server.publish()
+	assert.Error(t, verifier.Refresh(context.Background()))
+	_, err = verifier.Verify(sign(t, jwt.SigningMethodES256, "2026-10", newKey, validClaims()))
+	assert.NoError(t, err)
+}
@@ -0,0 +1,76 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/banking/audit-compliance/internal/config"
+)
+
+var (
+	// ErrPurposeRequired is returned when a caller who is not exempt gives no purpose
+	ErrPurposeRequired = errors.New("purpose of access required")
+	// ErrInvalidPurpose is returned for a malformed purpose or an unknown purpose code
+	ErrInvalidPurpose = errors.New("invalid purpose of access")
+)
+
+// referencePattern keeps references to case and ticket identifiers, not free text
+// that could carry customer data into the access log
+var referencePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._/#-]{0,99}$`)
+
+// Purpose is the justification given for reading audit data, e.g. CASE:FRAUD-1234
+type Purpose struct {
+	Code      string
+	Reference string
+}
+
+// String returns the purpose in its CODE:REFERENCE form
+func (p Purpose) String() string {
+	if p.Code == "" {
+		return ""
+	}
+	return p.Code + ":" + p.Reference
+}
+
+// PurposePolicy validates purposes against the configured codes and exemptions
+type PurposePolicy struct {
+	codes  map[string]bool
+	exempt map[string]bool
+}
+
+// NewPurposePolicy creates a policy from config. Codes and roles compare case-insensitively.
+func NewPurposePolicy(cfg config.PurposeConfig) *PurposePolicy {
+	p := &PurposePolicy{codes: map[string]bool{}, exempt: map[string]bool{}}
+	for _, code := range cfg.Codes {
+		p.codes[strings.ToUpper(code)] = true
+	}
+	for _, role := range cfg.ExemptRoles {
+		p.exempt[strings.ToUpper(role)] = true
+	}
+	return p
+}
+
+// Resolve validates the purpose given by a caller with role. An empty value is only
+// accepted from exempt roles; a given purpose is always validated.
+func (p *PurposePolicy) Resolve(value, role string) (Purpose, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		if p.exempt[strings.ToUpper(role)] {
+			return Purpose{}, nil
+		}
+		return Purpose{}, ErrPurposeRequired
+	}
+
+	code, reference, ok := strings.Cut(value, ":")
+	code = strings.ToUpper(strings.TrimSpace(code))
+	reference = strings.TrimSpace(reference)
+	if !ok || !p.codes[code] {
+		return Purpose{}, fmt.Errorf("%w: unknown purpose code %q", ErrInvalidPurpose, code)
+	}
+	if !referencePattern.MatchString(reference) {
+		return Purpose{}, fmt.Errorf("%w: reference must be a case or ticket identifier", ErrInvalidPurpose)
+	}
+	return Purpose{Code: code, Reference: reference}, nil
+}
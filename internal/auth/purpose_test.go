@@ -0,0 +1,47 @@
+package auth_test
+
+import (
+	"testing"
+
+	"github.com/banking/audit-compliance/internal/auth"
+	"github.com/banking/audit-compliance/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPurposePolicyResolve(t *testing.T) {
+	policy := auth.NewPurposePolicy(config.PurposeConfig{
+		Codes:       []string{"CASE", "ticket"},
+		ExemptRoles: []string{"SERVICE"},
+	})
+
+	tests := []struct {
+		name    string
+		value   string
+		role    string
+		want    auth.Purpose
+		wantErr error
+	}{
+		{name: "case reference", value: "CASE:FRAUD-1234", role: "AML_ANALYST", want: auth.Purpose{Code: "CASE", Reference: "FRAUD-1234"}},
+		{name: "code is case-insensitive", value: " case : FRAUD-1234 ", role: "AUDITOR", want: auth.Purpose{Code: "CASE", Reference: "FRAUD-1234"}},
+		{name: "configured lowercase code", value: "TICKET:JIRA/OPS#42", role: "AUDITOR", want: auth.Purpose{Code: "TICKET", Reference: "JIRA/OPS#42"}},
+		{name: "missing", value: "", role: "AUDITOR", wantErr: auth.ErrPurposeRequired},
+		{name: "exempt role may omit", value: "", role: "service", want: auth.Purpose{}},
+		{name: "exempt role is still validated", value: "CURIOSITY:1", role: "SERVICE", wantErr: auth.ErrInvalidPurpose},
+		{name: "unknown code", value: "CURIOSITY:1", role: "AUDITOR", wantErr: auth.ErrInvalidPurpose},
+		{name: "no reference", value: "CASE:", role: "AUDITOR", wantErr: auth.ErrInvalidPurpose},
+		{name: "no separator", value: "CASE", role: "AUDITOR", wantErr: auth.ErrInvalidPurpose},
+		{name: "free text reference", value: "CASE:looking at John Smith's account", role: "AUDITOR", wantErr: auth.ErrInvalidPurpose},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := policy.Resolve(tt.value, tt.role)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
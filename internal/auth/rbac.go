@@ -0,0 +1,194 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/banking/audit-compliance/internal/config"
+	"github.com/banking/audit-compliance/internal/domain"
+)
+
+// ErrForbidden is returned when a role lacks the permission or resource access required
+var ErrForbidden = errors.New("forbidden")
+
+// Permission names an operation on audit data that roles are granted in config
+type Permission string
+
+const (
+	PermissionWriteEvents    Permission = "events:write"
+	PermissionReadEvents     Permission = "events:read"
+	PermissionSearchEvents   Permission = "events:search"
+	PermissionExportEvents   Permission = "events:export"
+	PermissionVerifyLedger   Permission = "integrity:verify"
+	PermissionReadAccessLogs Permission = "access_logs:read"
+	PermissionReplayDLQ      Permission = "dlq:replay"
+)
+
+var knownPermissions = map[Permission]bool{
+	PermissionWriteEvents:    true,
+	PermissionReadEvents:     true,
+	PermissionSearchEvents:   true,
+	PermissionExportEvents:   true,
+	PermissionVerifyLedger:   true,
+	PermissionReadAccessLogs: true,
+	PermissionReplayDLQ:      true,
+}
+
+// RedactedValue replaces redacted string fields in responses
+const RedactedValue = "[REDACTED]"
+
+// redactors clear one PII field of an event; the keys are the field names accepted in config
+var redactors = map[string]func(e *domain.AuditEvent){
+	"ip_address":     func(e *domain.AuditEvent) { e.IPAddress = RedactedValue },
+	"geolocation":    func(e *domain.AuditEvent) { e.Geolocation = redactedPtr(e.Geolocation) },
+	"user_agent":     func(e *domain.AuditEvent) { e.UserAgent = redactedPtr(e.UserAgent) },
+	"session_id":     func(e *domain.AuditEvent) { e.SessionID = redactedPtr(e.SessionID) },
+	"failure_reason": func(e *domain.AuditEvent) { e.FailureReason = redactedPtr(e.FailureReason) },
+	"metadata":       func(e *domain.AuditEvent) { e.Metadata = nil },
+	"data_before":    func(e *domain.AuditEvent) { e.DataBefore = nil },
+	"data_after":     func(e *domain.AuditEvent) { e.DataAfter = nil },
+}
+
+func redactedPtr(s *string) *string {
+	if s == nil {
+		return nil
+	}
+	v := RedactedValue
+	return &v
+}
+
+type rolePolicy struct {
+	permissions   map[Permission]bool
+	allResources  bool
+	resourceTypes []domain.ResourceType
+	redact        []string
+}
+
+// Policy authorizes roles against the permissions, resource types and redactions
+// declared in config. Role names compare case-insensitively.
+type Policy struct {
+	aliases map[string]string
+	roles   map[string]*rolePolicy
+}
+
+// NewPolicy builds a policy from config, rejecting unknown permissions, resource types
+// and redaction fields so a typo cannot silently widen or narrow access
+func NewPolicy(cfg config.RBACConfig) (*Policy, error) {
+	p := &Policy{aliases: map[string]string{}, roles: map[string]*rolePolicy{}}
+
+	for name, rc := range cfg.Roles {
+		role := &rolePolicy{permissions: map[Permission]bool{}}
+		for _, perm := range rc.Permissions {
+			if !knownPermissions[Permission(perm)] {
+				return nil, fmt.Errorf("role %s: unknown permission %q", name, perm)
+			}
+			role.permissions[Permission(perm)] = true
+		}
+		for _, rt := range rc.ResourceTypes {
+			if rt == "*" {
+				role.allResources = true
+				continue
+			}
+			resourceType := domain.ResourceType(strings.ToUpper(rt))
+			if !resourceType.IsValid() {
+				return nil, fmt.Errorf("role %s: unknown resource type %q", name, rt)
+			}
+			role.resourceTypes = append(role.resourceTypes, resourceType)
+		}
+		for _, field := range rc.Redact {
+			if redactors[field] == nil {
+				return nil, fmt.Errorf("role %s: cannot redact unknown field %q", name, field)
+			}
+			role.redact = append(role.redact, field)
+		}
+		p.roles[strings.ToUpper(name)] = role
+	}
+
+	for alias, role := range cfg.RoleAliases {
+		if p.roles[strings.ToUpper(role)] == nil {
+			return nil, fmt.Errorf("role alias %s: unknown role %q", alias, role)
+		}
+		p.aliases[strings.ToLower(alias)] = strings.ToUpper(role)
+	}
+	return p, nil
+}
+
+// ResolveRole returns the first claimed role, by name or alias, that the policy defines,
+// or "" when none is
+func (p *Policy) ResolveRole(claimed []string) string {
+	for _, c := range claimed {
+		if role, ok := p.aliases[strings.ToLower(c)]; ok {
+			return role
+		}
+		if _, ok := p.roles[strings.ToUpper(c)]; ok {
+			return strings.ToUpper(c)
+		}
+	}
+	return ""
+}
+
+// Allows reports whether role holds perm. Undefined roles hold nothing.
+func (p *Policy) Allows(role string, perm Permission) bool {
+	r := p.roles[strings.ToUpper(role)]
+	return r != nil && r.permissions[perm]
+}
+
+// CanAccessResource reports whether role may see events about resourceType
+func (p *Policy) CanAccessResource(role string, resourceType domain.ResourceType) bool {
+	r := p.roles[strings.ToUpper(role)]
+	if r == nil {
+		return false
+	}
+	if r.allResources {
+		return true
+	}
+	for _, rt := range r.resourceTypes {
+		if rt == resourceType {
+			return true
+		}
+	}
+	return false
+}
+
+// ScopeFilter restricts filter to the resource types role may see. A filter naming a
+// type outside the role's scope is refused rather than silently narrowed.
+func (p *Policy) ScopeFilter(role string, filter *domain.AuditEventFilter) error {
+	r := p.roles[strings.ToUpper(role)]
+	if r == nil {
+		return ErrForbidden
+	}
+	if r.allResources {
+		return nil
+	}
+	if len(filter.ResourceTypes) == 0 {
+		filter.ResourceTypes = append([]domain.ResourceType(nil), r.resourceTypes...)
+		return nil
+	}
+	for _, rt := range filter.ResourceTypes {
+		if !p.CanAccessResource(role, rt) {
+			return fmt.Errorf("%w: role %s may not read %s events", ErrForbidden, strings.ToUpper(role), rt)
+		}
+	}
+	return nil
+}
+
+// Redacts reports whether role has any fields redacted
+func (p *Policy) Redacts(role string) bool {
+	r := p.roles[strings.ToUpper(role)]
+	return r != nil && len(r.redact) > 0
+}
+
+// Redact returns event as role may see it: a copy with the role's PII fields replaced,
+// or event itself when nothing is redacted
+func (p *Policy) Redact(role string, event *domain.AuditEvent) *domain.AuditEvent {
+	r := p.roles[strings.ToUpper(role)]
+	if r == nil || len(r.redact) == 0 || event == nil {
+		return event
+	}
+	redacted := *event
+	for _, field := range r.redact {
+		redactors[field](&redacted)
+	}
+	return &redacted
+}
@@ -0,0 +1,150 @@
+package auth_test
+
+import (
+	"testing"
+
+	"github.com/banking/audit-compliance/internal/auth"
+	"github.com/banking/audit-compliance/internal/config"
+	"github.com/banking/audit-compliance/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// defaultPolicy builds the policy declared by the default config, as loaded by viper
+func defaultPolicy(t *testing.T) *auth.Policy {
+	t.Helper()
+	cfg, err := config.Load()
+	require.NoError(t, err)
+	policy, err := auth.NewPolicy(cfg.Auth.RBAC)
+	require.NoError(t, err)
+	return policy
+}
+
+func TestPolicyPermissions(t *testing.T) {
+	policy := defaultPolicy(t)
+
+	tests := []struct {
+		role  string
+		perm  auth.Permission
+		allow bool
+	}{
+		{"COMPLIANCE_OFFICER", auth.PermissionReadEvents, true},
+		{"COMPLIANCE_OFFICER", auth.PermissionExportEvents, true},
+		{"COMPLIANCE_OFFICER", auth.PermissionReadAccessLogs, true},
+		{"DPO", auth.PermissionExportEvents, true},
+		{"DPO", auth.PermissionVerifyLedger, false},
+		{"AUDITOR", auth.PermissionVerifyLedger, true},
+		{"AUDITOR", auth.PermissionExportEvents, false},
+		{"AML_ANALYST", auth.PermissionSearchEvents, true},
+		{"AML_ANALYST", auth.PermissionReadAccessLogs, false},
+		{"SERVICE", auth.PermissionReadEvents, true},
+		{"SERVICE", auth.PermissionSearchEvents, false},
+		{"SERVICE", auth.PermissionWriteEvents, true},
+		{"AUDITOR", auth.PermissionWriteEvents, false},
+		{"OPERATOR", auth.PermissionReplayDLQ, true},
+		{"OPERATOR", auth.PermissionReadEvents, false},
+		{"COMPLIANCE_OFFICER", auth.PermissionReplayDLQ, false},
+		{"aml_analyst", auth.PermissionReadEvents, true},
+		{"", auth.PermissionReadEvents, false},
+		{"INTERN", auth.PermissionReadEvents, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.role+" "+string(tt.perm), func(t *testing.T) {
+			assert.Equal(t, tt.allow, policy.Allows(tt.role, tt.perm))
+		})
+	}
+}
+
+func TestPolicyResourceScope(t *testing.T) {
+	policy := defaultPolicy(t)
+
+	tests := []struct {
+		name      string
+		role      string
+		requested []domain.ResourceType
+		want      []domain.ResourceType
+		forbidden bool
+	}{
+		{name: "unrestricted role keeps an open filter", role: "AUDITOR"},
+		{name: "restricted role is narrowed to its scope", role: "AML_ANALYST",
+			want: []domain.ResourceType{domain.ResourceTypeAccount, domain.ResourceTypeTransfer, domain.ResourceTypeTransaction,
+				domain.ResourceTypeAMLFlag, domain.ResourceTypeKYC, domain.ResourceTypeReport}},
+		{name: "restricted role within scope", role: "AML_ANALYST",
+			requested: []domain.ResourceType{domain.ResourceTypeTransfer},
+			want:      []domain.ResourceType{domain.ResourceTypeTransfer}},
+		{name: "restricted role outside scope", role: "AML_ANALYST",
+			requested: []domain.ResourceType{domain.ResourceTypeTransfer, domain.ResourceTypeSession}, forbidden: true},
+		{name: "unknown role", role: "INTERN", forbidden: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := domain.AuditEventFilter{ResourceTypes: tt.requested}
+			err := policy.ScopeFilter(tt.role, &filter)
+			if tt.forbidden {
+				assert.ErrorIs(t, err, auth.ErrForbidden)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, filter.ResourceTypes)
+		})
+	}
+}
+
+func TestPolicyRedaction(t *testing.T) {
+	policy := defaultPolicy(t)
+	geo, agent := "Berlin, DE", "Mozilla/5.0"
+	event := &domain.AuditEvent{IPAddress: "10.1.2.3", Geolocation: &geo, UserAgent: &agent, Metadata: []byte(`{"iban":"DE89"}`),
+		DataBefore: []byte(`{"email":"old@example.com"}`), DataAfter: []byte(`{"email":"new@example.com"}`)}
+
+	tests := []struct {
+		role         string
+		ip           string
+		userAgent    string
+		keepMetadata bool
+		keepData     bool
+	}{
+		{role: "COMPLIANCE_OFFICER", ip: "10.1.2.3", userAgent: agent, keepMetadata: true, keepData: true},
+		{role: "DPO", ip: "10.1.2.3", userAgent: agent, keepMetadata: true, keepData: true},
+		{role: "AUDITOR", ip: auth.RedactedValue, userAgent: auth.RedactedValue, keepMetadata: true},
+		{role: "AML_ANALYST", ip: "10.1.2.3", userAgent: auth.RedactedValue, keepMetadata: true},
+		{role: "SERVICE", ip: auth.RedactedValue, userAgent: auth.RedactedValue},
+	}
+	for _, tt := range tests {
+		t.Run(tt.role, func(t *testing.T) {
+			got := policy.Redact(tt.role, event)
+			assert.Equal(t, tt.ip, got.IPAddress)
+			assert.Equal(t, tt.userAgent, *got.UserAgent)
+			assert.Equal(t, tt.keepMetadata, got.Metadata != nil)
+			assert.Equal(t, tt.keepData, got.DataBefore != nil, "data_before")
+			assert.Equal(t, tt.keepData, got.DataAfter != nil, "data_after")
+		})
+	}
+	assert.Equal(t, "10.1.2.3", event.IPAddress, "Redaction must not modify the original event")
+	assert.NotNil(t, event.DataBefore, "Redaction must not modify the original event")
+}
+
+func TestPolicyResolveRole(t *testing.T) {
+	policy, err := auth.NewPolicy(config.RBACConfig{
+		RoleAliases: map[string]string{"fraud-team": "AML_ANALYST"},
+		Roles:       map[string]config.RoleConfig{"AML_ANALYST": {}, "AUDITOR": {}},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "AML_ANALYST", policy.ResolveRole([]string{"employee", "Fraud-Team"}))
+	assert.Equal(t, "AUDITOR", policy.ResolveRole([]string{"auditor", "fraud-team"}))
+	assert.Equal(t, "", policy.ResolveRole([]string{"employee"}))
+}
+
+func TestNewPolicyRejectsTypos(t *testing.T) {
+	for name, cfg := range map[string]config.RBACConfig{
+		"permission":    {Roles: map[string]config.RoleConfig{"AUDITOR": {Permissions: []string{"events:reed"}}}},
+		"resource type": {Roles: map[string]config.RoleConfig{"AUDITOR": {ResourceTypes: []string{"ACCOUNTS"}}}},
+		"redact field":  {Roles: map[string]config.RoleConfig{"AUDITOR": {Redact: []string{"ip"}}}},
+		"alias target":  {RoleAliases: map[string]string{"fraud": "ANALYST"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := auth.NewPolicy(cfg)
+			assert.Error(t, err)
+		})
+	}
+}
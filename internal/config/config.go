@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"os"
 	"time"
 
 	"github.com/spf13/viper"
@@ -21,6 +22,7 @@ type Config struct {
 	Tracing       TracingConfig
 	Compliance    ComplianceConfig
 	Detection     DetectionConfig
+	Timestamp     TimestampConfig
 }
 
 // ServerConfig holds HTTP server configuration
@@ -31,6 +33,15 @@ type ServerConfig struct {
 	WriteTimeout    time.Duration `mapstructure:"write_timeout"`
 	ShutdownTimeout time.Duration `mapstructure:"shutdown_timeout"`
 	GRPCPort        int           `mapstructure:"grpc_port"`
+	TLS             TLSConfig     `mapstructure:"tls"`
+}
+
+// TLSConfig holds HTTPS and mutual TLS settings; an empty CertFile serves plain HTTP
+type TLSConfig struct {
+	CertFile     string `mapstructure:"cert_file"`
+	KeyFile      string `mapstructure:"key_file"`
+	ClientCAFile string `mapstructure:"client_ca_file"` // CA bundle client certificates must chain to
+	ClientAuth   string `mapstructure:"client_auth"`    // none, optional or require
 }
 
 // DatabaseConfig holds PostgreSQL configuration
@@ -38,21 +49,37 @@ type DatabaseConfig struct {
 	Host            string        `mapstructure:"host"`
 	Port            int           `mapstructure:"port"`
 	User            string        `mapstructure:"user"`
-	Password        string        `mapstructure:"password"`
+	Password        string        `mapstructure:"-"` // From AUDIT_DATABASE_PASSWORD only
 	DBName          string        `mapstructure:"dbname"`
 	SSLMode         string        `mapstructure:"sslmode"`
 	MaxOpenConns    int           `mapstructure:"max_open_conns"`
 	MaxIdleConns    int           `mapstructure:"max_idle_conns"`
 	ConnMaxLifetime time.Duration `mapstructure:"conn_max_lifetime"`
 	ConnMaxIdleTime time.Duration `mapstructure:"conn_max_idle_time"`
+	// Schema migrations run as the owner role; the service itself connects as User
+	OwnerUser     string `mapstructure:"owner_user"`
+	OwnerPassword string `mapstructure:"-"`            // From AUDIT_DATABASE_OWNER_PASSWORD only
+	AutoMigrate   bool   `mapstructure:"auto_migrate"` // Apply pending migrations at startup
 }
 
-// DSN returns the database connection string
+// DSN returns the database connection string. Without a password it is left out, so
+// the driver falls back to its passfile or certificate authentication.
 func (c DatabaseConfig) DSN() string {
-	return fmt.Sprintf(
-		"host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
-		c.Host, c.Port, c.User, c.Password, c.DBName, c.SSLMode,
+	dsn := fmt.Sprintf(
+		"host=%s port=%d user=%s dbname=%s sslmode=%s",
+		c.Host, c.Port, c.User, c.DBName, c.SSLMode,
 	)
+	if c.Password != "" {
+		dsn += fmt.Sprintf(" password=%s", c.Password)
+	}
+	return dsn
+}
+
+// OwnerDSN returns the connection string for the schema owner role
+func (c DatabaseConfig) OwnerDSN() string {
+	owner := c
+	owner.User, owner.Password = c.OwnerUser, c.OwnerPassword
+	return owner.DSN()
 }
 
 // ElasticsearchConfig holds Elasticsearch configuration
@@ -81,13 +108,16 @@ func (c RedisConfig) Addr() string {
 
 // KafkaConfig holds Kafka configuration
 type KafkaConfig struct {
-	Brokers          []string `mapstructure:"brokers"`
-	ConsumerGroup    string   `mapstructure:"consumer_group"`
-	AuditTopic       string   `mapstructure:"audit_topic"`
-	TransactionTopic string   `mapstructure:"transaction_topic"`
-	UserTopic        string   `mapstructure:"user_topic"`
-	AlertTopic       string   `mapstructure:"alert_topic"`
-	EnableIdempotent bool     `mapstructure:"enable_idempotent"`
+	Brokers          []string      `mapstructure:"brokers"`
+	ConsumerGroup    string        `mapstructure:"consumer_group"`
+	AuditTopic       string        `mapstructure:"audit_topic"`
+	TransactionTopic string        `mapstructure:"transaction_topic"`
+	UserTopic        string        `mapstructure:"user_topic"`
+	AlertTopic       string        `mapstructure:"alert_topic"`
+	DLQTopic         string        `mapstructure:"dlq_topic"` // Receives records that cannot be recorded; "" keeps them in dlq_events only
+	EnableIdempotent bool          `mapstructure:"enable_idempotent"`
+	BatchSize        int           `mapstructure:"batch_size"`    // Records written to the ledger per transaction, per partition
+	BatchTimeout     time.Duration `mapstructure:"batch_timeout"` // Longest a record waits for its batch to fill
 }
 
 // S3Config holds AWS S3 configuration for archival storage
@@ -109,13 +139,67 @@ type EncryptionConfig struct {
 	CurrentKeyVersion     int      `mapstructure:"current_key_version"`
 	AuditHMACSecret       string   `mapstructure:"audit_hmac_secret"`
 	DocumentEncryptionKey string   `mapstructure:"document_encryption_key"`
+	// Audit record signing: "ed25519" or legacy "hmac" (uses AuditHMACSecret)
+	SigningAlgorithm   string            `mapstructure:"signing_algorithm"`
+	SigningKeyID       string            `mapstructure:"signing_key_id"`
+	SigningPrivateKey  string            `mapstructure:"signing_private_key"`  // Base64 Ed25519 seed
+	SigningRetiredKeys map[string]string `mapstructure:"signing_retired_keys"` // Key ID -> base64 public key
+	// Last sequence number signed with HMAC before switching to Ed25519; later HMAC
+	// signatures are rejected. 0 when the ledger was signed with Ed25519 from the start;
+	// the server refuses to start with 0 once the ledger holds HMAC signatures.
+	LegacyHMACUntilSequence int64 `mapstructure:"legacy_hmac_until_sequence"`
 }
 
 // AuthConfig holds authentication settings
 type AuthConfig struct {
-	JWTPublicKeyPath string `mapstructure:"jwt_public_key_path"`
-	JWTIssuer        string `mapstructure:"jwt_issuer"`
-	ServiceAPIKey    string `mapstructure:"service_api_key"`
+	JWTPublicKeyPath     string                     `mapstructure:"jwt_public_key_path"`
+	JWTIssuer            string                     `mapstructure:"jwt_issuer"`
+	JWTAudience          string                     `mapstructure:"jwt_audience"` // Required "aud" value; empty skips the check
+	JWTClockSkew         time.Duration              `mapstructure:"jwt_clock_skew"`
+	JWKSURL              string                     `mapstructure:"jwks_url"` // Takes precedence over JWTPublicKeyPath
+	JWKSRefreshInterval  time.Duration              `mapstructure:"jwks_refresh_interval"`
+	ServiceAPIKey        string                     `mapstructure:"service_api_key"` // SHA-256 hex of a single key for the SERVICE role
+	ServiceAPIKeys       []ServiceAPIKeyConfig      `mapstructure:"service_api_keys"`
+	ClientCertIdentities []ClientCertIdentityConfig `mapstructure:"client_cert_identities"`
+	InsecureDevMode      bool                       `mapstructure:"insecure_dev_mode"` // Serve callers without credentials as DevRole
+	DevRole              string                     `mapstructure:"dev_role"`
+	Purpose              PurposeConfig              `mapstructure:"purpose"`
+	RBAC                 RBACConfig                 `mapstructure:"rbac"`
+}
+
+// ServiceAPIKeyConfig is one active service API key. Only its SHA-256 is configured;
+// rotate by adding the new key, moving callers over, then removing or expiring the old one.
+type ServiceAPIKeyConfig struct {
+	ID        string   `mapstructure:"id"`
+	SHA256    string   `mapstructure:"sha256"` // Hex digest of the key
+	Subject   string   `mapstructure:"subject"`
+	Roles     []string `mapstructure:"roles"`
+	ExpiresAt string   `mapstructure:"expires_at"` // RFC3339; empty never expires
+}
+
+// ClientCertIdentityConfig maps a verified client certificate subject to an identity
+type ClientCertIdentityConfig struct {
+	Subject string   `mapstructure:"subject"` // Common name or full distinguished name
+	Roles   []string `mapstructure:"roles"`
+}
+
+// RBACConfig declares the roles that may read audit data and what each may see
+type RBACConfig struct {
+	RoleAliases map[string]string     `mapstructure:"role_aliases"` // Claim value -> role name
+	Roles       map[string]RoleConfig `mapstructure:"roles"`
+}
+
+// RoleConfig holds one role's permissions, resource scope and PII redactions
+type RoleConfig struct {
+	Permissions   []string `mapstructure:"permissions"`    // e.g. events:read, integrity:verify
+	ResourceTypes []string `mapstructure:"resource_types"` // "*" for every resource type
+	Redact        []string `mapstructure:"redact"`         // Event fields hidden from this role
+}
+
+// PurposeConfig holds the purpose-of-access justification required on audit reads
+type PurposeConfig struct {
+	Codes       []string `mapstructure:"codes"`        // Accepted codes, e.g. CASE in "CASE:FRAUD-1234"
+	ExemptRoles []string `mapstructure:"exempt_roles"` // Roles that may read without a purpose
 }
 
 // LoggingConfig holds logging settings
@@ -137,16 +221,32 @@ type TracingConfig struct {
 
 // ComplianceConfig holds compliance-specific settings
 type ComplianceConfig struct {
-	CTRThresholdCents         int64  `mapstructure:"ctr_threshold_cents"`
-	SARFilingDeadlineDays     int    `mapstructure:"sar_filing_deadline_days"`
-	CTRFilingDeadlineDays     int    `mapstructure:"ctr_filing_deadline_days"`
-	GDPRResponseDeadlineDays  int    `mapstructure:"gdpr_response_deadline_days"`
-	GDPRErasureGraceDays      int    `mapstructure:"gdpr_erasure_grace_days"`
-	TransactionRetentionYears int    `mapstructure:"transaction_retention_years"`
-	LoginRetentionDays        int    `mapstructure:"login_retention_days"`
-	ReportRetentionYears      int    `mapstructure:"report_retention_years"`
-	EnableAutoArchive         bool   `mapstructure:"enable_auto_archive"`
-	ArchiveSchedule           string `mapstructure:"archive_schedule"` // Cron expression
+	CTRThresholdCents           int64         `mapstructure:"ctr_threshold_cents"`
+	SARFilingDeadlineDays       int           `mapstructure:"sar_filing_deadline_days"`
+	CTRFilingDeadlineDays       int           `mapstructure:"ctr_filing_deadline_days"`
+	GDPRResponseDeadlineDays    int           `mapstructure:"gdpr_response_deadline_days"`
+	GDPRErasureGraceDays        int           `mapstructure:"gdpr_erasure_grace_days"`
+	TransactionRetentionYears   int           `mapstructure:"transaction_retention_years"`
+	LoginRetentionDays          int           `mapstructure:"login_retention_days"`
+	ReportRetentionYears        int           `mapstructure:"report_retention_years"`
+	EnableAutoArchive           bool          `mapstructure:"enable_auto_archive"`
+	ArchiveSchedule             string        `mapstructure:"archive_schedule"` // Cron expression
+	IntegrityCheckInterval      time.Duration `mapstructure:"integrity_check_interval"`
+	IntegrityCheckWindow        time.Duration `mapstructure:"integrity_check_window"` // 0 = entire ledger
+	CheckpointInterval          time.Duration `mapstructure:"checkpoint_interval"`
+	PartitionInterval           time.Duration `mapstructure:"partition_interval"` // In-server maintenance; 0 leaves it to `server partitions`
+	PartitionMonthsAhead        int           `mapstructure:"partition_months_ahead"`
+	PartitionArchiveAfterMonths int           `mapstructure:"partition_archive_after_months"` // Closed months kept attached; 0 never archives
+	IdempotencyKeyTTL           time.Duration `mapstructure:"idempotency_key_ttl"`            // How long a key guards against duplicate writes
+	IdempotencyCleanupInterval  time.Duration `mapstructure:"idempotency_cleanup_interval"`   // 0 keeps keys forever
+}
+
+// TimestampConfig holds RFC 3161 timestamp authority settings for ledger checkpoints
+type TimestampConfig struct {
+	URL        string        `mapstructure:"url"`          // Empty disables trusted timestamping
+	CACertPath string        `mapstructure:"ca_cert_path"` // PEM roots the TSA certificate must chain to
+	Timeout    time.Duration `mapstructure:"timeout"`
+	Required   bool          `mapstructure:"required"` // Treat untimestamped checkpoints as integrity failures
 }
 
 // DetectionConfig holds AML detection settings
@@ -190,6 +290,10 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("error unmarshaling config: %w", err)
 	}
 
+	// Database credentials are never defaulted or read from config files
+	cfg.Database.Password = os.Getenv("AUDIT_DATABASE_PASSWORD")
+	cfg.Database.OwnerPassword = os.Getenv("AUDIT_DATABASE_OWNER_PASSWORD")
+
 	return &cfg, nil
 }
 
@@ -201,18 +305,20 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("server.write_timeout", "30s")
 	v.SetDefault("server.shutdown_timeout", "30s")
 	v.SetDefault("server.grpc_port", 9085)
+	v.SetDefault("server.tls.client_auth", "none")
 
 	// Database
 	v.SetDefault("database.host", "localhost")
 	v.SetDefault("database.port", 5432)
-	v.SetDefault("database.user", "postgres")
-	v.SetDefault("database.password", "postgres")
+	v.SetDefault("database.user", "audit_app") // Append-only role; never the owner or a superuser
 	v.SetDefault("database.dbname", "compliance_db")
 	v.SetDefault("database.sslmode", "disable")
 	v.SetDefault("database.max_open_conns", 25)
 	v.SetDefault("database.max_idle_conns", 5)
 	v.SetDefault("database.conn_max_lifetime", "5m")
 	v.SetDefault("database.conn_max_idle_time", "5m")
+	v.SetDefault("database.owner_user", "audit_owner")
+	v.SetDefault("database.auto_migrate", false)
 
 	// Elasticsearch
 	v.SetDefault("elasticsearch.addresses", []string{"http://localhost:9200"})
@@ -235,7 +341,10 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("kafka.transaction_topic", "banking.transactions")
 	v.SetDefault("kafka.user_topic", "banking.users")
 	v.SetDefault("kafka.alert_topic", "banking.compliance.alerts")
+	v.SetDefault("kafka.dlq_topic", "banking.audit.dlq")
 	v.SetDefault("kafka.enable_idempotent", true)
+	v.SetDefault("kafka.batch_size", 500)
+	v.SetDefault("kafka.batch_timeout", "50ms")
 
 	// S3
 	v.SetDefault("s3.region", "us-east-1")
@@ -247,10 +356,49 @@ func setDefaults(v *viper.Viper) {
 
 	// Encryption
 	v.SetDefault("encryption.current_key_version", 1)
+	v.SetDefault("encryption.signing_algorithm", "hmac") // ed25519 requires signing_private_key
+	v.SetDefault("encryption.legacy_hmac_until_sequence", 0)
 
 	// Auth
 	v.SetDefault("auth.jwt_public_key_path", "./keys/jwt_public.pem")
 	v.SetDefault("auth.jwt_issuer", "banking-auth-service")
+	v.SetDefault("auth.jwt_audience", "audit-compliance-service")
+	v.SetDefault("auth.jwt_clock_skew", "30s")
+	v.SetDefault("auth.jwks_url", "")
+	v.SetDefault("auth.jwks_refresh_interval", "5m")
+	v.SetDefault("auth.insecure_dev_mode", false)
+	v.SetDefault("auth.dev_role", "AUDITOR")
+	v.SetDefault("auth.purpose.codes", []string{"CASE", "TICKET", "SAR", "REGULATOR", "AUDIT", "GDPR"})
+	v.SetDefault("auth.purpose.exempt_roles", []string{"SERVICE"})
+	v.SetDefault("auth.rbac.role_aliases", map[string]string{})
+	v.SetDefault("auth.rbac.roles", map[string]interface{}{
+		"COMPLIANCE_OFFICER": map[string]interface{}{
+			"permissions":    []string{"events:read", "events:search", "events:export", "integrity:verify", "access_logs:read"},
+			"resource_types": []string{"*"},
+		},
+		"DPO": map[string]interface{}{
+			"permissions":    []string{"events:read", "events:search", "events:export", "access_logs:read"},
+			"resource_types": []string{"*"},
+		},
+		"AUDITOR": map[string]interface{}{
+			"permissions":    []string{"events:read", "events:search", "integrity:verify", "access_logs:read"},
+			"resource_types": []string{"*"},
+			"redact":         []string{"ip_address", "geolocation", "user_agent", "session_id", "data_before", "data_after"},
+		},
+		"AML_ANALYST": map[string]interface{}{
+			"permissions":    []string{"events:read", "events:search"},
+			"resource_types": []string{"ACCOUNT", "TRANSFER", "TRANSACTION", "AML_FLAG", "KYC", "REPORT"},
+			"redact":         []string{"user_agent", "session_id", "data_before", "data_after"},
+		},
+		"SERVICE": map[string]interface{}{
+			"permissions":    []string{"events:write", "events:read", "integrity:verify"},
+			"resource_types": []string{"*"},
+			"redact":         []string{"ip_address", "geolocation", "user_agent", "session_id", "failure_reason", "metadata", "data_before", "data_after"},
+		},
+		"OPERATOR": map[string]interface{}{
+			"permissions": []string{"dlq:replay"},
+		},
+	})
 
 	// Logging
 	v.SetDefault("logging.level", "info")
@@ -275,6 +423,14 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("compliance.report_retention_years", 10)
 	v.SetDefault("compliance.enable_auto_archive", true)
 	v.SetDefault("compliance.archive_schedule", "0 2 * * *") // 2 AM daily
+	v.SetDefault("compliance.integrity_check_interval", "24h")
+	v.SetDefault("compliance.integrity_check_window", "0s")
+	v.SetDefault("compliance.checkpoint_interval", "1h")
+	v.SetDefault("compliance.partition_interval", "0s")
+	v.SetDefault("compliance.partition_months_ahead", 3)
+	v.SetDefault("compliance.partition_archive_after_months", 12)
+	v.SetDefault("compliance.idempotency_key_ttl", "24h")
+	v.SetDefault("compliance.idempotency_cleanup_interval", "1h")
 
 	// Detection
 	v.SetDefault("detection.velocity_window_minutes", 60)
@@ -283,4 +439,8 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("detection.rapid_succession_window_mins", 15)
 	v.SetDefault("detection.high_risk_score_threshold", 70)
 	v.SetDefault("detection.enable_ml_models", false)
+
+	// Trusted timestamping
+	v.SetDefault("timestamp.timeout", "10s")
+	v.SetDefault("timestamp.required", false)
 }
@@ -145,6 +145,11 @@ func (e *FieldEncryptor) VerifyHMAC(data, signature string) bool {
 
 // GenerateHashChain creates a hash linking the current record to the previous one
 func (e *FieldEncryptor) GenerateHashChain(prevHash string, currentData []byte) string {
+	return HashChain(prevHash, currentData)
+}
+
+// HashChain is GenerateHashChain for callers without an encryptor; the chain is unkeyed
+func HashChain(prevHash string, currentData []byte) string {
 	h := sha256.New()
 	h.Write([]byte(prevHash))
 	h.Write(currentData)
@@ -192,14 +197,15 @@ func (e *FieldEncryptor) RotateKey(newKeyBase64 string, newVersion int) error {
 	return nil
 }
 
-// GenerateDigitalSignature creates a signature for audit records
+// GenerateDigitalSignature creates a version 1 (legacy) signature for audit records.
+// It covers five fields only; new records sign domain.AuditEvent.SignaturePayload instead.
 func (e *FieldEncryptor) GenerateDigitalSignature(eventID, userID, action, timestamp, result string) string {
 	// Concatenate all critical fields for signing
 	data := fmt.Sprintf("%s|%s|%s|%s|%s", eventID, userID, action, timestamp, result)
 	return e.HMAC(data)
 }
 
-// VerifyDigitalSignature verifies an audit record's digital signature
+// VerifyDigitalSignature verifies an audit record's version 1 (legacy) digital signature
 func (e *FieldEncryptor) VerifyDigitalSignature(eventID, userID, action, timestamp, result, signature string) bool {
 	data := fmt.Sprintf("%s|%s|%s|%s|%s", eventID, userID, action, timestamp, result)
 	return e.VerifyHMAC(data, signature)
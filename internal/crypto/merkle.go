@@ -0,0 +1,290 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"math/bits"
+)
+
+// Merkle tree helpers following RFC 6962 (Certificate Transparency). Leaves and interior
+// nodes use distinct prefixes so a leaf can never be passed off as a subtree.
+const (
+	merkleLeafPrefix = 0x00
+	merkleNodePrefix = 0x01
+)
+
+// MerkleLeafHash hashes a ledger entry into a tree leaf
+func MerkleLeafHash(data []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{merkleLeafPrefix})
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+func merkleNodeHash(left, right []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{merkleNodePrefix})
+	h.Write(left)
+	h.Write(right)
+	return h.Sum(nil)
+}
+
+// merkleSplit returns the largest power of two strictly smaller than n (n > 1)
+func merkleSplit(n int) int {
+	k := 1
+	for k<<1 < n {
+		k <<= 1
+	}
+	return k
+}
+
+// MerkleRoot computes the tree head over leaf hashes in ledger order
+func MerkleRoot(leafHashes [][]byte) []byte {
+	switch len(leafHashes) {
+	case 0:
+		empty := sha256.Sum256(nil)
+		return empty[:]
+	case 1:
+		return leafHashes[0]
+	}
+	k := merkleSplit(len(leafHashes))
+	return merkleNodeHash(MerkleRoot(leafHashes[:k]), MerkleRoot(leafHashes[k:]))
+}
+
+// MerkleNodeID addresses the perfect subtree of 2^Level leaves whose first leaf is Index<<Level
+type MerkleNodeID struct {
+	Level int
+	Index int64
+}
+
+// MerkleNode is the hash of a perfect subtree. Perfect subtrees never change as the tree
+// grows, so they can be stored once and reused for every later root and proof.
+type MerkleNode struct {
+	MerkleNodeID
+	Hash []byte
+}
+
+// MerkleSubtree is the leaf range [Start, End) hashed into a single root or proof node.
+// Only a subtree on the right edge of a tree is not itself a perfect subtree.
+type MerkleSubtree struct {
+	Start, End int64
+}
+
+// Nodes returns the perfect subtrees that make up s, largest first. MerkleFold turns
+// their hashes into the hash of s.
+func (s MerkleSubtree) Nodes() []MerkleNodeID {
+	ids := []MerkleNodeID{}
+	start := s.Start
+	size := uint64(s.End - s.Start)
+	for level := bits.Len64(size) - 1; level >= 0; level-- {
+		if size&(1<<level) != 0 {
+			ids = append(ids, MerkleNodeID{Level: level, Index: start >> level})
+			start += 1 << level
+		}
+	}
+	return ids
+}
+
+// MerkleFold hashes the perfect subtree hashes of a MerkleSubtree, in Nodes order, into its hash
+func MerkleFold(nodeHashes [][]byte) []byte {
+	if len(nodeHashes) == 0 {
+		return MerkleRoot(nil)
+	}
+	h := nodeHashes[len(nodeHashes)-1]
+	for i := len(nodeHashes) - 2; i >= 0; i-- {
+		h = merkleNodeHash(nodeHashes[i], h)
+	}
+	return h
+}
+
+// MerkleInclusionSubtrees returns the subtrees whose hashes form the audit path of the
+// leaf at index in a tree of treeSize leaves, in proof order
+func MerkleInclusionSubtrees(index, treeSize int64) []MerkleSubtree {
+	if index < 0 || index >= treeSize {
+		return []MerkleSubtree{}
+	}
+	return merklePath(0, index, treeSize)
+}
+
+// MerkleConsistencySubtrees returns the subtrees whose hashes form the proof that the tree
+// of oldSize leaves is a prefix of the tree of newSize leaves, in proof order
+func MerkleConsistencySubtrees(oldSize, newSize int64) []MerkleSubtree {
+	if oldSize <= 0 || oldSize >= newSize {
+		return []MerkleSubtree{}
+	}
+	// The old tree's last perfect subtree is where both trees start to differ
+	level := bits.TrailingZeros64(uint64(oldSize))
+	index := (oldSize - 1) >> level
+	proof := []MerkleSubtree{}
+	if index != 0 {
+		proof = append(proof, MerkleSubtree{Start: index << level, End: (index + 1) << level})
+	}
+	return append(proof, merklePath(level, index, newSize)...)
+}
+
+// merklePath climbs from the perfect subtree at level, index to the root of a tree of
+// treeSize leaves and returns the sibling of each node on the way. Siblings past the
+// right edge do not exist; those on it are cut short at treeSize.
+func merklePath(level int, index, treeSize int64) []MerkleSubtree {
+	path := []MerkleSubtree{}
+	for index != 0 || int64(1)<<level < treeSize {
+		start := (index ^ 1) << level
+		if start < treeSize {
+			path = append(path, MerkleSubtree{Start: start, End: min(start+int64(1)<<level, treeSize)})
+		}
+		index >>= 1
+		level++
+	}
+	return path
+}
+
+// MerkleFrontier is the right edge of a tree: the hashes of MerkleSubtree{0, size}.Nodes().
+// It is all that is needed to compute the root and to extend the tree one leaf at a time.
+type MerkleFrontier struct {
+	size   int64
+	hashes [][]byte
+}
+
+// NewMerkleFrontier resumes a tree of size leaves from its right edge
+func NewMerkleFrontier(size int64, nodeHashes [][]byte) (*MerkleFrontier, error) {
+	if size < 0 || len(nodeHashes) != bits.OnesCount64(uint64(size)) {
+		return nil, fmt.Errorf("a tree of %d leaves needs %d frontier hashes, got %d", size, bits.OnesCount64(uint64(size)), len(nodeHashes))
+	}
+	return &MerkleFrontier{
+		size:   size,
+		hashes: append([][]byte(nil), nodeHashes...),
+	}, nil
+}
+
+// Size returns the number of leaves in the tree
+func (f *MerkleFrontier) Size() int64 {
+	return f.size
+}
+
+// Root returns the tree head
+func (f *MerkleFrontier) Root() []byte {
+	return MerkleFold(f.hashes)
+}
+
+// Append adds a leaf and returns every perfect subtree it completes, the leaf itself first
+func (f *MerkleFrontier) Append(leafHash []byte) []MerkleNode {
+	index := f.size
+	h := leafHash
+	nodes := []MerkleNode{{MerkleNodeID: MerkleNodeID{Level: 0, Index: index}, Hash: h}}
+	for level := 1; index&1 == 1; level++ {
+		h = merkleNodeHash(f.hashes[len(f.hashes)-1], h)
+		f.hashes = f.hashes[:len(f.hashes)-1]
+		index >>= 1
+		nodes = append(nodes, MerkleNode{MerkleNodeID: MerkleNodeID{Level: level, Index: index}, Hash: h})
+	}
+	f.hashes = append(f.hashes, h)
+	f.size++
+	return nodes
+}
+
+// MerkleInclusionProof returns the audit path for the leaf at index in the tree formed by leafHashes
+func MerkleInclusionProof(leafHashes [][]byte, index int) [][]byte {
+	n := len(leafHashes)
+	if n <= 1 || index < 0 || index >= n {
+		return [][]byte{}
+	}
+	k := merkleSplit(n)
+	if index < k {
+		return append(MerkleInclusionProof(leafHashes[:k], index), MerkleRoot(leafHashes[k:]))
+	}
+	return append(MerkleInclusionProof(leafHashes[k:], index-k), MerkleRoot(leafHashes[:k]))
+}
+
+// MerkleConsistencyProof proves that the tree of the first oldSize leaves is a prefix of
+// the tree formed by leafHashes
+func MerkleConsistencyProof(leafHashes [][]byte, oldSize int) [][]byte {
+	if oldSize <= 0 || oldSize >= len(leafHashes) {
+		return [][]byte{}
+	}
+	return merkleSubproof(leafHashes, oldSize, true)
+}
+
+func merkleSubproof(leafHashes [][]byte, m int, complete bool) [][]byte {
+	n := len(leafHashes)
+	if m == n {
+		if complete {
+			return [][]byte{}
+		}
+		return [][]byte{MerkleRoot(leafHashes)}
+	}
+	k := merkleSplit(n)
+	if m <= k {
+		return append(merkleSubproof(leafHashes[:k], m, complete), MerkleRoot(leafHashes[k:]))
+	}
+	return append(merkleSubproof(leafHashes[k:], m-k, false), MerkleRoot(leafHashes[:k]))
+}
+
+// VerifyMerkleInclusion checks an audit path against a tree root (RFC 9162, 2.1.3.2)
+func VerifyMerkleInclusion(index, treeSize int64, leafHash []byte, proof [][]byte, root []byte) bool {
+	if index < 0 || index >= treeSize {
+		return false
+	}
+	fn, sn := index, treeSize-1
+	r := leafHash
+	for _, p := range proof {
+		if sn == 0 {
+			return false
+		}
+		if fn&1 == 1 || fn == sn {
+			r = merkleNodeHash(p, r)
+			for fn&1 == 0 && fn != 0 {
+				fn >>= 1
+				sn >>= 1
+			}
+		} else {
+			r = merkleNodeHash(r, p)
+		}
+		fn >>= 1
+		sn >>= 1
+	}
+	return sn == 0 && bytes.Equal(r, root)
+}
+
+// VerifyMerkleConsistency checks that oldRoot is a prefix of newRoot (RFC 9162, 2.1.4.2)
+func VerifyMerkleConsistency(oldSize, newSize int64, oldRoot, newRoot []byte, proof [][]byte) bool {
+	switch {
+	case oldSize <= 0 || oldSize > newSize:
+		return false
+	case oldSize == newSize:
+		return len(proof) == 0 && bytes.Equal(oldRoot, newRoot)
+	case len(proof) == 0:
+		return false
+	}
+
+	// When the old tree is a complete subtree its root is the implicit first proof node
+	if oldSize&(oldSize-1) == 0 {
+		proof = append([][]byte{oldRoot}, proof...)
+	}
+
+	fn, sn := oldSize-1, newSize-1
+	for fn&1 == 1 {
+		fn >>= 1
+		sn >>= 1
+	}
+
+	fr, sr := proof[0], proof[0]
+	for _, c := range proof[1:] {
+		if sn == 0 {
+			return false
+		}
+		if fn&1 == 1 || fn == sn {
+			fr = merkleNodeHash(c, fr)
+			sr = merkleNodeHash(c, sr)
+			for fn&1 == 0 && fn != 0 {
+				fn >>= 1
+				sn >>= 1
+			}
+		} else {
+			sr = merkleNodeHash(sr, c)
+		}
+		fn >>= 1
+		sn >>= 1
+	}
+	return sn == 0 && bytes.Equal(fr, oldRoot) && bytes.Equal(sr, newRoot)
+}
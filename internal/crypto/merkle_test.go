@@ -0,0 +1,227 @@
+package crypto
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// RFC 6962 reference inputs and tree heads, as used by the Certificate Transparency test suites
+var rfc6962Leaves = []string{
+	"", "00", "10", "2021", "3031", "40414243",
+	"5051525354555657", "606162636465666768696a6b6c6d6e6f",
+}
+
+var rfc6962Roots = []string{
+	"e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+	"6e340b9cffb37a989ca544e6bb780a2c78901d3fb33738768511a30617afa01d",
+	"fac54203e7cc696cf0dfcb42c92a1d9dbaf70ad9e621f4bd8d98662f00e3c125",
+	"aeb6bcfe274b70a14fb067a5e5578264db0fa9b51af5e0ba159158f329e06e77",
+	"d37ee418976dd95753c1c73862b9398fa2a2cf9b4ff0fdfe8b30cd95209614b7",
+	"4e3bbb1f7b478dcfe71fb631631519a3bca12c9aefca1612bfce4c13a86264d4",
+	"76e67dadbcdf1e10e1b74ddc608abd2f98dfb16fbce75277b5232a127f2087ef",
+	"ddb89be403809e325750d3d263cd78929c2942b7942a34b77e122c9594a74c8c",
+	"5dc9da79a70659a9ad559cb701ded9a2ab9d823aad2f4960cfe370eff4604328",
+}
+
+func rfc6962LeafHashes(t *testing.T) [][]byte {
+	t.Helper()
+	leaves := make([][]byte, len(rfc6962Leaves))
+	for i, l := range rfc6962Leaves {
+		data, err := hex.DecodeString(l)
+		require.NoError(t, err)
+		leaves[i] = MerkleLeafHash(data)
+	}
+	return leaves
+}
+
+func hexHashes(t *testing.T, hashes ...string) [][]byte {
+	t.Helper()
+	decoded := make([][]byte, len(hashes))
+	for i, h := range hashes {
+		b, err := hex.DecodeString(h)
+		require.NoError(t, err)
+		decoded[i] = b
+	}
+	return decoded
+}
+
+// syntheticLeaves returns n distinct leaf hashes
+func syntheticLeaves(n int) [][]byte {
+	leaves := make([][]byte, n)
+	for i := range leaves {
+		leaves[i] = MerkleLeafHash([]byte{byte(i >> 8), byte(i)})
+	}
+	return leaves
+}
+
+// storedNodes builds every perfect subtree of leaves, as a ledger that checkpointed them would hold
+func storedNodes(t *testing.T, leaves [][]byte) map[MerkleNodeID][]byte {
+	t.Helper()
+	f, err := NewMerkleFrontier(0, nil)
+	require.NoError(t, err)
+	nodes := make(map[MerkleNodeID][]byte)
+	for _, leaf := range leaves {
+		for _, n := range f.Append(leaf) {
+			nodes[n.MerkleNodeID] = n.Hash
+		}
+	}
+	return nodes
+}
+
+// foldSubtrees hashes subtrees from stored perfect subtrees only
+func foldSubtrees(t *testing.T, nodes map[MerkleNodeID][]byte, subtrees []MerkleSubtree) [][]byte {
+	t.Helper()
+	hashes := make([][]byte, len(subtrees))
+	for i, s := range subtrees {
+		var parts [][]byte
+		for _, id := range s.Nodes() {
+			h, ok := nodes[id]
+			require.True(t, ok, "node %+v is not stored", id)
+			parts = append(parts, h)
+		}
+		hashes[i] = MerkleFold(parts)
+	}
+	return hashes
+}
+
+func TestMerkleRootRFC6962(t *testing.T) {
+	leaves := rfc6962LeafHashes(t)
+	f, err := NewMerkleFrontier(0, nil)
+	require.NoError(t, err)
+	assert.Equal(t, rfc6962Roots[0], hex.EncodeToString(f.Root()))
+
+	for size := 1; size <= len(leaves); size++ {
+		f.Append(leaves[size-1])
+		assert.Equal(t, rfc6962Roots[size], hex.EncodeToString(MerkleRoot(leaves[:size])), "root of %d leaves", size)
+		assert.Equal(t, rfc6962Roots[size], hex.EncodeToString(f.Root()), "frontier root of %d leaves", size)
+	}
+}
+
+func TestMerkleInclusionRFC6962(t *testing.T) {
+	leaves := rfc6962LeafHashes(t)
+	nodes := storedNodes(t, leaves)
+
+	tests := []struct {
+		index, size int64
+		proof       [][]byte
+	}{
+		{index: 0, size: 1, proof: [][]byte{}},
+		{index: 0, size: 8, proof: hexHashes(t,
+			"96a296d224f285c67bee93c30f8a309157f0daa35dc5b87e410b78630a09cfc7",
+			"5f083f0a1a33ca076a95279832580db3e0ef4584bdff1f54c8a360f50de3031e",
+			"6b47aaf29ee3c2af9af889bc1fb9254dabd31177f16232dd6aab035ca39bf6e4")},
+		{index: 5, size: 8, proof: hexHashes(t,
+			"bc1a0643b12e4d2d7c77918f44e0f4f79a838b6cf9ec5b5c283e1f4d88599e6b",
+			"ca854ea128ed050b41b35ffc1b87b8eb2bde461e9e3b5596ece6b9d5975a0ae0",
+			"d37ee418976dd95753c1c73862b9398fa2a2cf9b4ff0fdfe8b30cd95209614b7")},
+		{index: 2, size: 3, proof: hexHashes(t,
+			"fac54203e7cc696cf0dfcb42c92a1d9dbaf70ad9e621f4bd8d98662f00e3c125")},
+		{index: 1, size: 5, proof: hexHashes(t,
+			"6e340b9cffb37a989ca544e6bb780a2c78901d3fb33738768511a30617afa01d",
+			"5f083f0a1a33ca076a95279832580db3e0ef4584bdff1f54c8a360f50de3031e",
+			"bc1a0643b12e4d2d7c77918f44e0f4f79a838b6cf9ec5b5c283e1f4d88599e6b")},
+	}
+
+	for _, tt := range tests {
+		root := hexHashes(t, rfc6962Roots[tt.size])[0]
+		assert.Equal(t, tt.proof, MerkleInclusionProof(leaves[:tt.size], int(tt.index)), "proof of %d in %d", tt.index, tt.size)
+		assert.Equal(t, tt.proof, foldSubtrees(t, nodes, MerkleInclusionSubtrees(tt.index, tt.size)), "stored proof of %d in %d", tt.index, tt.size)
+		assert.True(t, VerifyMerkleInclusion(tt.index, tt.size, leaves[tt.index], tt.proof, root))
+	}
+}
+
+func TestMerkleConsistencyRFC6962(t *testing.T) {
+	leaves := rfc6962LeafHashes(t)
+	nodes := storedNodes(t, leaves)
+
+	tests := []struct {
+		oldSize, newSize int64
+		proof            [][]byte
+	}{
+		{oldSize: 1, newSize: 1, proof: [][]byte{}},
+		{oldSize: 1, newSize: 8, proof: hexHashes(t,
+			"96a296d224f285c67bee93c30f8a309157f0daa35dc5b87e410b78630a09cfc7",
+			"5f083f0a1a33ca076a95279832580db3e0ef4584bdff1f54c8a360f50de3031e",
+			"6b47aaf29ee3c2af9af889bc1fb9254dabd31177f16232dd6aab035ca39bf6e4")},
+		{oldSize: 6, newSize: 8, proof: hexHashes(t,
+			"0ebc5d3437fbe2db158b9f126a1d118e308181031d0a949f8dededebc558ef6a",
+			"ca854ea128ed050b41b35ffc1b87b8eb2bde461e9e3b5596ece6b9d5975a0ae0",
+			"d37ee418976dd95753c1c73862b9398fa2a2cf9b4ff0fdfe8b30cd95209614b7")},
+		{oldSize: 2, newSize: 5, proof: hexHashes(t,
+			"5f083f0a1a33ca076a95279832580db3e0ef4584bdff1f54c8a360f50de3031e",
+			"bc1a0643b12e4d2d7c77918f44e0f4f79a838b6cf9ec5b5c283e1f4d88599e6b")},
+	}
+
+	for _, tt := range tests {
+		oldRoot := hexHashes(t, rfc6962Roots[tt.oldSize])[0]
+		newRoot := hexHashes(t, rfc6962Roots[tt.newSize])[0]
+		assert.Equal(t, tt.proof, MerkleConsistencyProof(leaves[:tt.newSize], int(tt.oldSize)), "proof of %d to %d", tt.oldSize, tt.newSize)
+		assert.Equal(t, tt.proof, foldSubtrees(t, nodes, MerkleConsistencySubtrees(tt.oldSize, tt.newSize)), "stored proof of %d to %d", tt.oldSize, tt.newSize)
+		assert.True(t, VerifyMerkleConsistency(tt.oldSize, tt.newSize, oldRoot, newRoot, tt.proof))
+	}
+}
+
+// TestMerkleStoredNodesMatchLeaves checks roots and proofs built from stored perfect
+// subtrees against the leaf-based reference for sizes around every power of two
+func TestMerkleStoredNodesMatchLeaves(t *testing.T) {
+	leaves := syntheticLeaves(70)
+	nodes := storedNodes(t, leaves)
+
+	for _, size := range []int64{1, 2, 3, 4, 5, 7, 8, 9, 15, 16, 17, 31, 32, 33, 63, 64, 65} {
+		tree := leaves[:size]
+		root := MerkleRoot(tree)
+		assert.Equal(t, [][]byte{root}, foldSubtrees(t, nodes, []MerkleSubtree{{Start: 0, End: size}}), "root of %d", size)
+
+		for _, index := range []int64{0, 1, size / 2, size - 2, size - 1} {
+			if index < 0 || index >= size {
+				continue
+			}
+			proof := foldSubtrees(t, nodes, MerkleInclusionSubtrees(index, size))
+			assert.Equal(t, MerkleInclusionProof(tree, int(index)), proof, "inclusion of %d in %d", index, size)
+			assert.True(t, VerifyMerkleInclusion(index, size, leaves[index], proof, root), "inclusion of %d in %d", index, size)
+			assert.False(t, VerifyMerkleInclusion(index, size+1, leaves[index], proof, MerkleRoot(leaves[:size+1])), "a proof is bound to its tree")
+			if size > 1 {
+				other := (index + 1) % size
+				assert.False(t, VerifyMerkleInclusion(other, size, leaves[index], proof, root), "a proof is bound to its index")
+			}
+		}
+
+		for _, oldSize := range []int64{1, size / 2, size - 1} {
+			if oldSize <= 0 || oldSize >= size {
+				continue
+			}
+			oldRoot := MerkleRoot(leaves[:oldSize])
+			proof := foldSubtrees(t, nodes, MerkleConsistencySubtrees(oldSize, size))
+			assert.Equal(t, MerkleConsistencyProof(tree, int(oldSize)), proof, "consistency of %d to %d", oldSize, size)
+			assert.True(t, VerifyMerkleConsistency(oldSize, size, oldRoot, root, proof), "consistency of %d to %d", oldSize, size)
+			assert.False(t, VerifyMerkleConsistency(oldSize+1, size, oldRoot, root, proof), "a proof is bound to the old size")
+		}
+	}
+}
+
+func TestMerkleFrontierResume(t *testing.T) {
+	leaves := syntheticLeaves(40)
+	nodes := storedNodes(t, leaves)
+
+	for _, size := range []int64{0, 1, 6, 16, 17, 33} {
+		var edge [][]byte
+		for _, id := range (MerkleSubtree{Start: 0, End: size}).Nodes() {
+			edge = append(edge, nodes[id])
+		}
+		f, err := NewMerkleFrontier(size, edge)
+		require.NoError(t, err)
+		for i := size; i < int64(len(leaves)); i++ {
+			for _, n := range f.Append(leaves[i]) {
+				assert.Equal(t, nodes[n.MerkleNodeID], n.Hash, "node %+v after resuming at %d", n.MerkleNodeID, size)
+			}
+		}
+		assert.Equal(t, MerkleRoot(leaves), f.Root())
+		assert.Equal(t, int64(len(leaves)), f.Size())
+	}
+
+	_, err := NewMerkleFrontier(3, [][]byte{{1}})
+	assert.Error(t, err, "A tree of 3 leaves has two frontier subtrees")
+}
@@ -0,0 +1,271 @@
+package crypto
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/banking/audit-compliance/internal/config"
+)
+
+// Supported audit record signature algorithms
+const (
+	AlgorithmHMACSHA256 = "HMAC-SHA256" // Legacy shared-secret mode
+	AlgorithmEd25519    = "Ed25519"
+)
+
+// hmacKeyID identifies the single, unversioned HMAC secret
+const hmacKeyID = "hmac"
+
+// Signer signs audit record payloads and verifies signatures made with its keys
+type Signer interface {
+	// Algorithm returns the algorithm stored alongside each signature
+	Algorithm() string
+	// KeyID returns the ID of the key used for new signatures
+	KeyID() string
+	// Sign signs payload with the current key
+	Sign(payload []byte) (string, error)
+	// Verify checks a signature made with the key identified by keyID
+	Verify(keyID string, payload []byte, signature string) bool
+}
+
+// PublicKey is a verification key in JWK form, published for external auditors
+type PublicKey struct {
+	KeyID     string `json:"kid"`
+	KeyType   string `json:"kty"`
+	Curve     string `json:"crv"`
+	Algorithm string `json:"alg"`
+	X         string `json:"x"`      // base64url-encoded public key
+	Status    string `json:"status"` // ACTIVE or RETIRED
+}
+
+// PublicKeySet is the published set of audit signing keys
+type PublicKeySet struct {
+	Keys []PublicKey `json:"keys"`
+}
+
+// HMACSigner signs with the FieldEncryptor's shared HMAC secret.
+// Anyone able to verify can also forge; kept for legacy rows and as a selectable mode.
+type HMACSigner struct {
+	encryptor *FieldEncryptor
+}
+
+// NewHMACSigner creates a signer backed by the encryptor's HMAC secret
+func NewHMACSigner(encryptor *FieldEncryptor) *HMACSigner {
+	return &HMACSigner{encryptor: encryptor}
+}
+
+func (s *HMACSigner) Algorithm() string { return AlgorithmHMACSHA256 }
+func (s *HMACSigner) KeyID() string     { return hmacKeyID }
+
+// Sign returns a hex-encoded HMAC-SHA256 of payload
+func (s *HMACSigner) Sign(payload []byte) (string, error) {
+	return s.encryptor.HMAC(string(payload)), nil
+}
+
+// Verify checks an HMAC signature. Rows written before key IDs were stored have an empty keyID.
+func (s *HMACSigner) Verify(keyID string, payload []byte, signature string) bool {
+	if keyID != "" && keyID != hmacKeyID {
+		return false
+	}
+	return s.encryptor.VerifyHMAC(string(payload), signature)
+}
+
+// Ed25519Signer signs with an Ed25519 private key and verifies against the active
+// and retired public keys, so rotation never invalidates existing rows.
+type Ed25519Signer struct {
+	keyID      string
+	privateKey ed25519.PrivateKey
+	publicKeys map[string]ed25519.PublicKey
+}
+
+// NewEd25519Signer creates a signer from a base64 Ed25519 seed (32 bytes) or private key
+// (64 bytes), plus retired public keys (kid -> base64) that remain valid for verification.
+func NewEd25519Signer(keyID, privateKeyBase64 string, retiredPublicKeys map[string]string) (*Ed25519Signer, error) {
+	if keyID == "" {
+		return nil, errors.New("signing key ID is required")
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(privateKeyBase64)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode signing key: %w", err)
+	}
+
+	var privateKey ed25519.PrivateKey
+	switch len(raw) {
+	case ed25519.SeedSize:
+		privateKey = ed25519.NewKeyFromSeed(raw)
+	case ed25519.PrivateKeySize:
+		privateKey = ed25519.PrivateKey(raw)
+	default:
+		return nil, fmt.Errorf("signing key must be %d or %d bytes, got %d", ed25519.SeedSize, ed25519.PrivateKeySize, len(raw))
+	}
+
+	publicKeys := map[string]ed25519.PublicKey{
+		keyID: privateKey.Public().(ed25519.PublicKey),
+	}
+	for kid, pubB64 := range retiredPublicKeys {
+		if kid == keyID {
+			return nil, fmt.Errorf("retired key %q collides with the active key ID", kid)
+		}
+		pub, err := base64.StdEncoding.DecodeString(pubB64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode retired key %q: %w", kid, err)
+		}
+		if len(pub) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("retired key %q must be %d bytes, got %d", kid, ed25519.PublicKeySize, len(pub))
+		}
+		publicKeys[kid] = ed25519.PublicKey(pub)
+	}
+
+	return &Ed25519Signer{
+		keyID:      keyID,
+		privateKey: privateKey,
+		publicKeys: publicKeys,
+	}, nil
+}
+
+func (s *Ed25519Signer) Algorithm() string { return AlgorithmEd25519 }
+func (s *Ed25519Signer) KeyID() string     { return s.keyID }
+
+// Sign returns a base64-encoded Ed25519 signature of payload
+func (s *Ed25519Signer) Sign(payload []byte) (string, error) {
+	return base64.StdEncoding.EncodeToString(ed25519.Sign(s.privateKey, payload)), nil
+}
+
+// Verify checks a base64-encoded Ed25519 signature against the public key for keyID
+func (s *Ed25519Signer) Verify(keyID string, payload []byte, signature string) bool {
+	pub, ok := s.publicKeys[keyID]
+	if !ok {
+		return false
+	}
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	return ed25519.Verify(pub, payload, sig)
+}
+
+// PublicKeys returns every verification key, active key first
+func (s *Ed25519Signer) PublicKeys() []PublicKey {
+	kids := make([]string, 0, len(s.publicKeys))
+	for kid := range s.publicKeys {
+		if kid != s.keyID {
+			kids = append(kids, kid)
+		}
+	}
+	sort.Strings(kids)
+	kids = append([]string{s.keyID}, kids...)
+
+	keys := make([]PublicKey, 0, len(kids))
+	for _, kid := range kids {
+		status := "RETIRED"
+		if kid == s.keyID {
+			status = "ACTIVE"
+		}
+		keys = append(keys, PublicKey{
+			KeyID:     kid,
+			KeyType:   "OKP",
+			Curve:     "Ed25519",
+			Algorithm: "EdDSA",
+			X:         base64.RawURLEncoding.EncodeToString(s.publicKeys[kid]),
+			Status:    status,
+		})
+	}
+	return keys
+}
+
+// Keyring holds the signer used for new records and every signer able to verify old ones
+type Keyring struct {
+	active      Signer
+	byAlgorithm map[string]Signer
+	ed25519     *Ed25519Signer
+	// legacyHMACUntil is the last chain position HMAC signatures are accepted for once
+	// records are signed with Ed25519; -1 while HMAC is the active algorithm
+	legacyHMACUntil int64
+}
+
+// NewKeyring builds the keyring selected by cfg.SigningAlgorithm. Once Ed25519 is active,
+// HMAC signatures verify only up to cfg.LegacyHMACUntilSequence: anyone holding the HMAC
+// secret can forge them, so they are not trusted for records written after the cut-over.
+func NewKeyring(cfg config.EncryptionConfig, encryptor *FieldEncryptor) (*Keyring, error) {
+	k := &Keyring{
+		byAlgorithm: map[string]Signer{},
+	}
+
+	hmacSigner := NewHMACSigner(encryptor)
+	k.byAlgorithm[hmacSigner.Algorithm()] = hmacSigner
+
+	if cfg.SigningPrivateKey != "" {
+		edSigner, err := NewEd25519Signer(cfg.SigningKeyID, cfg.SigningPrivateKey, cfg.SigningRetiredKeys)
+		if err != nil {
+			return nil, err
+		}
+		k.byAlgorithm[edSigner.Algorithm()] = edSigner
+		k.ed25519 = edSigner
+	}
+
+	if cfg.LegacyHMACUntilSequence < 0 {
+		return nil, errors.New("legacy HMAC cut-over sequence cannot be negative")
+	}
+	switch strings.ToLower(cfg.SigningAlgorithm) {
+	case "hmac", "":
+		k.active = hmacSigner
+		k.legacyHMACUntil = -1
+	case "ed25519":
+		if k.ed25519 == nil {
+			return nil, errors.New("ed25519 signing selected but no signing private key configured")
+		}
+		k.active = k.ed25519
+		k.legacyHMACUntil = cfg.LegacyHMACUntilSequence
+	default:
+		return nil, fmt.Errorf("unknown signing algorithm %q", cfg.SigningAlgorithm)
+	}
+
+	return k, nil
+}
+
+// CheckLegacyHMAC returns an error when the ledger holds HMAC signatures up to
+// lastHMACSequence that would be rejected because Ed25519 is active with no cut-over
+// configured. Starting anyway would report every such record as tampered.
+func (k *Keyring) CheckLegacyHMAC(lastHMACSequence int64) error {
+	if k.legacyHMACUntil == 0 && lastHMACSequence > 0 {
+		return fmt.Errorf("ledger holds HMAC signatures up to sequence %d but no legacy HMAC cut-over is configured; set encryption.legacy_hmac_until_sequence to the last sequence signed with HMAC", lastHMACSequence)
+	}
+	return nil
+}
+
+// Active returns the signer used for new records
+func (k *Keyring) Active() Signer {
+	return k.active
+}
+
+// Verify checks a signature with the signer registered for algorithm. position is the
+// chain position the signature covers, an event's sequence number or a checkpoint's tree
+// size, and decides whether an HMAC signature is still trusted.
+// Rows without a stored algorithm predate asymmetric signing and are HMAC.
+func (k *Keyring) Verify(algorithm, keyID string, position int64, payload []byte, signature string) bool {
+	if algorithm == "" {
+		algorithm = AlgorithmHMACSHA256
+	}
+	if algorithm == AlgorithmHMACSHA256 && k.legacyHMACUntil >= 0 && position > k.legacyHMACUntil {
+		return false
+	}
+	signer, ok := k.byAlgorithm[algorithm]
+	if !ok {
+		return false
+	}
+	return signer.Verify(keyID, payload, signature)
+}
+
+// PublicKeys returns the published verification key set. HMAC secrets are never published.
+func (k *Keyring) PublicKeys() PublicKeySet {
+	set := PublicKeySet{Keys: []PublicKey{}}
+	if k.ed25519 != nil {
+		set.Keys = k.ed25519.PublicKeys()
+	}
+	return set
+}
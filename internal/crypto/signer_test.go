@@ -0,0 +1,171 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"testing"
+
+	"github.com/banking/audit-compliance/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testEncryptor(t *testing.T) *FieldEncryptor {
+	t.Helper()
+	key := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{7}, 32))
+	encryptor, err := NewFieldEncryptor([]string{key}, 1, base64.StdEncoding.EncodeToString([]byte("hmac-secret")))
+	require.NoError(t, err)
+	return encryptor
+}
+
+func testSeed(b byte) string {
+	return base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{b}, ed25519.SeedSize))
+}
+
+func testPublicKey(b byte) string {
+	pub := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{b}, ed25519.SeedSize)).Public().(ed25519.PublicKey)
+	return base64.StdEncoding.EncodeToString(pub)
+}
+
+func TestNewKeyring(t *testing.T) {
+	tests := []struct {
+		name      string
+		cfg       config.EncryptionConfig
+		algorithm string
+		wantErr   bool
+	}{
+		{name: "default is hmac", cfg: config.EncryptionConfig{}, algorithm: AlgorithmHMACSHA256},
+		{name: "ed25519", cfg: config.EncryptionConfig{SigningAlgorithm: "ed25519", SigningKeyID: "k1", SigningPrivateKey: testSeed(1)}, algorithm: AlgorithmEd25519},
+		{name: "ed25519 without a key", cfg: config.EncryptionConfig{SigningAlgorithm: "ed25519"}, wantErr: true},
+		{name: "unknown algorithm", cfg: config.EncryptionConfig{SigningAlgorithm: "rsa"}, wantErr: true},
+		{name: "negative cut-over", cfg: config.EncryptionConfig{LegacyHMACUntilSequence: -1}, wantErr: true},
+		{
+			name: "retired key reuses the active ID",
+			cfg: config.EncryptionConfig{SigningAlgorithm: "ed25519", SigningKeyID: "k1", SigningPrivateKey: testSeed(1),
+				SigningRetiredKeys: map[string]string{"k1": testPublicKey(2)}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k, err := NewKeyring(tt.cfg, testEncryptor(t))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.algorithm, k.Active().Algorithm())
+		})
+	}
+}
+
+func TestKeyringRotation(t *testing.T) {
+	encryptor := testEncryptor(t)
+	payload := []byte("payload")
+
+	old, err := NewKeyring(config.EncryptionConfig{SigningAlgorithm: "ed25519", SigningKeyID: "k1", SigningPrivateKey: testSeed(1)}, encryptor)
+	require.NoError(t, err)
+	oldSig, err := old.Active().Sign(payload)
+	require.NoError(t, err)
+
+	rotated, err := NewKeyring(config.EncryptionConfig{
+		SigningAlgorithm: "ed25519", SigningKeyID: "k2", SigningPrivateKey: testSeed(2),
+		SigningRetiredKeys: map[string]string{"k1": testPublicKey(1)},
+	}, encryptor)
+	require.NoError(t, err)
+	newSig, err := rotated.Active().Sign(payload)
+	require.NoError(t, err)
+
+	assert.Equal(t, "k2", rotated.Active().KeyID())
+	assert.True(t, rotated.Verify(AlgorithmEd25519, "k1", 1, payload, oldSig), "A retired key still verifies its rows")
+	assert.True(t, rotated.Verify(AlgorithmEd25519, "k2", 2, payload, newSig))
+	assert.False(t, rotated.Verify(AlgorithmEd25519, "k2", 1, payload, oldSig), "A signature only verifies under its own key ID")
+	assert.False(t, rotated.Verify(AlgorithmEd25519, "k3", 1, payload, oldSig), "Unknown key IDs never verify")
+	assert.False(t, rotated.Verify(AlgorithmEd25519, "k1", 1, []byte("other"), oldSig))
+
+	keys := rotated.PublicKeys().Keys
+	require.Len(t, keys, 2)
+	assert.Equal(t, PublicKey{KeyID: "k2", KeyType: "OKP", Curve: "Ed25519", Algorithm: "EdDSA",
+		X:      base64.RawURLEncoding.EncodeToString(ed25519.NewKeyFromSeed(bytes.Repeat([]byte{2}, ed25519.SeedSize)).Public().(ed25519.PublicKey)),
+		Status: "ACTIVE"}, keys[0])
+	assert.Equal(t, "k1", keys[1].KeyID)
+	assert.Equal(t, "RETIRED", keys[1].Status)
+}
+
+func TestKeyringAlgorithmMismatch(t *testing.T) {
+	encryptor := testEncryptor(t)
+	payload := []byte("payload")
+	k, err := NewKeyring(config.EncryptionConfig{SigningAlgorithm: "ed25519", SigningKeyID: "k1", SigningPrivateKey: testSeed(1), LegacyHMACUntilSequence: 10}, encryptor)
+	require.NoError(t, err)
+
+	edSig, err := k.Active().Sign(payload)
+	require.NoError(t, err)
+	hmacSig, err := NewHMACSigner(encryptor).Sign(payload)
+	require.NoError(t, err)
+
+	assert.False(t, k.Verify(AlgorithmHMACSHA256, "k1", 1, payload, edSig), "An Ed25519 signature is not an HMAC")
+	assert.False(t, k.Verify(AlgorithmEd25519, hmacKeyID, 1, payload, hmacSig), "An HMAC is not an Ed25519 signature")
+	assert.False(t, k.Verify("RSA-SHA256", "k1", 1, payload, edSig), "Unknown algorithms never verify")
+	assert.True(t, k.Verify(AlgorithmHMACSHA256, hmacKeyID, 1, payload, hmacSig))
+	assert.True(t, k.Verify("", "", 1, payload, hmacSig), "Rows without an algorithm are legacy HMAC")
+}
+
+func TestKeyringLegacyHMACCutover(t *testing.T) {
+	encryptor := testEncryptor(t)
+	payload := []byte("payload")
+	hmacSig, err := NewHMACSigner(encryptor).Sign(payload)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		cfg      config.EncryptionConfig
+		position int64
+		valid    bool
+	}{
+		{name: "hmac mode accepts every position", cfg: config.EncryptionConfig{SigningAlgorithm: "hmac"}, position: 1 << 40, valid: true},
+		{name: "before the cut-over", cfg: config.EncryptionConfig{SigningAlgorithm: "ed25519", SigningKeyID: "k1", SigningPrivateKey: testSeed(1), LegacyHMACUntilSequence: 100}, position: 100, valid: true},
+		{name: "after the cut-over", cfg: config.EncryptionConfig{SigningAlgorithm: "ed25519", SigningKeyID: "k1", SigningPrivateKey: testSeed(1), LegacyHMACUntilSequence: 100}, position: 101},
+		{name: "no legacy rows", cfg: config.EncryptionConfig{SigningAlgorithm: "ed25519", SigningKeyID: "k1", SigningPrivateKey: testSeed(1)}, position: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k, err := NewKeyring(tt.cfg, encryptor)
+			require.NoError(t, err)
+			assert.Equal(t, tt.valid, k.Verify(AlgorithmHMACSHA256, hmacKeyID, tt.position, payload, hmacSig))
+		})
+	}
+}
+
+func TestKeyringCheckLegacyHMAC(t *testing.T) {
+	ed := config.EncryptionConfig{SigningAlgorithm: "ed25519", SigningKeyID: "k1", SigningPrivateKey: testSeed(1)}
+	cutover := ed
+	cutover.LegacyHMACUntilSequence = 100
+
+	tests := []struct {
+		name     string
+		cfg      config.EncryptionConfig
+		lastHMAC int64
+		wantErr  bool
+	}{
+		{name: "hmac mode", cfg: config.EncryptionConfig{SigningAlgorithm: "hmac"}, lastHMAC: 100},
+		{name: "ed25519 from the start", cfg: ed},
+		{name: "hmac history without a cut-over", cfg: ed, lastHMAC: 100, wantErr: true},
+		{name: "hmac history with a cut-over", cfg: cutover, lastHMAC: 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k, err := NewKeyring(tt.cfg, testEncryptor(t))
+			require.NoError(t, err)
+			err = k.CheckLegacyHMAC(tt.lastHMAC)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
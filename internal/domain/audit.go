@@ -1,6 +1,9 @@
 package domain
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -26,8 +29,21 @@ const (
 	ActionTypeRevoke      ActionType = "REVOKE"
 	ActionTypeEscalate    ActionType = "ESCALATE"
 	ActionTypeInvestigate ActionType = "INVESTIGATE"
+	ActionTypeVerify      ActionType = "VERIFY"
 )
 
+// IsValid reports whether a is a known action type
+func (a ActionType) IsValid() bool {
+	switch a {
+	case ActionTypeCreate, ActionTypeRead, ActionTypeUpdate, ActionTypeDelete, ActionTypeLogin,
+		ActionTypeLogout, ActionTypeTransfer, ActionTypeApprove, ActionTypeReject, ActionTypeFreeze,
+		ActionTypeUnfreeze, ActionTypeExport, ActionTypeConsent, ActionTypeRevoke, ActionTypeEscalate,
+		ActionTypeInvestigate, ActionTypeVerify:
+		return true
+	}
+	return false
+}
+
 // ResourceType represents the type of resource being accessed
 type ResourceType string
 
@@ -44,8 +60,20 @@ const (
 	ResourceTypeDevice      ResourceType = "DEVICE"
 	ResourceTypeAddress     ResourceType = "ADDRESS"
 	ResourceTypeDocument    ResourceType = "DOCUMENT"
+	ResourceTypeLedger      ResourceType = "LEDGER"
 )
 
+// IsValid reports whether r is a known resource type
+func (r ResourceType) IsValid() bool {
+	switch r {
+	case ResourceTypeAccount, ResourceTypeUser, ResourceTypeTransfer, ResourceTypeTransaction,
+		ResourceTypeKYC, ResourceTypeAMLFlag, ResourceTypeReport, ResourceTypeConsent, ResourceTypeSession,
+		ResourceTypeDevice, ResourceTypeAddress, ResourceTypeDocument, ResourceTypeLedger:
+		return true
+	}
+	return false
+}
+
 // AuditResult represents the result of an audited action
 type AuditResult string
 
@@ -56,33 +84,52 @@ const (
 	AuditResultDenied  AuditResult = "DENIED"
 )
 
+// IsValid reports whether r is a known result
+func (r AuditResult) IsValid() bool {
+	switch r {
+	case AuditResultSuccess, AuditResultFailure, AuditResultPending, AuditResultDenied:
+		return true
+	}
+	return false
+}
+
 // AuditEvent represents an immutable audit log entry
 // This record can NEVER be modified or deleted - core regulatory requirement
 type AuditEvent struct {
-	EventID           uuid.UUID    `json:"event_id" db:"event_id"`
-	TransactionID     *uuid.UUID   `json:"transaction_id,omitempty" db:"transaction_id"`
-	UserID            uuid.UUID    `json:"user_id" db:"user_id"`
-	ActorID           *uuid.UUID   `json:"actor_id,omitempty" db:"actor_id"` // System/Admin who performed action
-	ActionType        ActionType   `json:"action_type" db:"action_type"`
-	ResourceType      ResourceType `json:"resource_type" db:"resource_type"`
-	ResourceID        string       `json:"resource_id" db:"resource_id"`
-	ServiceSource     string       `json:"service_source" db:"service_source"`
-	Timestamp         time.Time    `json:"timestamp" db:"timestamp"`
-	Result            AuditResult  `json:"result" db:"result"`
-	FailureReason     *string      `json:"failure_reason,omitempty" db:"failure_reason"`
-	IPAddress         string       `json:"ip_address" db:"ip_address"`
-	Geolocation       *string      `json:"geolocation,omitempty" db:"geolocation"`
-	UserAgent         *string      `json:"user_agent,omitempty" db:"user_agent"`
-	RequestID         string       `json:"request_id" db:"request_id"`
-	SessionID         *string      `json:"session_id,omitempty" db:"session_id"`
-	DigitalSignature  string       `json:"digital_signature" db:"digital_signature"` // HMAC signature for non-repudiation
-	Metadata          []byte       `json:"metadata,omitempty" db:"metadata"`         // JSON blob for additional context
-	DataBefore        []byte       `json:"-" db:"data_before"`                       // Encrypted state before change
-	DataAfter         []byte       `json:"-" db:"data_after"`                        // Encrypted state after change
-	ComplianceFlags   []string     `json:"compliance_flags,omitempty" db:"compliance_flags"`
-	RetentionCategory string       `json:"retention_category" db:"retention_category"`
-	EncryptionKeyID   int          `json:"-" db:"encryption_key_id"`
-	CreatedAt         time.Time    `json:"created_at" db:"created_at"`
+	EventID            uuid.UUID    `json:"event_id" db:"event_id"`
+	TransactionID      *uuid.UUID   `json:"transaction_id,omitempty" db:"transaction_id"`
+	UserID             uuid.UUID    `json:"user_id" db:"user_id"`
+	ActorID            *uuid.UUID   `json:"actor_id,omitempty" db:"actor_id"` // System/Admin who performed action
+	ActionType         ActionType   `json:"action_type" db:"action_type"`
+	ResourceType       ResourceType `json:"resource_type" db:"resource_type"`
+	ResourceID         string       `json:"resource_id" db:"resource_id"`
+	ServiceSource      string       `json:"service_source" db:"service_source"`
+	Timestamp          time.Time    `json:"timestamp" db:"timestamp"`
+	Result             AuditResult  `json:"result" db:"result"`
+	FailureReason      *string      `json:"failure_reason,omitempty" db:"failure_reason"`
+	IPAddress          string       `json:"ip_address" db:"ip_address"`
+	Geolocation        *string      `json:"geolocation,omitempty" db:"geolocation"`
+	UserAgent          *string      `json:"user_agent,omitempty" db:"user_agent"`
+	RequestID          string       `json:"request_id" db:"request_id"`
+	SessionID          *string      `json:"session_id,omitempty" db:"session_id"`
+	DigitalSignature   string       `json:"digital_signature" db:"digital_signature"`     // Signature for non-repudiation
+	SignatureVersion   int          `json:"signature_version" db:"signature_version"`     // Serialization covered by DigitalSignature
+	SignatureAlgorithm string       `json:"signature_algorithm" db:"signature_algorithm"` // HMAC-SHA256 or Ed25519
+	SigningKeyID       string       `json:"signing_key_id" db:"signing_key_id"`           // Key that produced DigitalSignature
+	Metadata           []byte       `json:"metadata,omitempty" db:"metadata"`             // JSON blob for additional context
+	DataBefore         []byte       `json:"-" db:"data_before"`                           // Encrypted state before change
+	DataAfter          []byte       `json:"-" db:"data_after"`                            // Encrypted state after change
+	ComplianceFlags    []string     `json:"compliance_flags,omitempty" db:"compliance_flags"`
+	RetentionCategory  string       `json:"retention_category" db:"retention_category"`
+	EncryptionKeyID    int          `json:"-" db:"encryption_key_id"`
+	CreatedAt          time.Time    `json:"created_at" db:"created_at"`
+	SequenceNum        int64        `json:"sequence_num" db:"sequence_num"` // Position in the hash chain
+	PrevHash           string       `json:"prev_hash" db:"prev_hash"`       // RecordHash of the previous event
+	RecordHash         string       `json:"record_hash" db:"record_hash"`   // SHA-256 over PrevHash and CanonicalRecord
+
+	// TimestampReceived is set when Timestamp is the time the event was received rather
+	// than a time its producer stated; a redelivery is then compared without it
+	TimestampReceived bool `json:"-" db:"-"`
 }
 
 // NewAuditEvent creates a new audit event with auto-generated ID and timestamp
@@ -100,30 +147,77 @@ func NewAuditEvent(userID uuid.UUID, action ActionType, resource ResourceType, r
 	}
 }
 
+// ErrInvalidEvent is returned when a submitted event is missing or has malformed fields
+var ErrInvalidEvent = errors.New("invalid audit event")
+
+// Validate checks the fields a producer must supply when submitting an event directly
+// rather than through a mapped Kafka topic
+func (e *AuditEvent) Validate() error {
+	switch {
+	case e.UserID == uuid.Nil:
+		return fmt.Errorf("%w: user_id is required", ErrInvalidEvent)
+	case !e.ActionType.IsValid():
+		return fmt.Errorf("%w: unknown action_type %q", ErrInvalidEvent, e.ActionType)
+	case !e.ResourceType.IsValid():
+		return fmt.Errorf("%w: unknown resource_type %q", ErrInvalidEvent, e.ResourceType)
+	case e.ResourceID == "":
+		return fmt.Errorf("%w: resource_id is required", ErrInvalidEvent)
+	case !e.Result.IsValid():
+		return fmt.Errorf("%w: unknown result %q", ErrInvalidEvent, e.Result)
+	}
+	if len(e.Metadata) > 0 {
+		var object map[string]json.RawMessage
+		if err := json.Unmarshal(e.Metadata, &object); err != nil {
+			return fmt.Errorf("%w: metadata must be a JSON object", ErrInvalidEvent)
+		}
+	}
+	return nil
+}
+
+// EventAttestation bundles an event with the exact bytes its signature covers, so external
+// auditors can verify it offline against the published public key set
+type EventAttestation struct {
+	Event              *AuditEvent `json:"event"`
+	SignatureVersion   int         `json:"signature_version"`
+	SignatureAlgorithm string      `json:"signature_algorithm"`
+	SigningKeyID       string      `json:"signing_key_id"`
+	Signature          string      `json:"signature"`
+	SignedPayload      []byte      `json:"signed_payload"` // base64 in JSON
+}
+
 // AuditEventFilter for querying audit logs
 type AuditEventFilter struct {
-	EventID       *uuid.UUID
-	UserID        *uuid.UUID
-	TransactionID *uuid.UUID
-	ActionTypes   []ActionType
-	ResourceTypes []ResourceType
-	ResourceID    *string
-	StartTime     *time.Time
-	EndTime       *time.Time
-	Result        *AuditResult
-	ServiceSource *string
-	IPAddress     *string
-	Limit         int
-	Offset        int
+	EventID           *uuid.UUID             `json:"event_id,omitempty"`
+	UserID            *uuid.UUID             `json:"user_id,omitempty"`
+	ActorID           *uuid.UUID             `json:"actor_id,omitempty"`
+	TransactionID     *uuid.UUID             `json:"transaction_id,omitempty"`
+	ActionTypes       []ActionType           `json:"action_types,omitempty"`   // Matches any
+	ResourceTypes     []ResourceType         `json:"resource_types,omitempty"` // Matches any
+	ResourceID        *string                `json:"resource_id,omitempty"`
+	StartTime         *time.Time             `json:"start_time,omitempty"`
+	EndTime           *time.Time             `json:"end_time,omitempty"`
+	Result            *AuditResult           `json:"result,omitempty"`
+	ServiceSource     *string                `json:"service_source,omitempty"`
+	IPAddress         *string                `json:"ip_address,omitempty"`
+	ComplianceFlags   []string               `json:"compliance_flags,omitempty"` // Event must carry all of them
+	RetentionCategory *string                `json:"retention_category,omitempty"`
+	MetadataContains  map[string]interface{} `json:"metadata_contains,omitempty"` // JSONB containment, e.g. {"channel": "mobile"}
+	MetadataPath      *string                `json:"metadata_path,omitempty"`     // JSONPath predicate, e.g. $.amount_cents > 1000000
+	Limit             int                    `json:"limit"`
+	Offset            int                    `json:"offset,omitempty"` // Deprecated: deep offsets scan every skipped row; use Cursor
+	Cursor            *EventCursor           `json:"cursor,omitempty"` // Resume after this position; takes precedence over Offset
+	Count             CountMode              `json:"count,omitempty"`
 }
 
 // AuditEventPage represents paginated audit events
 type AuditEventPage struct {
-	Events     []*AuditEvent `json:"events"`
-	TotalCount int64         `json:"total_count"`
-	Page       int           `json:"page"`
-	PageSize   int           `json:"page_size"`
-	HasMore    bool          `json:"has_more"`
+	Events         []*AuditEvent `json:"events"`
+	TotalCount     *int64        `json:"total_count,omitempty"` // Only when a count was requested
+	CountEstimated bool          `json:"count_estimated,omitempty"`
+	Page           int           `json:"page,omitempty"` // Offset pagination only
+	PageSize       int           `json:"page_size"`
+	HasMore        bool          `json:"has_more"`
+	NextCursor     string        `json:"next_cursor,omitempty"`
 }
 
 // RetentionPolicy defines data retention rules
@@ -182,15 +276,37 @@ var StandardRetentionPolicies = map[string]RetentionPolicy{
 	},
 }
 
+// Access types recorded in AuditAccessLog
+const (
+	AccessTypeView   = "VIEW"
+	AccessTypeSearch = "SEARCH"
+	AccessTypeExport = "EXPORT"
+	AccessTypeVerify = "VERIFY"
+)
+
 // AuditAccessLog tracks who accessed audit logs (audit of audits)
 type AuditAccessLog struct {
-	AccessID      uuid.UUID `json:"access_id" db:"access_id"`
-	AccessorID    uuid.UUID `json:"accessor_id" db:"accessor_id"`
-	AccessorRole  string    `json:"accessor_role" db:"accessor_role"`
-	AccessType    string    `json:"access_type" db:"access_type"` // VIEW, EXPORT, SEARCH
-	QueryFilter   string    `json:"query_filter" db:"query_filter"`
-	RecordsViewed int       `json:"records_viewed" db:"records_viewed"`
-	IPAddress     string    `json:"ip_address" db:"ip_address"`
-	Timestamp     time.Time `json:"timestamp" db:"timestamp"`
-	Purpose       string    `json:"purpose" db:"purpose"`
+	AccessID         uuid.UUID `json:"access_id" db:"access_id"`
+	AccessorID       uuid.UUID `json:"accessor_id" db:"accessor_id"`
+	AccessorSubject  string    `json:"accessor_subject" db:"accessor_subject"` // Token subject AccessorID was taken from
+	AccessorRole     string    `json:"accessor_role" db:"accessor_role"`
+	AccessType       string    `json:"access_type" db:"access_type"` // VIEW, EXPORT, SEARCH, VERIFY
+	QueryFilter      string    `json:"query_filter" db:"query_filter"`
+	RecordsViewed    int       `json:"records_viewed" db:"records_viewed"`
+	IPAddress        string    `json:"ip_address" db:"ip_address"`
+	Timestamp        time.Time `json:"timestamp" db:"timestamp"`
+	Purpose          string    `json:"purpose" db:"purpose"` // CODE:REFERENCE as given by the caller
+	PurposeCode      string    `json:"purpose_code,omitempty" db:"purpose_code"`
+	PurposeReference string    `json:"purpose_reference,omitempty" db:"purpose_reference"`
+}
+
+// AccessLogFilter for searching the audit-of-audits trail
+type AccessLogFilter struct {
+	AccessorID       *uuid.UUID `json:"accessor_id,omitempty"`
+	AccessType       *string    `json:"access_type,omitempty"`
+	PurposeCode      *string    `json:"purpose_code,omitempty"`
+	PurposeReference *string    `json:"purpose_reference,omitempty"`
+	StartTime        *time.Time `json:"start_time,omitempty"`
+	EndTime          *time.Time `json:"end_time,omitempty"`
+	Limit            int        `json:"limit"`
 }
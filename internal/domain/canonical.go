@@ -0,0 +1,254 @@
+package domain
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LedgerGenesisHash is the prev_hash of the first record in the audit chain
+const LedgerGenesisHash = "0000000000000000000000000000000000000000000000000000000000000000"
+
+// Signature versions describe which serialization of the event a DigitalSignature covers.
+// Old rows keep their version so they still verify after the format changes.
+const (
+	// SignatureVersionLegacy covers event_id, user_id, action_type, timestamp and result only
+	SignatureVersionLegacy = 1
+	// SignatureVersionCanonical covers every content field via CanonicalContent, with
+	// metadata numbers encoded as their exact decimal value, and the signature algorithm
+	// and key ID, so a row cannot be re-signed under a weaker algorithm
+	SignatureVersionCanonical = 2
+
+	CurrentSignatureVersion = SignatureVersionCanonical
+)
+
+// SignaturePayload returns the bytes covered by the digital signature for a signature version.
+// The boolean is false for unknown versions.
+func (e *AuditEvent) SignaturePayload(version int) ([]byte, bool) {
+	switch version {
+	case SignatureVersionLegacy:
+		return []byte(fmt.Sprintf("%s|%s|%s|%s|%s",
+			e.EventID, e.UserID, e.ActionType, e.Timestamp.UTC().Format(time.RFC3339), e.Result)), true
+	case SignatureVersionCanonical:
+		w := &canonicalWriter{}
+		w.string("signature_version", strconv.Itoa(version))
+		w.string("signature_algorithm", e.SignatureAlgorithm)
+		w.string("signing_key_id", e.SigningKeyID)
+		w.bytes("content", e.CanonicalContent())
+		return w.buf.Bytes(), true
+	default:
+		return nil, false
+	}
+}
+
+// CanonicalContent returns a deterministic encoding of every content field of the event.
+// Chain position and signature are excluded so the result can be signed before the
+// event is appended to the ledger.
+func (e *AuditEvent) CanonicalContent() []byte {
+	w := &canonicalWriter{}
+	e.writeSubmitted(w)
+	w.string("encryption_key_id", strconv.Itoa(e.EncryptionKeyID))
+	w.time("created_at", e.CreatedAt)
+	return w.buf.Bytes()
+}
+
+// SubmittedContent returns a deterministic encoding of the fields a producer supplies,
+// leaving out what the ledger assigns on receipt. Two deliveries of the same event have
+// equal submitted content even when they were received at different times.
+func (e *AuditEvent) SubmittedContent() []byte {
+	w := &canonicalWriter{}
+	e.writeSubmitted(w)
+	return w.buf.Bytes()
+}
+
+// IsRedeliveryOf reports whether e, a new delivery, has the submitted content of the
+// recorded event. A Timestamp taken from the time of receipt is not part of it.
+func (e *AuditEvent) IsRedeliveryOf(recorded *AuditEvent) bool {
+	delivered := *e
+	if e.TimestampReceived {
+		delivered.Timestamp = recorded.Timestamp
+	}
+	return bytes.Equal(recorded.SubmittedContent(), delivered.SubmittedContent())
+}
+
+// writeSubmitted writes the submitted fields in CanonicalContent order
+func (e *AuditEvent) writeSubmitted(w *canonicalWriter) {
+	w.string("event_id", e.EventID.String())
+	w.uuidPtr("transaction_id", e.TransactionID)
+	w.string("user_id", e.UserID.String())
+	w.uuidPtr("actor_id", e.ActorID)
+	w.string("action_type", string(e.ActionType))
+	w.string("resource_type", string(e.ResourceType))
+	w.string("resource_id", e.ResourceID)
+	w.string("service_source", e.ServiceSource)
+	w.time("timestamp", e.Timestamp)
+	w.string("result", string(e.Result))
+	w.stringPtr("failure_reason", e.FailureReason)
+	w.string("ip_address", e.IPAddress)
+	w.stringPtr("geolocation", e.Geolocation)
+	w.stringPtr("user_agent", e.UserAgent)
+	w.string("request_id", e.RequestID)
+	w.stringPtr("session_id", e.SessionID)
+	w.bytes("metadata", canonicalJSON(e.Metadata))
+	w.bytes("data_before", e.DataBefore)
+	w.bytes("data_after", e.DataAfter)
+	w.strings("compliance_flags", e.ComplianceFlags)
+	w.string("retention_category", e.RetentionCategory)
+}
+
+// CanonicalRecord returns the canonical content extended with the digital signature and
+// the chain position. It is the input to the record hash that links the ledger together,
+// and also covers how the row was signed, legacy rows included.
+func (e *AuditEvent) CanonicalRecord() []byte {
+	w := &canonicalWriter{}
+	w.bytes("content", e.CanonicalContent())
+	w.string("signature_version", strconv.Itoa(e.SignatureVersion))
+	w.string("signature_algorithm", e.SignatureAlgorithm)
+	w.string("signing_key_id", e.SigningKeyID)
+	w.string("digital_signature", e.DigitalSignature)
+	w.string("sequence_num", strconv.FormatInt(e.SequenceNum, 10))
+	w.string("prev_hash", e.PrevHash)
+	return w.buf.Bytes()
+}
+
+// canonicalWriter writes length-prefixed name/value pairs so that no two distinct
+// events can produce the same byte sequence.
+type canonicalWriter struct {
+	buf bytes.Buffer
+}
+
+func (w *canonicalWriter) raw(b []byte) {
+	var length [4]byte
+	binary.BigEndian.PutUint32(length[:], uint32(len(b)))
+	w.buf.Write(length[:])
+	w.buf.Write(b)
+}
+
+func (w *canonicalWriter) null(name string) {
+	w.raw([]byte(name))
+	w.buf.WriteByte(0)
+}
+
+func (w *canonicalWriter) bytes(name string, value []byte) {
+	if value == nil {
+		w.null(name)
+		return
+	}
+	w.raw([]byte(name))
+	w.buf.WriteByte(1)
+	w.raw(value)
+}
+
+func (w *canonicalWriter) string(name, value string) {
+	w.bytes(name, []byte(value))
+}
+
+func (w *canonicalWriter) stringPtr(name string, value *string) {
+	if value == nil {
+		w.null(name)
+		return
+	}
+	w.string(name, *value)
+}
+
+func (w *canonicalWriter) uuidPtr(name string, value *uuid.UUID) {
+	if value == nil {
+		w.null(name)
+		return
+	}
+	w.string(name, value.String())
+}
+
+// time encodes at microsecond precision in UTC, which is what PostgreSQL persists
+func (w *canonicalWriter) time(name string, value time.Time) {
+	w.string(name, value.UTC().Truncate(time.Microsecond).Format(time.RFC3339Nano))
+}
+
+func (w *canonicalWriter) strings(name string, values []string) {
+	// NULL and empty arrays are treated alike; pgx does not preserve the difference reliably
+	w.raw([]byte(name))
+	w.buf.WriteByte(1)
+	w.string("count", strconv.Itoa(len(values)))
+	for _, v := range values {
+		w.raw([]byte(v))
+	}
+}
+
+// canonicalJSON normalizes a JSON document so that the JSONB round trip through
+// PostgreSQL (key reordering, whitespace, number formatting) does not change the
+// encoding. Numbers are kept exact.
+func canonicalJSON(data []byte) []byte {
+	if len(data) == 0 {
+		return nil
+	}
+	var v interface{}
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	if err := d.Decode(&v); err != nil {
+		return data
+	}
+	normalized, err := json.Marshal(canonicalNumbers(v))
+	if err != nil {
+		return data
+	}
+	return normalized
+}
+
+// maxExactExponent bounds the exponent canonicalNumber expands exactly, so a hostile
+// payload cannot force a huge expansion. It is the most digits PostgreSQL's numeric
+// holds before the decimal point; JSONB refuses anything larger.
+const maxExactExponent = 131072
+
+// canonicalNumbers replaces every json.Number in v with its canonical form
+func canonicalNumbers(v interface{}) interface{} {
+	switch t := v.(type) {
+	case json.Number:
+		return canonicalNumber(t)
+	case map[string]interface{}:
+		for k, item := range t {
+			t[k] = canonicalNumbers(item)
+		}
+	case []interface{}:
+		for i, item := range t {
+			t[i] = canonicalNumbers(item)
+		}
+	}
+	return v
+}
+
+// canonicalNumber formats a number as its exact value in plain decimal notation, with
+// no exponent and no trailing fractional zeros, whatever its notation ("1e2", "100.0"
+// and "100" alike; "2.50e-1" and "0.25" alike). JSONB keeps a number's value but not its
+// notation. A number beyond what JSONB stores is kept as written.
+func canonicalNumber(n json.Number) json.Number {
+	s := string(n)
+	exp := 0
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		var err error
+		if exp, err = strconv.Atoi(s[i+1:]); err != nil || exp > maxExactExponent || exp < -maxExactExponent {
+			return n
+		}
+		s = s[:i]
+	}
+	r, ok := new(big.Rat).SetString(string(n))
+	if !ok {
+		return n
+	}
+	if r.IsInt() {
+		return json.Number(r.Num().String())
+	}
+
+	// A JSON number is a terminating decimal, so scale fractional digits are exact
+	scale := -exp
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		scale += len(s) - i - 1
+	}
+	return json.Number(strings.TrimRight(r.FloatString(scale), "0"))
+}
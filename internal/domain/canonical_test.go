@@ -0,0 +1,100 @@
+package domain_test
+
+import (
+	"testing"
+
+	"github.com/banking/audit-compliance/internal/domain"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func metadataEvent(version int, metadata string) *domain.AuditEvent {
+	e := domain.NewAuditEvent(uuid.MustParse("7f1c2e4a-9b3d-4c5e-8a6f-1b2c3d4e5f60"), domain.ActionTypeTransfer, domain.ResourceTypeTransfer, "TRF-1")
+	e.EventID = uuid.MustParse("3a4b5c6d-7e8f-4a1b-8c2d-3e4f5a6b7c8d")
+	e.SignatureVersion = version
+	e.Metadata = []byte(metadata)
+	return e
+}
+
+func TestCanonicalContentMetadataNumbers(t *testing.T) {
+	tests := []struct {
+		name    string
+		version int
+		a, b    string
+		equal   bool
+	}{
+		{
+			name:    "integers above 2^53 are distinct",
+			version: domain.SignatureVersionCanonical,
+			a:       `{"account":9007199254740993}`,
+			b:       `{"account":9007199254740992}`,
+		},
+		{
+			name:    "JSONB reformatting is not a change",
+			version: domain.SignatureVersionCanonical,
+			a:       `{"amount": 1e2, "fee": 0.50, "ids": [12345678901234567890.0]}`,
+			b:       `{"ids":[12345678901234567890],"fee":0.5,"amount":100}`,
+			equal:   true,
+		},
+		{
+			name:    "fractions beyond float64 precision are distinct",
+			version: domain.SignatureVersionCanonical,
+			a:       `{"rate":0.1000000000000000001}`,
+			b:       `{"rate":0.1}`,
+		},
+		{
+			name:    "fraction notation is not a change",
+			version: domain.SignatureVersionCanonical,
+			a:       `{"rate": 2.50e-1, "fx": [-12345678901234567.8900]}`,
+			b:       `{"fx":[-123456789012345678.9E-1],"rate":0.25}`,
+			equal:   true,
+		},
+		{
+			name:    "fractions still differ",
+			version: domain.SignatureVersionCanonical,
+			a:       `{"rate":0.25}`,
+			b:       `{"rate":0.26}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := metadataEvent(tt.version, tt.a), metadataEvent(tt.version, tt.b)
+			b.CreatedAt = a.CreatedAt
+			b.Timestamp = a.Timestamp
+			if tt.equal {
+				assert.Equal(t, a.CanonicalContent(), b.CanonicalContent())
+			} else {
+				assert.NotEqual(t, a.CanonicalContent(), b.CanonicalContent())
+				assert.NotEqual(t, a.SubmittedContent(), b.SubmittedContent())
+			}
+		})
+	}
+}
+
+func TestSignatureBindsAlgorithmAndKey(t *testing.T) {
+	signed := metadataEvent(domain.SignatureVersionCanonical, `{}`)
+	signed.SignatureAlgorithm = "Ed25519"
+	signed.SigningKeyID = "k1"
+	payload, ok := signed.SignaturePayload(signed.SignatureVersion)
+	assert.True(t, ok)
+
+	for name, change := range map[string]func(e *domain.AuditEvent){
+		"algorithm": func(e *domain.AuditEvent) { e.SignatureAlgorithm = "HMAC-SHA256" },
+		"key ID":    func(e *domain.AuditEvent) { e.SigningKeyID = "hmac" },
+	} {
+		t.Run(name, func(t *testing.T) {
+			resigned := *signed
+			change(&resigned)
+			other, _ := resigned.SignaturePayload(resigned.SignatureVersion)
+			assert.NotEqual(t, payload, other)
+			assert.NotEqual(t, signed.CanonicalRecord(), resigned.CanonicalRecord())
+		})
+	}
+
+	legacy := metadataEvent(domain.SignatureVersionLegacy, `{}`)
+	legacy.SignatureAlgorithm = "HMAC-SHA256"
+	relabeled := *legacy
+	relabeled.SignatureAlgorithm = "Ed25519"
+	assert.NotEqual(t, legacy.CanonicalRecord(), relabeled.CanonicalRecord(), "The record hash of a legacy row covers how it was signed")
+}
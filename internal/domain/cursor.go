@@ -0,0 +1,73 @@
+package domain
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidCursor is returned when a pagination cursor cannot be decoded
+var ErrInvalidCursor = errors.New("invalid pagination cursor")
+
+const cursorVersion = 1
+
+// EventCursor is a keyset position in the (timestamp DESC, event_id DESC) ordering of
+// the ledger: the next page starts strictly after this event
+type EventCursor struct {
+	Timestamp time.Time `json:"timestamp"`
+	EventID   uuid.UUID `json:"event_id"`
+}
+
+// CursorAfter returns the cursor positioned just after event
+func CursorAfter(event *AuditEvent) EventCursor {
+	return EventCursor{Timestamp: event.Timestamp, EventID: event.EventID}
+}
+
+// Encode returns the opaque token handed to clients. Timestamps are kept at the
+// microsecond precision Postgres stores.
+func (c EventCursor) Encode() string {
+	buf := make([]byte, 1+8+16)
+	buf[0] = cursorVersion
+	binary.BigEndian.PutUint64(buf[1:9], uint64(c.Timestamp.UnixMicro()))
+	copy(buf[9:], c.EventID[:])
+	return base64.RawURLEncoding.EncodeToString(buf)
+}
+
+// DecodeEventCursor parses a token produced by EventCursor.Encode
+func DecodeEventCursor(token string) (*EventCursor, error) {
+	buf, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(buf) != 1+8+16 || buf[0] != cursorVersion {
+		return nil, ErrInvalidCursor
+	}
+	var id uuid.UUID
+	copy(id[:], buf[9:])
+	return &EventCursor{
+		Timestamp: time.UnixMicro(int64(binary.BigEndian.Uint64(buf[1:9]))).UTC(),
+		EventID:   id,
+	}, nil
+}
+
+// CountMode selects how a page reports the total number of matching events
+type CountMode string
+
+const (
+	CountNone      CountMode = ""          // No total; cheapest, the default
+	CountExact     CountMode = "exact"     // COUNT(*) over the filter; costly on large ranges
+	CountEstimated CountMode = "estimated" // Planner row estimate
+)
+
+// ParseCountMode parses the count query parameter; "none" and "" select CountNone
+func ParseCountMode(s string) (CountMode, bool) {
+	switch s {
+	case "", "none":
+		return CountNone, true
+	case string(CountExact):
+		return CountExact, true
+	case string(CountEstimated):
+		return CountEstimated, true
+	}
+	return CountNone, false
+}
@@ -0,0 +1,38 @@
+package domain_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/banking/audit-compliance/internal/domain"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventCursorRoundTrip(t *testing.T) {
+	cursor := domain.EventCursor{
+		Timestamp: time.Date(2024, 3, 31, 23, 59, 59, 123456000, time.UTC),
+		EventID:   uuid.New(),
+	}
+
+	decoded, err := domain.DecodeEventCursor(cursor.Encode())
+	require.NoError(t, err)
+	assert.Equal(t, cursor, *decoded)
+}
+
+func TestDecodeEventCursorRejectsGarbage(t *testing.T) {
+	valid := domain.EventCursor{Timestamp: time.Now(), EventID: uuid.New()}.Encode()
+
+	for name, token := range map[string]string{
+		"empty":      "",
+		"not base64": "!!!",
+		"truncated":  valid[:len(valid)-4],
+		"version":    "_" + valid[1:],
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := domain.DecodeEventCursor(token)
+			assert.ErrorIs(t, err, domain.ErrInvalidCursor)
+		})
+	}
+}
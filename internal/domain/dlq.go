@@ -0,0 +1,62 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DeadLetterClass names why a Kafka record could not be recorded in the ledger
+type DeadLetterClass string
+
+const (
+	// DeadLetterMalformed records are not valid JSON
+	DeadLetterMalformed DeadLetterClass = "MALFORMED"
+	// DeadLetterUnmapped records are valid JSON that no event mapper accepts
+	DeadLetterUnmapped DeadLetterClass = "UNMAPPED"
+	// DeadLetterQuarantined records fail their CloudEvents or data schema; Violations
+	// locates each failure
+	DeadLetterQuarantined DeadLetterClass = "QUARANTINED"
+	// DeadLetterConflict records reuse a recorded event ID with different content
+	DeadLetterConflict DeadLetterClass = "CONFLICT"
+	// DeadLetterPersistence records failed to persist after every retry
+	DeadLetterPersistence DeadLetterClass = "PERSISTENCE"
+)
+
+// DeadLetterHeader is a Kafka record header as received
+type DeadLetterHeader struct {
+	Key   string `json:"key"`
+	Value []byte `json:"value"` // base64 in JSON
+}
+
+// DeadLetterViolation is one way a quarantined record fails its schema
+type DeadLetterViolation struct {
+	Field   string `json:"field"` // JSON pointer into the record value; "" for the value itself
+	Message string `json:"message"`
+}
+
+// DeadLetter is a Kafka record the consumer could not record, kept byte for byte so it
+// can be inspected and replayed
+type DeadLetter struct {
+	DLQID            uuid.UUID             `json:"dlq_id" db:"dlq_id"`
+	SourceTopic      string                `json:"source_topic" db:"source_topic"`
+	SourcePartition  int32                 `json:"source_partition" db:"source_partition"`
+	SourceOffset     int64                 `json:"source_offset" db:"source_offset"`
+	MessageKey       []byte                `json:"message_key,omitempty" db:"message_key"`
+	Payload          []byte                `json:"payload" db:"payload"`
+	Headers          []DeadLetterHeader    `json:"headers" db:"headers"`
+	MessageTimestamp *time.Time            `json:"message_timestamp,omitempty" db:"message_timestamp"`
+	ErrorClass       DeadLetterClass       `json:"error_class" db:"error_class"`
+	ErrorMessage     string                `json:"error_message" db:"error_message"`
+	Violations       []DeadLetterViolation `json:"violations,omitempty" db:"violations"` // Set for QUARANTINED records
+	FailedAt         time.Time             `json:"failed_at" db:"failed_at"`
+	ReplayedAt       *time.Time            `json:"replayed_at,omitempty" db:"replayed_at"`
+	ReplayedEventID  *uuid.UUID            `json:"replayed_event_id,omitempty" db:"replayed_event_id"`
+}
+
+// DeadLetterFilter selects dead letters for inspection
+type DeadLetterFilter struct {
+	ErrorClass  *DeadLetterClass `json:"error_class,omitempty"`
+	PendingOnly bool             `json:"pending_only,omitempty"` // Not yet replayed
+	Limit       int              `json:"limit"`
+}
@@ -0,0 +1,93 @@
+package domain
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LedgerVerificationReport is the result of re-verifying a range of the audit hash chain
+type LedgerVerificationReport struct {
+	From                 time.Time           `json:"from"`
+	To                   time.Time           `json:"to"`
+	FirstSequence        int64               `json:"first_sequence"`
+	LastSequence         int64               `json:"last_sequence"`
+	ChainHead            int64               `json:"chain_head"` // Checked against the latest checkpoint's tree size
+	EventsChecked        int64               `json:"events_checked"`
+	Valid                bool                `json:"valid"`
+	FirstBrokenLink      *ChainBreak         `json:"first_broken_link,omitempty"`
+	MissingSequences     []SequenceGap       `json:"missing_sequences,omitempty"`
+	ArchivedSequences    int64               `json:"archived_sequences,omitempty"` // In detached partitions, still indexed
+	InvalidSignatures    []uuid.UUID         `json:"invalid_signatures,omitempty"`
+	InvalidRecordHashes  []uuid.UUID         `json:"invalid_record_hashes,omitempty"`
+	CheckpointsChecked   int                 `json:"checkpoints_checked"`
+	InvalidCheckpoints   []CheckpointFailure `json:"invalid_checkpoints,omitempty"`
+	UnverifiedTimestamps []int64             `json:"unverified_timestamps,omitempty"` // Checkpoint tree sizes whose token has no TSA roots to check against
+	StartedAt            time.Time           `json:"started_at"`
+	CompletedAt          time.Time           `json:"completed_at"`
+}
+
+// ChainBreak describes an event whose prev_hash does not match its predecessor's record_hash
+type ChainBreak struct {
+	SequenceNum      int64     `json:"sequence_num"`
+	EventID          uuid.UUID `json:"event_id"`
+	ExpectedPrevHash string    `json:"expected_prev_hash"`
+	ActualPrevHash   string    `json:"actual_prev_hash"`
+}
+
+// SequenceGap is an inclusive range of sequence numbers absent from the ledger
+type SequenceGap struct {
+	From int64 `json:"from"`
+	To   int64 `json:"to"`
+}
+
+// CheckpointFailure describes a checkpoint that failed re-verification
+type CheckpointFailure struct {
+	TreeSize int64  `json:"tree_size"`
+	Reason   string `json:"reason"`
+}
+
+// LedgerCheckpoint is a signed Merkle tree head over the first TreeSize events of the chain.
+// Regulators can pin RootHash and later demand consistency proofs against newer checkpoints.
+type LedgerCheckpoint struct {
+	CheckpointID       uuid.UUID `json:"checkpoint_id" db:"checkpoint_id"`
+	TreeSize           int64     `json:"tree_size" db:"tree_size"`
+	RootHash           string    `json:"root_hash" db:"root_hash"` // Hex-encoded
+	Signature          string    `json:"signature" db:"signature"`
+	SignatureAlgorithm string    `json:"signature_algorithm" db:"signature_algorithm"`
+	SigningKeyID       string    `json:"signing_key_id" db:"signing_key_id"`
+	CreatedAt          time.Time `json:"created_at" db:"created_at"`
+	// RFC 3161 token from a trusted timestamp authority over SHA-256(SignedPayload())
+	TimestampToken []byte     `json:"timestamp_token,omitempty" db:"tsa_token"`
+	TimestampedAt  *time.Time `json:"timestamped_at,omitempty" db:"tsa_time"`
+}
+
+// SignedPayload returns the bytes covered by the checkpoint signature
+func (c *LedgerCheckpoint) SignedPayload() []byte {
+	return []byte(fmt.Sprintf("audit-ledger-checkpoint/v1\n%d\n%s\n%s\n",
+		c.TreeSize, c.RootHash, c.CreatedAt.UTC().Truncate(time.Microsecond).Format(time.RFC3339Nano)))
+}
+
+// TimestampDigest returns the digest submitted to the timestamp authority
+func (c *LedgerCheckpoint) TimestampDigest() []byte {
+	sum := sha256.Sum256(c.SignedPayload())
+	return sum[:]
+}
+
+// InclusionProof proves that an event is the leaf at LeafIndex of a checkpointed tree
+type InclusionProof struct {
+	EventID    uuid.UUID         `json:"event_id"`
+	LeafIndex  int64             `json:"leaf_index"` // SequenceNum - 1
+	LeafHash   string            `json:"leaf_hash"`
+	AuditPath  []string          `json:"audit_path"`
+	Checkpoint *LedgerCheckpoint `json:"checkpoint"`
+}
+
+// ConsistencyProof proves that the First checkpoint's tree is a prefix of the Second's
+type ConsistencyProof struct {
+	First  *LedgerCheckpoint `json:"first"`
+	Second *LedgerCheckpoint `json:"second"`
+	Proof  []string          `json:"proof"`
+}
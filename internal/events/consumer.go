@@ -2,26 +2,57 @@ package events
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/IBM/sarama"
 	"github.com/banking/audit-compliance/internal/config"
 	"github.com/banking/audit-compliance/internal/domain"
+	"github.com/banking/audit-compliance/internal/schema"
 	"github.com/banking/audit-compliance/internal/service"
 	"github.com/google/uuid"
 	"go.uber.org/zap"
 )
 
+// eventKeyHeader is a record header a producer may set to a key unique to the event, so
+// that redeliveries and re-publications of it map to the same event ID. The Kafka message
+// key is not used: it names the partitioning entity, not the event.
+const eventKeyHeader = "audit-event-key"
+
+// eventIDNamespace derives event IDs for Kafka records that carry no event_id
+var eventIDNamespace = uuid.MustParse("b5e0f3a2-71c4-5a8d-9e26-3d4f8c1a0b97")
+
+const (
+	// writeTimeout bounds one attempt to record or dead-letter a record. Attempts run to
+	// completion even when the session ends, so a record in flight at a rebalance or
+	// shutdown is finished and marked rather than abandoned halfway.
+	writeTimeout = 5 * time.Second
+	// maxAttempts is how often a ledger write is tried before the record is dead-lettered
+	maxAttempts = 3
+)
+
+// eventStore records events at most once; *service.AuditService in production
+type eventStore interface {
+	StoreEventOnce(ctx context.Context, event *domain.AuditEvent) (bool, error)
+	StoreEventsOnce(ctx context.Context, events []*domain.AuditEvent) ([]service.StoreResult, error)
+}
+
 type AuditConsumer struct {
 	consumerGroup sarama.ConsumerGroup
 	auditService  *service.AuditService
+	dlq           *DeadLetterQueue
+	mappers       *MapperRegistry
 	topics        []string
+	batchSize     int
+	batchTimeout  time.Duration
 	logger        *zap.Logger
 }
 
-func NewAuditConsumer(cfg config.KafkaConfig, auditService *service.AuditService, logger *zap.Logger) (*AuditConsumer, error) {
+// NewAuditConsumer creates a consumer that maps records onto audit events with mappers and
+// records every event it cannot store in dlq
+func NewAuditConsumer(cfg config.KafkaConfig, auditService *service.AuditService, dlq *DeadLetterQueue, mappers *MapperRegistry, logger *zap.Logger) (*AuditConsumer, error) {
 	config := sarama.NewConfig()
 	config.Consumer.Group.Rebalance.Strategy = sarama.BalanceStrategyRoundRobin
 	config.Consumer.Offsets.Initial = sarama.OffsetOldest
@@ -37,108 +68,283 @@ func NewAuditConsumer(cfg config.KafkaConfig, auditService *service.AuditService
 	return &AuditConsumer{
 		consumerGroup: consumerGroup,
 		auditService:  auditService,
+		dlq:           dlq,
+		mappers:       mappers,
 		topics:        topics,
+		batchSize:     max(cfg.BatchSize, 1),
+		batchTimeout:  cfg.BatchTimeout,
 		logger:        logger,
 	}, nil
 }
 
+// Start consumes until ctx is canceled. It returns once every partition has finished its
+// record in flight and marked it, so offsets committed by Close cover exactly the records
+// that reached the ledger or the dead-letter queue.
 func (c *AuditConsumer) Start(ctx context.Context) error {
 	handler := &auditConsumerHandler{
-		auditService: c.auditService,
+		store:        c.auditService,
+		dlq:          c.dlq,
+		mappers:      c.mappers,
+		batchSize:    c.batchSize,
+		batchTimeout: c.batchTimeout,
+		retryDelay:   time.Second,
 		logger:       c.logger,
 	}
 
 	for {
-		if err := c.consumerGroup.Consume(ctx, c.topics, handler); err != nil {
-			if ctx.Err() != nil {
-				return nil // Context canceled
-			}
+		err := c.consumerGroup.Consume(ctx, c.topics, handler)
+		if ctx.Err() != nil {
+			return nil // Context canceled
+		}
+		if err != nil {
 			c.logger.Error("Error from consumer", zap.Error(err))
-			time.Sleep(time.Second * 5) // Retry backoff
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(5 * time.Second): // Retry backoff
+			}
 		}
 	}
 }
 
+// Close leaves the consumer group, committing marked offsets. Call it after Start returns.
 func (c *AuditConsumer) Close() error {
 	return c.consumerGroup.Close()
 }
 
 type auditConsumerHandler struct {
-	auditService *service.AuditService
+	store        eventStore
+	dlq          deadLetterSink
+	mappers      *MapperRegistry
+	batchSize    int           // Records per ledger transaction; 1 writes each on its own
+	batchTimeout time.Duration // Longest the first record of a batch waits for more
+	retryDelay   time.Duration // Backoff before the second attempt; grows linearly
 	logger       *zap.Logger
 }
 
 func (h *auditConsumerHandler) Setup(_ sarama.ConsumerGroupSession) error   { return nil }
 func (h *auditConsumerHandler) Cleanup(_ sarama.ConsumerGroupSession) error { return nil }
+
+// ConsumeClaim processes one partition's records strictly in order, in batches of up to
+// batchSize records or batchTimeout. A batch is marked only once every record in it is in
+// the ledger or the dead-letter queue; if any reaches neither, the claim ends without
+// marking the batch or anything after it, and the next session redelivers from there.
+// This makes delivery into the ledger at-least-once for every source topic.
 func (h *auditConsumerHandler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
-	for message := range claim.Messages() {
-		h.processMessage(session.Context(), message)
-		session.MarkMessage(message, "")
+	ctx := session.Context()
+	var batch []*sarama.ConsumerMessage
+	timer := time.NewTimer(h.batchTimeout)
+	timer.Stop()
+	defer timer.Stop()
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := h.processBatch(ctx, batch); err != nil {
+			return err
+		}
+		// Marking the last record commits the whole batch
+		session.MarkMessage(batch[len(batch)-1], "")
+		batch = batch[:0]
+		return nil
 	}
-	return nil
-}
 
-func (h *auditConsumerHandler) processMessage(ctx context.Context, msg *sarama.ConsumerMessage) {
-	// Generic event structure to peek at fields
-	var genericEvent map[string]interface{}
-	if err := json.Unmarshal(msg.Value, &genericEvent); err != nil {
-		h.logger.Error("Failed to unmarshal event", zap.Error(err))
-		return // Skip malformed
+	for {
+		// Stopping takes priority over records already buffered. Records already taken
+		// into the batch are in flight and are finished first.
+		if ctx.Err() != nil {
+			return flush()
+		}
+		select {
+		case message, ok := <-claim.Messages():
+			if !ok {
+				return flush()
+			}
+			batch = append(batch, message)
+			if len(batch) == 1 {
+				timer.Reset(h.batchTimeout)
+			}
+			if len(batch) >= h.batchSize {
+				timer.Stop()
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+		case <-timer.C:
+			if err := flush(); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			// Rebalance or shutdown: take no new records
+			return flush()
+		}
 	}
+}
 
-	// Transform to AuditDomain
-	auditEvent := h.mapToAuditEvent(genericEvent, msg.Topic)
+// processBatch records a batch of one partition's records in a single ledger
+// transaction. Malformed, invalid, unmappable and conflicting records are dead-lettered.
+// If the transaction fails, the records are retried one at a time so a single bad record
+// cannot hold back the rest. It returns an error when any record reached neither ledger
+// nor dead-letter queue.
+func (h *auditConsumerHandler) processBatch(ctx context.Context, msgs []*sarama.ConsumerMessage) error {
+	if len(msgs) == 1 {
+		return h.processMessage(ctx, msgs[0])
+	}
 
-	// Retry mechanism for persistence
-	maxRetries := 3
-	for i := 0; i < maxRetries; i++ {
-		if err := h.auditService.ProcessAndStoreEvent(ctx, auditEvent); err != nil {
-			h.logger.Error("Failed to process audit event",
-				zap.String("topic", msg.Topic),
-				zap.Error(err),
-				zap.Int("retry", i+1),
-			)
-			if i < maxRetries-1 {
-				time.Sleep(time.Duration(i+1) * time.Second) // Simple backoff
-				continue
+	events := make([]*domain.AuditEvent, 0, len(msgs))
+	sources := make([]*sarama.ConsumerMessage, 0, len(msgs))
+	for _, msg := range msgs {
+		event, err := h.mappers.Decode(msg)
+		if err != nil {
+			if err := h.rejectUndecodable(ctx, msg, err); err != nil {
+				return err
 			}
-			// If we exhausted retries, log failure and potentially move to DLQ (future)
-			h.logger.Error("Dropping event after retries", zap.String("event_id", auditEvent.EventID.String()))
+			continue
 		}
-		break // Success
+		events = append(events, event)
+		sources = append(sources, msg)
+	}
+	if len(events) == 0 {
+		return nil
 	}
-}
 
-// mapToAuditEvent transforms various event formats into a standardized AuditEvent
-func (h *auditConsumerHandler) mapToAuditEvent(raw map[string]interface{}, topic string) *domain.AuditEvent {
-	// Defaults
-	event := domain.NewAuditEvent(uuid.Nil, domain.ActionType("UNKNOWN"), domain.ResourceType("UNKNOWN"), "0")
-	event.ServiceSource = topic // Proxy for service name for now
+	writeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), writeTimeout)
+	results, err := h.store.StoreEventsOnce(writeCtx, events)
+	cancel()
+	if err != nil {
+		h.logger.Warn("Batch ledger write failed; recording records one at a time",
+			zap.String("topic", msgs[0].Topic),
+			zap.Int32("partition", msgs[0].Partition),
+			zap.Int("records", len(sources)),
+			zap.Error(err),
+		)
+		for _, msg := range sources {
+			if err := h.processMessage(ctx, msg); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
 
-	// Extract standard fields if they exist
-	if idStr, ok := raw["event_id"].(string); ok {
-		if uid, err := uuid.Parse(idStr); err == nil {
-			event.EventID = uid
+	for i, result := range results {
+		if result.Err != nil {
+			if err := h.deadLetter(ctx, sources[i], domain.DeadLetterConflict, result.Err); err != nil {
+				return err
+			}
 		}
 	}
+	return nil
+}
 
-	if typeStr, ok := raw["event_type"].(string); ok {
-		// Map detailed event type to generic ActionType if possible, or just store it
-		// For now, we use the raw string or map common ones
-		event.ActionType = domain.ActionType(typeStr) // Dynamic casting
+// processMessage records one Kafka record in the ledger, dead-lettering it when it is
+// malformed, fails its schema or is unmappable, conflicts with a recorded event or cannot be stored. It returns an error
+// when the record reached neither, including when ctx ends while waiting to retry.
+func (h *auditConsumerHandler) processMessage(ctx context.Context, msg *sarama.ConsumerMessage) error {
+	// Transform to AuditDomain
+	auditEvent, err := h.mappers.Decode(msg)
+	if err != nil {
+		return h.rejectUndecodable(ctx, msg, err)
 	}
 
-	if userIDStr, ok := raw["user_id"].(string); ok {
-		if uid, err := uuid.Parse(userIDStr); err == nil {
-			event.UserID = uid
+	// Retry mechanism for persistence. Kafka delivers at least once, so a redelivered
+	// event maps to the same ID and is recognized rather than appended twice.
+	for attempt := 1; ; attempt++ {
+		duplicate, err := h.storeEvent(ctx, auditEvent)
+		if err == nil {
+			if duplicate {
+				h.logger.Info("Skipping redelivered audit event",
+					zap.String("event_id", auditEvent.EventID.String()),
+					zap.String("topic", msg.Topic),
+					zap.Int64("offset", msg.Offset),
+				)
+			}
+			return nil // Success
+		}
+		if errors.Is(err, service.ErrConflictingEvent) {
+			// Already raised as a tamper alert; retrying cannot change the outcome
+			h.logger.Error("Rejecting conflicting audit event",
+				zap.String("event_id", auditEvent.EventID.String()),
+				zap.String("topic", msg.Topic),
+				zap.Int32("partition", msg.Partition),
+				zap.Int64("offset", msg.Offset),
+			)
+			return h.deadLetter(ctx, msg, domain.DeadLetterConflict, err)
+		}
+		h.logger.Error("Failed to process audit event",
+			zap.String("topic", msg.Topic),
+			zap.Error(err),
+			zap.Int("attempt", attempt),
+		)
+		if attempt == maxAttempts {
+			return h.deadLetter(ctx, msg, domain.DeadLetterPersistence, err)
+		}
+
+		// The session ending means another consumer now owns the partition, or we are
+		// shutting down: leave the record unmarked for redelivery rather than wait
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("stopped retrying %s/%d/%d: %w", msg.Topic, msg.Partition, msg.Offset, ctx.Err())
+		case <-time.After(time.Duration(attempt) * h.retryDelay): // Simple backoff
 		}
 	}
+}
 
-	// Payload handling
-	// Store the entire raw event as Metadata JSON
-	if metaBytes, err := json.Marshal(raw); err == nil {
-		event.Metadata = metaBytes
+// rejectUndecodable dead-letters a record that could not be decoded, validated or mapped
+func (h *auditConsumerHandler) rejectUndecodable(ctx context.Context, msg *sarama.ConsumerMessage, err error) error {
+	class := domain.DeadLetterMalformed
+	switch {
+	case errors.Is(err, schema.ErrInvalid):
+		class = domain.DeadLetterQuarantined
+	case errors.Is(err, ErrUnmappableEvent):
+		class = domain.DeadLetterUnmapped
 	}
+	h.logger.Error("Failed to map event",
+		zap.String("topic", msg.Topic),
+		zap.Int64("offset", msg.Offset),
+		zap.String("class", string(class)),
+		zap.Error(err),
+	)
+	return h.deadLetter(ctx, msg, class, err)
+}
+
+// storeEvent makes one ledger write attempt that is not cut short by the session ending
+func (h *auditConsumerHandler) storeEvent(ctx context.Context, event *domain.AuditEvent) (bool, error) {
+	writeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), writeTimeout)
+	defer cancel()
+	return h.store.StoreEventOnce(writeCtx, event)
+}
 
-	return event
+// deadLetter hands msg to the dead-letter queue
+func (h *auditConsumerHandler) deadLetter(ctx context.Context, msg *sarama.ConsumerMessage, class domain.DeadLetterClass, cause error) error {
+	writeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), writeTimeout)
+	defer cancel()
+	if err := h.dlq.Send(writeCtx, msg, class, cause); err != nil {
+		h.logger.Error("Failed to dead-letter audit event; leaving it for redelivery",
+			zap.String("topic", msg.Topic),
+			zap.Int32("partition", msg.Partition),
+			zap.Int64("offset", msg.Offset),
+			zap.Error(err),
+		)
+		return fmt.Errorf("failed to dead-letter %s/%d/%d: %w", msg.Topic, msg.Partition, msg.Offset, err)
+	}
+	return nil
+}
+
+// deriveEventID returns the event's own event_id when it carries a valid one. Otherwise
+// the ID is derived from the producer's event key header or, failing that, from the
+// record's topic, partition and offset, which stay fixed across redeliveries.
+func deriveEventID(raw map[string]interface{}, msg *sarama.ConsumerMessage) uuid.UUID {
+	if idStr, ok := raw["event_id"].(string); ok {
+		if uid, err := uuid.Parse(idStr); err == nil {
+			return uid
+		}
+	}
+	for _, h := range msg.Headers {
+		if h != nil && string(h.Key) == eventKeyHeader && len(h.Value) > 0 {
+			return uuid.NewSHA1(eventIDNamespace, []byte(msg.Topic+"\x00key\x00"+string(h.Value)))
+		}
+	}
+	position := msg.Topic + "\x00" + strconv.FormatInt(int64(msg.Partition), 10) + "\x00" + strconv.FormatInt(msg.Offset, 10)
+	return uuid.NewSHA1(eventIDNamespace, []byte(position))
 }
@@ -0,0 +1,433 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/banking/audit-compliance/internal/domain"
+	"github.com/banking/audit-compliance/internal/service"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// fakeStore keeps recorded events by ID, as the ledger's unique index does
+type fakeStore struct {
+	events  map[uuid.UUID]*domain.AuditEvent
+	calls   int
+	batches int
+	err     error  // Returned by every write when set
+	onStore func() // Called during each write
+}
+
+func (f *fakeStore) StoreEventOnce(ctx context.Context, event *domain.AuditEvent) (bool, error) {
+	f.calls++
+	if f.onStore != nil {
+		f.onStore()
+	}
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	if f.err != nil {
+		return false, f.err
+	}
+	if stored, ok := f.events[event.EventID]; ok {
+		if !event.IsRedeliveryOf(stored) {
+			return false, service.ErrConflictingEvent
+		}
+		return true, nil
+	}
+	f.events[event.EventID] = event
+	return false, nil
+}
+
+func (f *fakeStore) StoreEventsOnce(ctx context.Context, events []*domain.AuditEvent) ([]service.StoreResult, error) {
+	f.batches++
+	if f.onStore != nil {
+		f.onStore()
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if f.err != nil {
+		return nil, f.err
+	}
+	results := make([]service.StoreResult, len(events))
+	for i, event := range events {
+		if stored, ok := f.events[event.EventID]; ok {
+			results[i].Duplicate = true
+			if !event.IsRedeliveryOf(stored) {
+				results[i] = service.StoreResult{Err: service.ErrConflictingEvent}
+			}
+			continue
+		}
+		f.events[event.EventID] = event
+	}
+	return results, nil
+}
+
+// fakeSink collects dead letters, failing when err is set
+type fakeSink struct {
+	classes []domain.DeadLetterClass
+	err     error
+}
+
+func (f *fakeSink) Send(_ context.Context, _ *sarama.ConsumerMessage, class domain.DeadLetterClass, _ error) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.classes = append(f.classes, class)
+	return nil
+}
+
+func newTestHandler() (*auditConsumerHandler, *fakeStore, *fakeSink) {
+	store := &fakeStore{events: map[uuid.UUID]*domain.AuditEvent{}}
+	sink := &fakeSink{}
+	h := &auditConsumerHandler{store: store, dlq: sink, mappers: NewMapperRegistry(AuditMapper, nil), batchSize: 1, batchTimeout: time.Millisecond, retryDelay: time.Millisecond, logger: zap.NewNop()}
+	return h, store, sink
+}
+
+// auditPayload returns an audit topic record of action with extra fields appended
+func auditPayload(action, extra string) string {
+	return `{"event_type":"` + action + `","user_id":"` + testUserID + `","resource_type":"USER","resource_id":"` + testUserID + `"` + extra + `}`
+}
+
+func record(offset int64, value string, headers ...*sarama.RecordHeader) *sarama.ConsumerMessage {
+	return &sarama.ConsumerMessage{
+		Topic:     "banking.audit.events",
+		Partition: 2,
+		Offset:    offset,
+		Timestamp: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+		Value:     []byte(value),
+		Headers:   headers,
+	}
+}
+
+func TestRedelivery(t *testing.T) {
+	payload := auditPayload("LOGIN", "")
+	keyHeader := &sarama.RecordHeader{Key: []byte(eventKeyHeader), Value: []byte("login-42")}
+	eventID := uuid.New()
+
+	tests := []struct {
+		name       string
+		deliveries []*sarama.ConsumerMessage
+		stored     int
+	}{
+		{"same record redelivered", []*sarama.ConsumerMessage{record(7, payload), record(7, payload)}, 1},
+		{"distinct offsets", []*sarama.ConsumerMessage{record(7, payload), record(8, payload)}, 2},
+		{"event key republished at a new offset", []*sarama.ConsumerMessage{record(7, payload, keyHeader), record(9, payload, keyHeader)}, 1},
+		{"payload event_id republished at a new offset", []*sarama.ConsumerMessage{
+			record(7, auditPayload("LOGIN", `,"event_id":"`+eventID.String()+`"`)),
+			record(9, auditPayload("LOGIN", `,"event_id":"`+eventID.String()+`"`)),
+		}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, store, sink := newTestHandler()
+			for _, msg := range tt.deliveries {
+				require.NoError(t, h.processMessage(context.Background(), msg))
+			}
+			assert.Empty(t, sink.classes)
+			assert.Len(t, store.events, tt.stored)
+			assert.Equal(t, len(tt.deliveries), store.calls, "Duplicates must not be retried")
+		})
+	}
+}
+
+func TestRedeliveryWithConflictingContent(t *testing.T) {
+	h, store, sink := newTestHandler()
+	eventID := uuid.New()
+
+	require.NoError(t, h.processMessage(context.Background(), record(7, auditPayload("LOGIN", `,"event_id":"`+eventID.String()+`"`))))
+	original := store.events[eventID].SubmittedContent()
+	require.NoError(t, h.processMessage(context.Background(), record(9, auditPayload("LOGOUT", `,"event_id":"`+eventID.String()+`"`))))
+
+	assert.Equal(t, 2, store.calls, "A conflicting redelivery must not be retried")
+	assert.Equal(t, original, store.events[eventID].SubmittedContent(), "The recorded event must be kept")
+	assert.Equal(t, []domain.DeadLetterClass{domain.DeadLetterConflict}, sink.classes)
+}
+
+// republished returns msg as a producer re-publishing it later would send it
+func republished(msg *sarama.ConsumerMessage, offset int64) *sarama.ConsumerMessage {
+	again := *msg
+	again.Offset = offset
+	again.Timestamp = msg.Timestamp.Add(time.Hour)
+	return &again
+}
+
+func TestRepublicationWithNewRecordTimestamp(t *testing.T) {
+	keyHeader := &sarama.RecordHeader{Key: []byte(eventKeyHeader), Value: []byte("login-42")}
+	eventID := uuid.New().String()
+
+	tests := []struct {
+		name     string
+		first    *sarama.ConsumerMessage
+		again    *sarama.ConsumerMessage
+		conflict bool
+	}{
+		{
+			name:  "event key without a stated time",
+			first: record(7, auditPayload("LOGIN", ""), keyHeader),
+		},
+		{
+			name:  "payload event_id with a stated time",
+			first: record(7, auditPayload("LOGIN", `,"event_id":"`+eventID+`","timestamp":"2024-02-29T23:59:58.5Z"`)),
+		},
+		{
+			name:     "stated times differ",
+			first:    record(7, auditPayload("LOGIN", `,"event_id":"`+eventID+`","timestamp":"2024-02-29T23:59:58.5Z"`)),
+			again:    record(9, auditPayload("LOGIN", `,"event_id":"`+eventID+`","timestamp":"2024-02-29T23:59:59Z"`)),
+			conflict: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, store, sink := newTestHandler()
+			again := tt.again
+			if again == nil {
+				again = republished(tt.first, 9)
+			}
+			require.NoError(t, h.processMessage(context.Background(), tt.first))
+			require.NoError(t, h.processMessage(context.Background(), again))
+			assert.Len(t, store.events, 1)
+			if tt.conflict {
+				assert.Equal(t, []domain.DeadLetterClass{domain.DeadLetterConflict}, sink.classes)
+			} else {
+				assert.Empty(t, sink.classes, "A re-publication is not a conflict")
+			}
+		})
+	}
+
+	// The producer's stated time is recorded, not when the record reached Kafka
+	h, store, _ := newTestHandler()
+	require.NoError(t, h.processMessage(context.Background(),
+		record(7, auditPayload("LOGIN", `,"event_id":"`+eventID+`","timestamp":"2024-02-29T23:59:58.5Z"`))))
+	recorded := store.events[uuid.MustParse(eventID)]
+	assert.Equal(t, time.Date(2024, 2, 29, 23, 59, 58, 5e8, time.UTC), recorded.Timestamp)
+	assert.False(t, recorded.TimestampReceived)
+}
+
+func TestMalformedRecordIsDeadLettered(t *testing.T) {
+	h, store, sink := newTestHandler()
+
+	require.NoError(t, h.processMessage(context.Background(), record(7, `{"event_type":`)))
+	assert.Zero(t, store.calls)
+	assert.Equal(t, []domain.DeadLetterClass{domain.DeadLetterMalformed}, sink.classes)
+
+	// Valid JSON that no mapper accepts is kept apart so it can be replayed once one does
+	h.mappers = newTestMappers(t)
+	require.NoError(t, h.processMessage(context.Background(), topicRecord(testTopics.UserTopic, `{"event_type":"USER_TELEPORTED","user_id":"`+testUserID+`"}`)))
+	assert.Equal(t, []domain.DeadLetterClass{domain.DeadLetterMalformed, domain.DeadLetterUnmapped}, sink.classes)
+
+	// Data that fails its schema is quarantined, never recorded
+	require.NoError(t, h.processMessage(context.Background(), topicRecord(testTopics.UserTopic, `{"event_type":"USER_CREATED","user_id":"42"}`)))
+	assert.Equal(t, domain.DeadLetterQuarantined, sink.classes[2])
+	assert.Zero(t, store.calls)
+
+	// A record that cannot be dead-lettered either is reported so it is not marked consumed
+	sink.err = errors.New("dlq unavailable")
+	assert.Error(t, h.processMessage(context.Background(), record(8, `not json`)))
+}
+
+func TestDeriveEventID(t *testing.T) {
+	raw := map[string]interface{}{}
+
+	first := deriveEventID(raw, record(7, ""))
+	assert.Equal(t, first, deriveEventID(raw, record(7, "")))
+	assert.NotEqual(t, first, deriveEventID(raw, record(8, "")))
+
+	other := record(7, "")
+	other.Topic = "banking.user.events"
+	assert.NotEqual(t, first, deriveEventID(raw, other), "Offsets of different topics must not collide")
+
+	eventID := uuid.New()
+	require.Equal(t, eventID, deriveEventID(map[string]interface{}{"event_id": eventID.String()}, record(7, "")))
+	assert.Equal(t, first, deriveEventID(map[string]interface{}{"event_id": "not-a-uuid"}, record(7, "")))
+}
+
+// fakeSession records marked offsets
+type fakeSession struct {
+	sarama.ConsumerGroupSession
+	ctx    context.Context
+	marked []int64
+}
+
+func (s *fakeSession) Context() context.Context { return s.ctx }
+
+func (s *fakeSession) MarkMessage(msg *sarama.ConsumerMessage, _ string) {
+	s.marked = append(s.marked, msg.Offset)
+}
+
+// fakeClaim delivers a fixed set of records
+type fakeClaim struct {
+	sarama.ConsumerGroupClaim
+	messages chan *sarama.ConsumerMessage
+}
+
+func (c *fakeClaim) Messages() <-chan *sarama.ConsumerMessage { return c.messages }
+
+func newClaim(msgs ...*sarama.ConsumerMessage) *fakeClaim {
+	c := &fakeClaim{messages: make(chan *sarama.ConsumerMessage, len(msgs))}
+	for _, m := range msgs {
+		c.messages <- m
+	}
+	close(c.messages)
+	return c
+}
+
+var loginPayload = auditPayload("LOGIN", "")
+
+func TestConsumeClaimMarksOnlyDurableRecords(t *testing.T) {
+	tests := []struct {
+		name     string
+		storeErr error
+		dlqErr   error
+		marked   []int64
+		fails    bool
+	}{
+		{"recorded in the ledger", nil, nil, []int64{1, 2, 3}, false},
+		{"dead-lettered after retries", errors.New("ledger unavailable"), nil, []int64{1, 2, 3}, false},
+		{"neither ledger nor dead-letter queue", errors.New("ledger unavailable"), errors.New("dlq unavailable"), nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, store, sink := newTestHandler()
+			store.err, sink.err = tt.storeErr, tt.dlqErr
+			session := &fakeSession{ctx: context.Background()}
+
+			err := h.ConsumeClaim(session, newClaim(record(1, loginPayload), record(2, loginPayload), record(3, loginPayload)))
+			assert.Equal(t, tt.marked, session.marked)
+			if tt.fails {
+				assert.Error(t, err)
+				assert.Equal(t, maxAttempts, store.calls, "Records after an unmarked one must not be processed")
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestRetryStopsWhenSessionEnds(t *testing.T) {
+	h, store, sink := newTestHandler()
+	h.retryDelay = time.Hour
+	store.err = errors.New("ledger unavailable")
+	ctx, cancel := context.WithCancel(context.Background())
+	store.onStore = cancel // The partition is revoked during the first attempt
+
+	session := &fakeSession{ctx: ctx}
+	err := h.ConsumeClaim(session, newClaim(record(1, loginPayload)))
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1, store.calls)
+	assert.Empty(t, sink.classes, "A revoked record is redelivered, not dead-lettered")
+	assert.Empty(t, session.marked)
+}
+
+func TestShutdownDrainsRecordInFlight(t *testing.T) {
+	h, store, _ := newTestHandler()
+	ctx, cancel := context.WithCancel(context.Background())
+	store.onStore = cancel // Shutdown begins while the first record is being written
+
+	session := &fakeSession{ctx: ctx}
+	claim := &fakeClaim{messages: make(chan *sarama.ConsumerMessage, 2)}
+	claim.messages <- record(1, loginPayload)
+	claim.messages <- record(2, loginPayload)
+
+	require.NoError(t, h.ConsumeClaim(session, claim))
+	assert.Equal(t, []int64{1}, session.marked, "The record in flight completes and is marked; no new one is taken")
+	assert.Len(t, store.events, 1)
+}
+
+func TestConsumeClaimBatches(t *testing.T) {
+	eventID := uuid.New()
+	recorded := auditPayload("LOGIN", `,"event_id":"`+eventID.String()+`"`)
+
+	tests := []struct {
+		name     string
+		records  []*sarama.ConsumerMessage
+		storeErr error
+		batches  int
+		calls    int // Records written one at a time
+		stored   int
+		dead     []domain.DeadLetterClass
+		marked   []int64
+	}{
+		{
+			name:    "full batches written in one call each",
+			records: []*sarama.ConsumerMessage{record(1, loginPayload), record(2, loginPayload), record(3, loginPayload), record(4, loginPayload)},
+			batches: 2, stored: 4, marked: []int64{2, 4},
+		},
+		{
+			name:    "partial batch flushed when the claim ends",
+			records: []*sarama.ConsumerMessage{record(1, loginPayload), record(2, loginPayload), record(3, loginPayload)},
+			batches: 1, calls: 1, stored: 3, marked: []int64{2, 3},
+		},
+		{
+			name:    "malformed record dead-lettered without failing the batch",
+			records: []*sarama.ConsumerMessage{record(1, `{"event_type":`), record(2, loginPayload)},
+			batches: 1, stored: 1, dead: []domain.DeadLetterClass{domain.DeadLetterMalformed}, marked: []int64{2},
+		},
+		{
+			name:    "conflicting record dead-lettered",
+			records: []*sarama.ConsumerMessage{record(1, recorded), record(2, auditPayload("LOGOUT", `,"event_id":"`+eventID.String()+`"`))},
+			batches: 1, stored: 1, dead: []domain.DeadLetterClass{domain.DeadLetterConflict}, marked: []int64{2},
+		},
+		{
+			name:     "failed batch retried one record at a time",
+			records:  []*sarama.ConsumerMessage{record(1, loginPayload), record(2, loginPayload)},
+			storeErr: errors.New("ledger unavailable"),
+			batches:  1, calls: 2 * maxAttempts,
+			dead:   []domain.DeadLetterClass{domain.DeadLetterPersistence, domain.DeadLetterPersistence},
+			marked: []int64{2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, store, sink := newTestHandler()
+			h.batchSize, h.batchTimeout = 2, time.Hour
+			store.err = tt.storeErr
+			session := &fakeSession{ctx: context.Background()}
+
+			require.NoError(t, h.ConsumeClaim(session, newClaim(tt.records...)))
+			assert.Equal(t, tt.batches, store.batches)
+			assert.Equal(t, tt.calls, store.calls)
+			assert.Len(t, store.events, tt.stored)
+			assert.Equal(t, tt.dead, sink.classes)
+			assert.Equal(t, tt.marked, session.marked, "Only the last record of each batch is marked")
+		})
+	}
+}
+
+func TestBatchTimeoutFlushes(t *testing.T) {
+	h, store, _ := newTestHandler()
+	h.batchSize, h.batchTimeout = 100, time.Millisecond
+	flushed := make(chan struct{}, 1)
+	store.onStore = func() { flushed <- struct{}{} }
+	session := &fakeSession{ctx: context.Background()}
+
+	// The claim stays open, so only the timeout can flush the partial batch
+	claim := &fakeClaim{messages: make(chan *sarama.ConsumerMessage, 2)}
+	claim.messages <- record(1, loginPayload)
+	claim.messages <- record(2, loginPayload)
+	done := make(chan error)
+	go func() { done <- h.ConsumeClaim(session, claim) }()
+
+	select {
+	case <-flushed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Partial batch was not flushed on timeout")
+	}
+	close(claim.messages)
+	require.NoError(t, <-done)
+	assert.Equal(t, 1, store.batches)
+	assert.Len(t, store.events, 2)
+	assert.Equal(t, []int64{2}, session.marked)
+}
@@ -0,0 +1,283 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/banking/audit-compliance/internal/config"
+	"github.com/banking/audit-compliance/internal/domain"
+	"github.com/banking/audit-compliance/internal/repository/postgres"
+	"github.com/banking/audit-compliance/internal/schema"
+	"github.com/banking/audit-compliance/internal/service"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// Headers added to records published to the dead-letter topic, after the originals
+const (
+	dlqHeaderErrorClass      = "dlq-error-class"
+	dlqHeaderError           = "dlq-error"
+	dlqHeaderSourceTopic     = "dlq-source-topic"
+	dlqHeaderSourcePartition = "dlq-source-partition"
+	dlqHeaderSourceOffset    = "dlq-source-offset"
+)
+
+// dlqIDNamespace derives dead-letter IDs from the record's position
+var dlqIDNamespace = uuid.MustParse("cba56485-c819-453f-9b63-660175bf79b8")
+
+// deadLetterStore keeps dead letters for inspection and replay; *postgres.DLQRepository
+// in production
+type deadLetterStore interface {
+	SaveDeadLetter(ctx context.Context, dl *domain.DeadLetter) error
+	GetDeadLetters(ctx context.Context, ids []uuid.UUID) ([]*domain.DeadLetter, error)
+	MarkReplayed(ctx context.Context, id, eventID uuid.UUID, at time.Time) error
+}
+
+// deadLetterSink receives records the consumer could not record
+type deadLetterSink interface {
+	Send(ctx context.Context, msg *sarama.ConsumerMessage, class domain.DeadLetterClass, cause error) error
+}
+
+// DeadLetterQueue keeps records the consumer could not record in the ledger, both in the
+// dlq_events table and, when configured, on the dead-letter topic, so none is lost
+type DeadLetterQueue struct {
+	producer sarama.SyncProducer // nil when no topic is configured
+	topic    string
+	store    deadLetterStore
+	logger   *zap.Logger
+}
+
+// NewDeadLetterQueue creates a dead-letter queue publishing to cfg.DLQTopic
+func NewDeadLetterQueue(cfg config.KafkaConfig, store *postgres.DLQRepository, logger *zap.Logger) (*DeadLetterQueue, error) {
+	q := &DeadLetterQueue{topic: cfg.DLQTopic, store: store, logger: logger}
+	if cfg.DLQTopic == "" {
+		return q, nil
+	}
+
+	config := sarama.NewConfig()
+	config.Version = sarama.V2_8_0_0
+	config.Producer.RequiredAcks = sarama.WaitForAll
+	config.Producer.Return.Successes = true
+	config.Producer.Retry.Max = 5
+	if cfg.EnableIdempotent {
+		config.Producer.Idempotent = true
+		config.Net.MaxOpenRequests = 1
+	}
+
+	producer, err := sarama.NewSyncProducer(cfg.Brokers, config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create dead-letter producer: %w", err)
+	}
+	q.producer = producer
+	return q, nil
+}
+
+// Send records msg as a dead letter of class, in the table and on the topic. The
+// original bytes and headers are kept unchanged. The dead letter's ID is derived from
+// the record's position and saving it again is a no-op, so when publishing fails and the
+// record is redelivered it does not gain a second row.
+func (q *DeadLetterQueue) Send(ctx context.Context, msg *sarama.ConsumerMessage, class domain.DeadLetterClass, cause error) error {
+	dl := deadLetterFromMessage(msg, class, cause)
+	if err := q.store.SaveDeadLetter(ctx, dl); err != nil {
+		return err
+	}
+
+	if q.producer != nil {
+		headers := make([]sarama.RecordHeader, 0, len(msg.Headers)+5)
+		for _, h := range msg.Headers {
+			if h != nil {
+				headers = append(headers, *h)
+			}
+		}
+		headers = append(headers,
+			sarama.RecordHeader{Key: []byte(dlqHeaderErrorClass), Value: []byte(class)},
+			sarama.RecordHeader{Key: []byte(dlqHeaderError), Value: []byte(dl.ErrorMessage)},
+			sarama.RecordHeader{Key: []byte(dlqHeaderSourceTopic), Value: []byte(msg.Topic)},
+			sarama.RecordHeader{Key: []byte(dlqHeaderSourcePartition), Value: []byte(strconv.FormatInt(int64(msg.Partition), 10))},
+			sarama.RecordHeader{Key: []byte(dlqHeaderSourceOffset), Value: []byte(strconv.FormatInt(msg.Offset, 10))},
+		)
+		out := &sarama.ProducerMessage{Topic: q.topic, Value: sarama.ByteEncoder(msg.Value), Headers: headers}
+		if msg.Key != nil {
+			out.Key = sarama.ByteEncoder(msg.Key)
+		}
+		if _, _, err := q.producer.SendMessage(out); err != nil {
+			return fmt.Errorf("failed to publish dead letter: %w", err)
+		}
+	}
+
+	q.logger.Warn("Audit event dead-lettered",
+		zap.String("dlq_id", dl.DLQID.String()),
+		zap.String("error_class", string(class)),
+		zap.String("topic", msg.Topic),
+		zap.Int32("partition", msg.Partition),
+		zap.Int64("offset", msg.Offset),
+		zap.Error(cause),
+	)
+	return nil
+}
+
+// Close releases the producer
+func (q *DeadLetterQueue) Close() error {
+	if q.producer == nil {
+		return nil
+	}
+	return q.producer.Close()
+}
+
+func deadLetterFromMessage(msg *sarama.ConsumerMessage, class domain.DeadLetterClass, cause error) *domain.DeadLetter {
+	dl := &domain.DeadLetter{
+		DLQID:           deadLetterID(msg),
+		SourceTopic:     msg.Topic,
+		SourcePartition: msg.Partition,
+		SourceOffset:    msg.Offset,
+		MessageKey:      msg.Key,
+		Payload:         msg.Value,
+		ErrorClass:      class,
+		ErrorMessage:    cause.Error(),
+		FailedAt:        time.Now().UTC(),
+	}
+	if dl.Payload == nil {
+		dl.Payload = []byte{}
+	}
+	var verr *schema.ValidationError
+	if errors.As(cause, &verr) {
+		for _, v := range verr.Violations {
+			dl.Violations = append(dl.Violations, domain.DeadLetterViolation{Field: v.Field, Message: v.Message})
+		}
+	}
+	if !msg.Timestamp.IsZero() {
+		ts := msg.Timestamp.UTC()
+		dl.MessageTimestamp = &ts
+	}
+	for _, h := range msg.Headers {
+		if h != nil {
+			dl.Headers = append(dl.Headers, domain.DeadLetterHeader{Key: string(h.Key), Value: h.Value})
+		}
+	}
+	return dl
+}
+
+// deadLetterID returns the ID of the dead letter for msg, the same on every delivery
+func deadLetterID(msg *sarama.ConsumerMessage) uuid.UUID {
+	position := msg.Topic + "\x00" + strconv.FormatInt(int64(msg.Partition), 10) + "\x00" + strconv.FormatInt(msg.Offset, 10)
+	return uuid.NewSHA1(dlqIDNamespace, []byte(position))
+}
+
+// messageFromDeadLetter rebuilds the record as first delivered, so a replay derives the
+// same event ID and content as the original delivery would have
+func messageFromDeadLetter(dl *domain.DeadLetter) *sarama.ConsumerMessage {
+	msg := &sarama.ConsumerMessage{
+		Topic:     dl.SourceTopic,
+		Partition: dl.SourcePartition,
+		Offset:    dl.SourceOffset,
+		Key:       dl.MessageKey,
+		Value:     dl.Payload,
+	}
+	if dl.MessageTimestamp != nil {
+		msg.Timestamp = *dl.MessageTimestamp
+	}
+	for _, h := range dl.Headers {
+		msg.Headers = append(msg.Headers, &sarama.RecordHeader{Key: []byte(h.Key), Value: h.Value})
+	}
+	return msg
+}
+
+// Replay outcomes
+const (
+	ReplayRecorded        = "RECORDED"
+	ReplayDuplicate       = "DUPLICATE" // The event was already in the ledger
+	ReplayAlreadyReplayed = "ALREADY_REPLAYED"
+	ReplayNotFound        = "NOT_FOUND"
+	ReplayFailed          = "FAILED"
+)
+
+// ReplayResult is the outcome of replaying one dead letter
+type ReplayResult struct {
+	DLQID   uuid.UUID  `json:"dlq_id"`
+	Status  string     `json:"status"`
+	EventID *uuid.UUID `json:"event_id,omitempty"`
+	Error   string     `json:"error,omitempty"`
+}
+
+// Replayer sends dead letters back through the ledger, mapped exactly as the consumer
+// maps a live record
+type Replayer struct {
+	letters deadLetterStore
+	events  eventStore
+	mappers *MapperRegistry
+	logger  *zap.Logger
+}
+
+// NewReplayer creates a replayer over the dlq_events table. mappers should be the
+// consumer's, so a record unmappable when it arrived can be replayed once a mapper for it
+// is registered.
+func NewReplayer(letters *postgres.DLQRepository, auditService *service.AuditService, mappers *MapperRegistry, logger *zap.Logger) *Replayer {
+	return &Replayer{letters: letters, events: auditService, mappers: mappers, logger: logger}
+}
+
+// Replay records the selected dead letters in the ledger and marks each one replayed. A
+// failure of one entry is reported in its result and does not stop the others.
+func (r *Replayer) Replay(ctx context.Context, ids []uuid.UUID) ([]ReplayResult, error) {
+	letters, err := r.letters.GetDeadLetters(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uuid.UUID]*domain.DeadLetter, len(letters))
+	for _, dl := range letters {
+		byID[dl.DLQID] = dl
+	}
+
+	results := make([]ReplayResult, len(ids))
+	for i, id := range ids {
+		results[i] = r.replay(ctx, id, byID[id])
+		r.logger.Info("Replayed dead letter",
+			zap.String("dlq_id", id.String()),
+			zap.String("status", results[i].Status),
+			zap.String("error", results[i].Error),
+		)
+	}
+	return results, nil
+}
+
+func (r *Replayer) replay(ctx context.Context, id uuid.UUID, dl *domain.DeadLetter) ReplayResult {
+	result := ReplayResult{DLQID: id}
+	switch {
+	case dl == nil:
+		result.Status = ReplayNotFound
+		return result
+	case dl.ReplayedAt != nil:
+		result.Status = ReplayAlreadyReplayed
+		result.EventID = dl.ReplayedEventID
+		return result
+	}
+
+	event, err := r.mappers.Decode(messageFromDeadLetter(dl))
+	if err != nil {
+		result.Status, result.Error = ReplayFailed, err.Error()
+		return result
+	}
+	duplicate, err := r.events.StoreEventOnce(ctx, event)
+	if err != nil {
+		result.Status, result.Error = ReplayFailed, err.Error()
+		return result
+	}
+
+	result.Status = ReplayRecorded
+	if duplicate {
+		result.Status = ReplayDuplicate
+	}
+	result.EventID = &event.EventID
+	if err := r.letters.MarkReplayed(ctx, id, event.EventID, time.Now().UTC()); err != nil {
+		if errors.Is(err, postgres.ErrDeadLetterReplayed) {
+			// A concurrent replay got there first; the event is recorded either way
+			result.Status = ReplayAlreadyReplayed
+			return result
+		}
+		result.Status, result.Error = ReplayFailed, err.Error()
+	}
+	return result
+}
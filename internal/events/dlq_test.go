@@ -0,0 +1,112 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/banking/audit-compliance/internal/domain"
+	"github.com/banking/audit-compliance/internal/repository/postgres"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// fakeLetters is an in-memory dlq_events table
+type fakeLetters struct {
+	letters map[uuid.UUID]*domain.DeadLetter
+}
+
+func (f *fakeLetters) SaveDeadLetter(_ context.Context, dl *domain.DeadLetter) error {
+	if _, ok := f.letters[dl.DLQID]; !ok {
+		f.letters[dl.DLQID] = dl
+	}
+	return nil
+}
+
+func (f *fakeLetters) GetDeadLetters(_ context.Context, ids []uuid.UUID) ([]*domain.DeadLetter, error) {
+	var found []*domain.DeadLetter
+	for _, id := range ids {
+		if dl, ok := f.letters[id]; ok {
+			found = append(found, dl)
+		}
+	}
+	return found, nil
+}
+
+func (f *fakeLetters) MarkReplayed(_ context.Context, id, eventID uuid.UUID, at time.Time) error {
+	dl := f.letters[id]
+	if dl.ReplayedAt != nil {
+		return postgres.ErrDeadLetterReplayed
+	}
+	dl.ReplayedAt, dl.ReplayedEventID = &at, &eventID
+	return nil
+}
+
+func TestDeadLetterRoundTrip(t *testing.T) {
+	msg := record(7, auditPayload("LOGIN", ""), &sarama.RecordHeader{Key: []byte(eventKeyHeader), Value: []byte("login-42")})
+	msg.Key = []byte("account-9")
+
+	dl := deadLetterFromMessage(msg, domain.DeadLetterPersistence, errors.New("ledger unavailable"))
+	assert.Equal(t, domain.DeadLetterPersistence, dl.ErrorClass)
+	assert.Equal(t, "ledger unavailable", dl.ErrorMessage)
+	assert.Equal(t, msg, messageFromDeadLetter(dl), "Replay must see the record as first delivered")
+}
+
+func TestDeadLetterIDIsStable(t *testing.T) {
+	letters := &fakeLetters{letters: map[uuid.UUID]*domain.DeadLetter{}}
+	q := &DeadLetterQueue{store: letters, logger: zap.NewNop()}
+
+	require.NoError(t, q.Send(context.Background(), record(7, `{}`), domain.DeadLetterPersistence, errors.New("timeout")))
+	require.NoError(t, q.Send(context.Background(), record(7, `{}`), domain.DeadLetterPersistence, errors.New("timeout")))
+	assert.Len(t, letters.letters, 1, "A redelivered record keeps its one dead letter")
+
+	require.NoError(t, q.Send(context.Background(), record(8, `{}`), domain.DeadLetterPersistence, errors.New("timeout")))
+	assert.Len(t, letters.letters, 2)
+}
+
+func TestQuarantineKeepsViolations(t *testing.T) {
+	msg := structured(testTopics.TransactionTopic,
+		`{"specversion":"1.0","id":"evt-1","source":"/payments","type":"TRANSFER_COMPLETED","data":{"user_id":"42","transfer_id":"TRF-1"}}`)
+	_, err := newTestMappers(t).Decode(msg)
+	require.Error(t, err)
+
+	dl := deadLetterFromMessage(msg, domain.DeadLetterQuarantined, err)
+	require.NotEmpty(t, dl.Violations)
+	assert.Equal(t, "/data/user_id", dl.Violations[0].Field)
+	assert.Contains(t, dl.ErrorMessage, "/data/user_id")
+}
+
+func TestReplay(t *testing.T) {
+	letters := &fakeLetters{letters: map[uuid.UUID]*domain.DeadLetter{}}
+	events := &fakeStore{events: map[uuid.UUID]*domain.AuditEvent{}}
+	replayer := &Replayer{letters: letters, events: events, mappers: NewMapperRegistry(AuditMapper, nil), logger: zap.NewNop()}
+
+	failed := deadLetterFromMessage(record(7, auditPayload("LOGIN", "")), domain.DeadLetterPersistence, errors.New("timeout"))
+	malformed := deadLetterFromMessage(record(8, `{"event_type":`), domain.DeadLetterMalformed, errors.New("unexpected EOF"))
+	for _, dl := range []*domain.DeadLetter{failed, malformed} {
+		require.NoError(t, letters.SaveDeadLetter(context.Background(), dl))
+	}
+	missing := uuid.New()
+
+	results, err := replayer.Replay(context.Background(), []uuid.UUID{failed.DLQID, malformed.DLQID, missing})
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+
+	assert.Equal(t, ReplayRecorded, results[0].Status)
+	require.NotNil(t, results[0].EventID)
+	assert.Equal(t, deriveEventID(map[string]interface{}{}, record(7, "")), *results[0].EventID,
+		"A replay must record the event under the ID the original delivery would have used")
+	assert.Equal(t, ReplayFailed, results[1].Status)
+	assert.NotEmpty(t, results[1].Error)
+	assert.Nil(t, letters.letters[malformed.DLQID].ReplayedAt)
+	assert.Equal(t, ReplayNotFound, results[2].Status)
+
+	again, err := replayer.Replay(context.Background(), []uuid.UUID{failed.DLQID})
+	require.NoError(t, err)
+	assert.Equal(t, ReplayAlreadyReplayed, again[0].Status)
+	assert.Len(t, events.events, 1)
+}
@@ -0,0 +1,179 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/banking/audit-compliance/internal/domain"
+	"github.com/banking/audit-compliance/internal/schema"
+	"github.com/google/uuid"
+)
+
+// CloudEvents 1.0 Kafka protocol binding
+const (
+	contentTypeHeader       = "content-type"
+	cloudEventsContentType  = "application/cloudevents+json" // Structured mode
+	cloudEventsHeaderPrefix = "ce_"                          // Binary mode attribute headers
+	cloudEventSchema        = "cloudevent.v1.json"
+)
+
+// CloudEvent holds the context attributes of a CloudEvents 1.0 envelope
+type CloudEvent struct {
+	SpecVersion     string `json:"specversion"`
+	ID              string `json:"id"`
+	Source          string `json:"source"`
+	Type            string `json:"type"`
+	Subject         string `json:"subject,omitempty"`
+	Time            string `json:"time,omitempty"`
+	DataContentType string `json:"datacontenttype,omitempty"`
+	DataSchema      string `json:"dataschema,omitempty"`
+}
+
+// Envelope is a decoded Kafka record: the JSON object its audit event is mapped from and,
+// for a CloudEvent, the envelope's context attributes
+type Envelope struct {
+	Type       string                 // event_type of a plain record; the CloudEvents type otherwise
+	Data       map[string]interface{} // The payload of a plain record; the data of a CloudEvent
+	CloudEvent *CloudEvent            // nil for plain JSON records
+	Message    *sarama.ConsumerMessage
+}
+
+// eventID returns the ID the record's event is recorded under. A CloudEvent is
+// identified by its source and id, as the specification requires them to be unique,
+// unless its data carries an event_id.
+func (e *Envelope) eventID() uuid.UUID {
+	if e.CloudEvent == nil {
+		return deriveEventID(e.Data, e.Message)
+	}
+	if id, err := uuid.Parse(stringField(e.Data, "event_id")); err == nil {
+		return id
+	}
+	return uuid.NewSHA1(eventIDNamespace, []byte("ce\x00"+e.CloudEvent.Source+"\x00"+e.CloudEvent.ID))
+}
+
+// setTimestamp sets when the event occurred from what the producer stated: the data's
+// timestamp, else the CloudEvents time. Failing both it falls back to the record's
+// timestamp, which a re-publication changes, and marks it as the time of receipt.
+func (e *Envelope) setTimestamp(event *domain.AuditEvent) {
+	for _, stated := range []string{stringField(e.Data, "timestamp"), e.cloudEventTime()} {
+		if t, err := time.Parse(time.RFC3339Nano, stated); err == nil {
+			event.Timestamp = t.UTC()
+			return
+		}
+	}
+	if !e.Message.Timestamp.IsZero() {
+		event.Timestamp = e.Message.Timestamp.UTC()
+	}
+	event.TimestampReceived = true
+}
+
+// cloudEventTime returns the CloudEvents time, or "" for a plain record
+func (e *Envelope) cloudEventTime() string {
+	if e.CloudEvent == nil {
+		return ""
+	}
+	return e.CloudEvent.Time
+}
+
+// source returns the CloudEvents source, or "" for a plain record
+func (e *Envelope) source() string {
+	if e.CloudEvent == nil {
+		return ""
+	}
+	return e.CloudEvent.Source
+}
+
+// typeViolation reports the record's event type as invalid: the type attribute of a
+// CloudEvent, or the event_type of a plain record validated against the schema name
+func (e *Envelope) typeViolation(name, message string) error {
+	if e.CloudEvent != nil {
+		return &schema.ValidationError{Schema: cloudEventSchema, Violations: []schema.Violation{{Field: "/type", Message: message}}}
+	}
+	return &schema.ValidationError{Schema: name, Violations: []schema.Violation{{Field: "/event_type", Message: message}}}
+}
+
+// unwrap returns the record's CloudEvents attributes, if any, its data, and the JSON
+// pointer of the data within the record. A structured CloudEvent is identified by its
+// content type, a binary one by its ce_specversion header; anything else is a plain
+// JSON record and is its own data. The attributes are validated against the CloudEvents
+// schema.
+func (r *MapperRegistry) unwrap(msg *sarama.ConsumerMessage) (*CloudEvent, []byte, string, error) {
+	if strings.HasPrefix(header(msg, contentTypeHeader), cloudEventsContentType) {
+		var doc struct {
+			CloudEvent
+			Data json.RawMessage `json:"data"`
+		}
+		if err := json.Unmarshal(msg.Value, &doc); err != nil {
+			return nil, nil, "", fmt.Errorf("malformed CloudEvent: %w", err)
+		}
+		if err := r.validate(cloudEventSchema, msg.Value, ""); err != nil {
+			return nil, nil, "", err
+		}
+		data := []byte(doc.Data)
+		if len(data) == 0 || string(data) == "null" {
+			data = []byte("{}")
+		}
+		return &doc.CloudEvent, data, "/data", nil
+	}
+
+	if header(msg, cloudEventsHeaderPrefix+"specversion") != "" {
+		attrs := map[string]string{}
+		for _, h := range msg.Headers {
+			if h != nil && strings.HasPrefix(string(h.Key), cloudEventsHeaderPrefix) {
+				attrs[strings.TrimPrefix(string(h.Key), cloudEventsHeaderPrefix)] = string(h.Value)
+			}
+		}
+		if ct := header(msg, contentTypeHeader); ct != "" {
+			attrs["datacontenttype"] = ct
+		}
+		encoded, err := json.Marshal(attrs)
+		if err != nil {
+			return nil, nil, "", fmt.Errorf("failed to encode CloudEvents attributes: %w", err)
+		}
+		if err := r.validate(cloudEventSchema, encoded, ""); err != nil {
+			return nil, nil, "", err
+		}
+		ce := &CloudEvent{}
+		if err := json.Unmarshal(encoded, ce); err != nil {
+			return nil, nil, "", fmt.Errorf("malformed CloudEvent: %w", err)
+		}
+		return ce, msg.Value, "", nil
+	}
+
+	return nil, msg.Value, "", nil
+}
+
+// validate checks payload against the named schema, reporting violations at their JSON
+// pointer within the record by prefixing them with at
+func (r *MapperRegistry) validate(name string, payload []byte, at string) error {
+	if r.schemas == nil {
+		return fmt.Errorf("%w: no schema registry for %s", schema.ErrUnknownSchema, name)
+	}
+	v, err := r.schemas.Validator(name)
+	if err != nil {
+		return err
+	}
+	err = v.Validate(payload)
+	verr, ok := err.(*schema.ValidationError)
+	if !ok || at == "" {
+		return err
+	}
+	located := &schema.ValidationError{Schema: verr.Schema, Violations: make([]schema.Violation, len(verr.Violations))}
+	for i, violation := range verr.Violations {
+		located.Violations[i] = schema.Violation{Field: at + violation.Field, Message: violation.Message}
+	}
+	return located
+}
+
+// header returns the value of the record's first header named key
+func header(msg *sarama.ConsumerMessage, key string) string {
+	for _, h := range msg.Headers {
+		if h != nil && strings.EqualFold(string(h.Key), key) {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,201 @@
+package events
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/banking/audit-compliance/internal/domain"
+	"github.com/banking/audit-compliance/internal/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func hdr(key, value string) *sarama.RecordHeader {
+	return &sarama.RecordHeader{Key: []byte(key), Value: []byte(value)}
+}
+
+func structured(topic, value string) *sarama.ConsumerMessage {
+	msg := topicRecord(topic, value)
+	msg.Headers = []*sarama.RecordHeader{hdr(contentTypeHeader, cloudEventsContentType+"; charset=utf-8")}
+	return msg
+}
+
+func binary(topic, data string, attrs ...string) *sarama.ConsumerMessage {
+	msg := topicRecord(topic, data)
+	msg.Headers = []*sarama.RecordHeader{hdr("content-type", "application/json")}
+	for i := 0; i+1 < len(attrs); i += 2 {
+		msg.Headers = append(msg.Headers, hdr(cloudEventsHeaderPrefix+attrs[i], attrs[i+1]))
+	}
+	return msg
+}
+
+func TestCloudEvents(t *testing.T) {
+	data := `{"user_id":"` + testUserID + `","transfer_id":"TRF-1","ip_address":"10.0.0.1"}`
+	envelope := func(extra string) string {
+		return `{"specversion":"1.0","id":"evt-1","source":"/payments/gateway","type":"TRANSFER_COMPLETED",` +
+			`"time":"2024-05-01T08:30:00Z"` + extra + `}`
+	}
+
+	tests := []struct {
+		name  string
+		msg   *sarama.ConsumerMessage
+		err   error
+		field string // Location of the first schema violation, when checked
+	}{
+		{name: "structured", msg: structured(testTopics.TransactionTopic, envelope(`,"data":`+data))},
+		{name: "structured with dataschema", msg: structured(testTopics.TransactionTopic,
+			envelope(`,"dataschema":"https://schemas.banking.internal/audit/transaction_event.v1.json","data":`+data))},
+		{
+			name:  "dataschema applies on a topic without a schema",
+			msg:   structured("banking.misc.events", envelope(`,"dataschema":"https://schemas.banking.internal/audit/transaction_event.v1.json","data":{"transfer_id":"TRF-1"}`)),
+			err:   schema.ErrInvalid,
+			field: "/data",
+		},
+		{
+			name:  "dataschema of another topic",
+			msg:   structured(testTopics.TransactionTopic, envelope(`,"dataschema":"https://schemas.banking.internal/audit/user_event.v1.json","data":`+data)),
+			err:   schema.ErrInvalid,
+			field: "/dataschema",
+		},
+		{
+			name:  "dataschema of the generic audit topic",
+			msg:   structured(testTopics.TransactionTopic, envelope(`,"dataschema":"audit_topic_event.v1.json","data":`+data)),
+			err:   schema.ErrInvalid,
+			field: "/dataschema",
+		},
+		{
+			name:  "unregistered version of the topic's schema",
+			msg:   structured(testTopics.TransactionTopic, envelope(`,"dataschema":"https://schemas.banking.internal/audit/transaction_event.v2.json","data":`+data)),
+			err:   schema.ErrInvalid,
+			field: "/dataschema",
+		},
+		{name: "binary", msg: binary(testTopics.TransactionTopic, data,
+			"specversion", "1.0", "id", "evt-1", "source", "/payments/gateway", "type", "TRANSFER_COMPLETED", "time", "2024-05-01T08:30:00Z")},
+		{
+			name:  "structured data failing its schema",
+			msg:   structured(testTopics.TransactionTopic, envelope(`,"data":{"user_id":"42","transfer_id":"TRF-1"}`)),
+			err:   schema.ErrInvalid,
+			field: "/data/user_id",
+		},
+		{
+			name:  "binary data failing its schema",
+			msg:   binary(testTopics.TransactionTopic, `{"user_id":"`+testUserID+`","ip_address":"10.0.0"}`, "specversion", "1.0", "id", "evt-1", "source", "/payments", "type", "TRANSFER_COMPLETED"),
+			err:   schema.ErrInvalid,
+			field: "/ip_address",
+		},
+		{
+			name:  "unsupported spec version",
+			msg:   structured(testTopics.TransactionTopic, `{"specversion":"0.3","id":"evt-1","source":"/payments","type":"TRANSFER_COMPLETED","data":`+data+`}`),
+			err:   schema.ErrInvalid,
+			field: "/specversion",
+		},
+		{
+			name:  "binary without a source",
+			msg:   binary(testTopics.TransactionTopic, data, "specversion", "1.0", "id", "evt-1", "type", "TRANSFER_COMPLETED"),
+			err:   schema.ErrInvalid,
+			field: "",
+		},
+		{
+			name:  "unknown dataschema",
+			msg:   structured(testTopics.TransactionTopic, envelope(`,"dataschema":"https://schemas.banking.internal/audit/transfer.v9.json","data":`+data)),
+			err:   schema.ErrInvalid,
+			field: "/dataschema",
+		},
+		{
+			name: "binary data",
+			msg:  structured(testTopics.TransactionTopic, envelope(`,"data_base64":"eyJ1c2VyX2lkIjoxfQ=="`)),
+			err:  schema.ErrInvalid,
+		},
+		{
+			name: "unknown type",
+			msg:  structured(testTopics.TransactionTopic, `{"specversion":"1.0","id":"evt-1","source":"/payments","type":"TRANSFER_TELEPORTED","data":`+data+`}`),
+			err:  ErrUnmappableEvent,
+		},
+		{
+			name:  "audit topic type that is not an action",
+			msg:   structured(testTopics.AuditTopic, auditCloudEvent("TELEPORT", auditPayload("LOGIN", ""))),
+			err:   schema.ErrInvalid,
+			field: "/type",
+		},
+		{
+			name:  "audit topic type differing from its data",
+			msg:   structured(testTopics.AuditTopic, auditCloudEvent("LOGOUT", auditPayload("LOGIN", ""))),
+			err:   schema.ErrInvalid,
+			field: "/type",
+		},
+		{
+			name:  "binary audit topic type that is not an action",
+			msg:   binary(testTopics.AuditTopic, auditPayload("LOGIN", ""), "specversion", "1.0", "id", "evt-1", "source", "/core", "type", "login"),
+			err:   schema.ErrInvalid,
+			field: "/type",
+		},
+		{
+			name: "malformed envelope",
+			msg:  structured(testTopics.TransactionTopic, `{"specversion":`),
+		},
+	}
+
+	mappers := newTestMappers(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, err := mappers.Decode(tt.msg)
+			switch {
+			case tt.name == "malformed envelope":
+				require.Error(t, err)
+				assert.NotErrorIs(t, err, schema.ErrInvalid)
+				return
+			case tt.err != nil:
+				require.ErrorIs(t, err, tt.err)
+				if tt.field != "" {
+					var verr *schema.ValidationError
+					require.True(t, errors.As(err, &verr))
+					assert.Equal(t, tt.field, verr.Violations[0].Field, verr.Error())
+				}
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, domain.ActionTypeTransfer, event.ActionType)
+			assert.Equal(t, domain.ResourceTypeTransfer, event.ResourceType)
+			assert.Equal(t, "TRF-1", event.ResourceID)
+			assert.Equal(t, "/payments/gateway", event.ServiceSource)
+			assert.Equal(t, time.Date(2024, 5, 1, 8, 30, 0, 0, time.UTC), event.Timestamp, "The CloudEvents time is when the event occurred")
+			assert.JSONEq(t, data, string(event.Metadata), "Only data is kept as metadata")
+		})
+	}
+}
+
+func auditCloudEvent(eventType, data string) string {
+	return `{"specversion":"1.0","id":"evt-1","source":"/core/sessions","type":"` + eventType + `","data":` + data + `}`
+}
+
+func TestAuditTopicCloudEvent(t *testing.T) {
+	event, err := newTestMappers(t).Decode(structured(testTopics.AuditTopic, auditCloudEvent("LOGIN", auditPayload("LOGIN", ""))))
+	require.NoError(t, err)
+	assert.Equal(t, domain.ActionTypeLogin, event.ActionType)
+	assert.Equal(t, domain.ResourceTypeUser, event.ResourceType)
+	assert.Equal(t, "/core/sessions", event.ServiceSource)
+}
+
+func TestCloudEventIDs(t *testing.T) {
+	mappers := newTestMappers(t)
+	data := `{"user_id":"` + testUserID + `","transfer_id":"TRF-1"}`
+	decode := func(msg *sarama.ConsumerMessage) *domain.AuditEvent {
+		event, err := mappers.Decode(msg)
+		require.NoError(t, err)
+		return event
+	}
+
+	structuredEvent := decode(structured(testTopics.TransactionTopic,
+		`{"specversion":"1.0","id":"evt-1","source":"/payments","type":"TRANSFER_COMPLETED","data":`+data+`}`))
+	binaryEvent := decode(binary(testTopics.TransactionTopic, data, "specversion", "1.0", "id", "evt-1", "source", "/payments", "type", "TRANSFER_COMPLETED"))
+	other := binary(testTopics.TransactionTopic, data, "specversion", "1.0", "id", "evt-1", "source", "/payments", "type", "TRANSFER_COMPLETED")
+	other.Offset = 99
+
+	assert.Equal(t, structuredEvent.EventID, binaryEvent.EventID, "Source and id identify a CloudEvent in either mode")
+	assert.Equal(t, binaryEvent.EventID, decode(other).EventID, "A republished CloudEvent keeps its ID")
+	assert.NotEqual(t, binaryEvent.EventID, decode(binary(testTopics.TransactionTopic, data,
+		"specversion", "1.0", "id", "evt-1", "source", "/cards", "type", "TRANSFER_COMPLETED")).EventID)
+}
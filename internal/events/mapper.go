@@ -0,0 +1,337 @@
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/IBM/sarama"
+	"github.com/banking/audit-compliance/internal/config"
+	"github.com/banking/audit-compliance/internal/domain"
+	"github.com/banking/audit-compliance/internal/schema"
+	"github.com/google/uuid"
+)
+
+// ErrUnmappableEvent is returned for a well-formed record that no mapper can turn into an
+// audit event, such as one of an unknown event type or missing a required field
+var ErrUnmappableEvent = errors.New("unmappable event")
+
+// Mapper turns a decoded Kafka record into an audit event. The event must be derived from
+// the record alone, so that a redelivery maps to an identical event.
+type Mapper interface {
+	Map(env *Envelope) (*domain.AuditEvent, error)
+}
+
+// MapperFunc adapts a function to Mapper
+type MapperFunc func(env *Envelope) (*domain.AuditEvent, error)
+
+// Map calls f
+func (f MapperFunc) Map(env *Envelope) (*domain.AuditEvent, error) {
+	return f(env)
+}
+
+// auditTopicSchema is the data schema of the generic audit topic
+const auditTopicSchema = "audit_topic_event.v1.json"
+
+// AuditMapper maps records of the generic audit topic, whose producers state the audit
+// fields themselves: the event type is the action, and resource_type and resource_id
+// name what it acted on. Records of any other action or resource type are refused, and
+// a CloudEvent whose type is not an action, or differs from its data's event_type, is
+// invalid.
+var AuditMapper Mapper = MapperFunc(func(env *Envelope) (*domain.AuditEvent, error) {
+	raw := env.Data
+	action := domain.ActionType(env.Type)
+	if !action.IsValid() {
+		return nil, env.typeViolation(auditTopicSchema, fmt.Sprintf("unknown action %q", env.Type))
+	}
+	if stated := stringField(raw, "event_type"); stated != env.Type {
+		return nil, env.typeViolation(auditTopicSchema, fmt.Sprintf("%q differs from the data's event_type %q", env.Type, stated))
+	}
+	userID, err := uuidField(raw, "user_id")
+	if err != nil {
+		return nil, err
+	}
+	if userID == nil {
+		return nil, fmt.Errorf("%w: %s event without user_id", ErrUnmappableEvent, env.Type)
+	}
+	resource := domain.ResourceType(stringField(raw, "resource_type"))
+	if !resource.IsValid() {
+		return nil, fmt.Errorf("%w: unknown resource type %q", ErrUnmappableEvent, resource)
+	}
+	resourceID := stringField(raw, "resource_id")
+	if resourceID == "" {
+		return nil, fmt.Errorf("%w: %s event without resource_id", ErrUnmappableEvent, env.Type)
+	}
+
+	event := domain.NewAuditEvent(*userID, action, resource, resourceID)
+	event.Result = domain.AuditResultSuccess
+	if err := mapContext(event, env, env.Message.Topic); err != nil {
+		return nil, err
+	}
+	return event, nil
+})
+
+// MapperRegistry validates a record against its schemas and selects the mapper for it:
+// one registered for its event type, else one registered for its topic, else the fallback
+type MapperRegistry struct {
+	byEventType  map[string]Mapper
+	byTopic      map[string]Mapper
+	topicSchemas map[string]string // Topic -> schema of data that names none
+	schemas      schema.Registry
+	fallback     Mapper // nil refuses records no mapper is registered for
+}
+
+// NewMapperRegistry creates a registry that validates data against schemas and maps
+// unregistered records with fallback. schemas may be nil when no record is validated;
+// CloudEvents are then refused.
+func NewMapperRegistry(fallback Mapper, schemas schema.Registry) *MapperRegistry {
+	return &MapperRegistry{
+		byEventType:  map[string]Mapper{},
+		byTopic:      map[string]Mapper{},
+		topicSchemas: map[string]string{},
+		schemas:      schemas,
+		fallback:     fallback,
+	}
+}
+
+// NewDefaultMappers registers the first-class mappers and data schemas for the audit,
+// transaction, user and alert topics of cfg. Records of any other topic are refused.
+func NewDefaultMappers(cfg config.KafkaConfig, schemas schema.Registry) (*MapperRegistry, error) {
+	r := NewMapperRegistry(nil, schemas)
+	r.RegisterTopic(cfg.AuditTopic, AuditMapper)
+	r.RegisterTopic(cfg.TransactionTopic, TransactionMapper)
+	r.RegisterTopic(cfg.UserTopic, UserMapper)
+	r.RegisterTopic(cfg.AlertTopic, AlertMapper)
+	for topic, name := range map[string]string{
+		cfg.AuditTopic:       auditTopicSchema,
+		cfg.TransactionTopic: "transaction_event.v1.json",
+		cfg.UserTopic:        "user_event.v1.json",
+		cfg.AlertTopic:       "alert_event.v1.json",
+	} {
+		if err := r.RegisterTopicSchema(topic, name); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// RegisterTopic maps every record of topic with m, unless its event type has a mapper
+func (r *MapperRegistry) RegisterTopic(topic string, m Mapper) {
+	r.byTopic[topic] = m
+}
+
+// RegisterEventType maps records whose event type is eventType with m, on any topic
+func (r *MapperRegistry) RegisterEventType(eventType string, m Mapper) {
+	r.byEventType[eventType] = m
+}
+
+// RegisterTopicSchema validates the data of topic's records against the schema name. A
+// CloudEvent may name another version of it as its dataschema, but no other schema.
+func (r *MapperRegistry) RegisterTopicSchema(topic, name string) error {
+	if r.schemas == nil {
+		return fmt.Errorf("%w: no schema registry for %s", schema.ErrUnknownSchema, name)
+	}
+	if _, err := r.schemas.Validator(name); err != nil {
+		return fmt.Errorf("topic %s: %w", topic, err)
+	}
+	r.topicSchemas[topic] = name
+	return nil
+}
+
+// Decode parses a Kafka record, plain JSON or a CloudEvent in structured or binary mode,
+// validates its data and maps it onto an audit event. Errors wrap schema.ErrInvalid when
+// the record fails a schema, and ErrUnmappableEvent when it is valid but no mapper can
+// map it.
+func (r *MapperRegistry) Decode(msg *sarama.ConsumerMessage) (*domain.AuditEvent, error) {
+	ce, data, dataPath, err := r.unwrap(msg)
+	if err != nil {
+		return nil, err
+	}
+	env := &Envelope{CloudEvent: ce, Message: msg}
+	if err := json.Unmarshal(data, &env.Data); err != nil || env.Data == nil {
+		return nil, fmt.Errorf("malformed event: data is not a JSON object: %v", err)
+	}
+
+	// Validate before mapping, so a producer bug is quarantined rather than recorded
+	name := r.topicSchemas[msg.Topic]
+	if ce != nil && ce.DataSchema != "" {
+		named := schema.NameFromURI(ce.DataSchema)
+		if name != "" && schema.Payload(named) != schema.Payload(name) {
+			return nil, &schema.ValidationError{Schema: cloudEventSchema, Violations: []schema.Violation{
+				{Field: "/dataschema", Message: fmt.Sprintf("topic %s requires a version of %s", msg.Topic, name)},
+			}}
+		}
+		if _, err := r.schemas.Validator(named); err != nil {
+			return nil, &schema.ValidationError{Schema: cloudEventSchema, Violations: []schema.Violation{
+				{Field: "/dataschema", Message: err.Error()},
+			}}
+		}
+		name = named
+	}
+	if name != "" {
+		if err := r.validate(name, data, dataPath); err != nil {
+			return nil, err
+		}
+	}
+
+	env.Type = stringField(env.Data, "event_type")
+	if ce != nil {
+		env.Type = ce.Type
+	}
+	m, ok := r.byEventType[env.Type]
+	if !ok {
+		m, ok = r.byTopic[msg.Topic]
+	}
+	if !ok {
+		m = r.fallback
+	}
+	if m == nil {
+		return nil, fmt.Errorf("%w: no mapper for topic %s", ErrUnmappableEvent, msg.Topic)
+	}
+	return m.Map(env)
+}
+
+// eventMapping is how a mapper records one event type
+type eventMapping struct {
+	action   domain.ActionType
+	resource domain.ResourceType
+	idField  string             // Payload field holding the resource ID
+	result   domain.AuditResult // Unless the payload carries a valid "result"
+}
+
+// typeMapper maps the event types of one source topic from a fixed table and refuses any
+// other type. For a CloudEvent the event type is its type attribute.
+type typeMapper struct {
+	source string // Service name recorded when the payload names none
+	types  map[string]eventMapping
+}
+
+// TransactionMapper maps events of the core banking transaction topic
+var TransactionMapper Mapper = typeMapper{source: "transaction-service", types: map[string]eventMapping{
+	"TRANSACTION_CREATED":  {domain.ActionTypeCreate, domain.ResourceTypeTransaction, "transaction_id", domain.AuditResultSuccess},
+	"TRANSACTION_APPROVED": {domain.ActionTypeApprove, domain.ResourceTypeTransaction, "transaction_id", domain.AuditResultSuccess},
+	"TRANSACTION_REJECTED": {domain.ActionTypeReject, domain.ResourceTypeTransaction, "transaction_id", domain.AuditResultSuccess},
+	"TRANSACTION_VIEWED":   {domain.ActionTypeRead, domain.ResourceTypeTransaction, "transaction_id", domain.AuditResultSuccess},
+	"TRANSFER_INITIATED":   {domain.ActionTypeTransfer, domain.ResourceTypeTransfer, "transfer_id", domain.AuditResultPending},
+	"TRANSFER_COMPLETED":   {domain.ActionTypeTransfer, domain.ResourceTypeTransfer, "transfer_id", domain.AuditResultSuccess},
+	"TRANSFER_FAILED":      {domain.ActionTypeTransfer, domain.ResourceTypeTransfer, "transfer_id", domain.AuditResultFailure},
+}}
+
+// UserMapper maps events of the user and identity topic
+var UserMapper Mapper = typeMapper{source: "user-service", types: map[string]eventMapping{
+	"USER_CREATED":      {domain.ActionTypeCreate, domain.ResourceTypeUser, "user_id", domain.AuditResultSuccess},
+	"USER_UPDATED":      {domain.ActionTypeUpdate, domain.ResourceTypeUser, "user_id", domain.AuditResultSuccess},
+	"USER_DELETED":      {domain.ActionTypeDelete, domain.ResourceTypeUser, "user_id", domain.AuditResultSuccess},
+	"USER_LOGIN":        {domain.ActionTypeLogin, domain.ResourceTypeSession, "session_id", domain.AuditResultSuccess},
+	"USER_LOGIN_FAILED": {domain.ActionTypeLogin, domain.ResourceTypeUser, "user_id", domain.AuditResultFailure},
+	"USER_LOGOUT":       {domain.ActionTypeLogout, domain.ResourceTypeSession, "session_id", domain.AuditResultSuccess},
+	"PASSWORD_CHANGED":  {domain.ActionTypeUpdate, domain.ResourceTypeUser, "user_id", domain.AuditResultSuccess},
+	"DEVICE_VERIFIED":   {domain.ActionTypeVerify, domain.ResourceTypeDevice, "device_id", domain.AuditResultSuccess},
+	"KYC_VERIFIED":      {domain.ActionTypeVerify, domain.ResourceTypeKYC, "kyc_id", domain.AuditResultSuccess},
+	"CONSENT_GRANTED":   {domain.ActionTypeConsent, domain.ResourceTypeConsent, "consent_id", domain.AuditResultSuccess},
+	"CONSENT_REVOKED":   {domain.ActionTypeRevoke, domain.ResourceTypeConsent, "consent_id", domain.AuditResultSuccess},
+}}
+
+// AlertMapper maps events of the compliance alert topic
+var AlertMapper Mapper = typeMapper{source: "compliance-service", types: map[string]eventMapping{
+	"AML_ALERT_RAISED":       {domain.ActionTypeCreate, domain.ResourceTypeAMLFlag, "alert_id", domain.AuditResultPending},
+	"AML_ALERT_ESCALATED":    {domain.ActionTypeEscalate, domain.ResourceTypeAMLFlag, "alert_id", domain.AuditResultPending},
+	"AML_ALERT_INVESTIGATED": {domain.ActionTypeInvestigate, domain.ResourceTypeAMLFlag, "alert_id", domain.AuditResultSuccess},
+	"AML_ALERT_CLEARED":      {domain.ActionTypeApprove, domain.ResourceTypeAMLFlag, "alert_id", domain.AuditResultSuccess},
+	"ACCOUNT_FROZEN":         {domain.ActionTypeFreeze, domain.ResourceTypeAccount, "account_id", domain.AuditResultSuccess},
+	"ACCOUNT_UNFROZEN":       {domain.ActionTypeUnfreeze, domain.ResourceTypeAccount, "account_id", domain.AuditResultSuccess},
+}}
+
+// Map fills the event from the data fields shared by the banking services' events:
+// user_id (required), actor_id, transaction_id, ip_address, user_agent, session_id,
+// request_id, result and failure_reason. The whole data is kept as metadata.
+func (m typeMapper) Map(env *Envelope) (*domain.AuditEvent, error) {
+	raw, eventType := env.Data, env.Type
+	mapping, ok := m.types[eventType]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown event type %q on topic %s", ErrUnmappableEvent, eventType, env.Message.Topic)
+	}
+
+	userID, err := uuidField(raw, "user_id")
+	if err != nil {
+		return nil, err
+	}
+	if userID == nil {
+		return nil, fmt.Errorf("%w: %s event without user_id", ErrUnmappableEvent, eventType)
+	}
+	resourceID := stringField(raw, mapping.idField)
+	if resourceID == "" {
+		return nil, fmt.Errorf("%w: %s event without %s", ErrUnmappableEvent, eventType, mapping.idField)
+	}
+
+	event := domain.NewAuditEvent(*userID, mapping.action, mapping.resource, resourceID)
+	event.Result = mapping.result
+	if err := mapContext(event, env, m.source); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+// mapContext fills the event from the record and the data fields shared by every
+// topic's events, recording source as the service unless the record names one. The
+// whole data is kept as metadata.
+func mapContext(event *domain.AuditEvent, env *Envelope, source string) error {
+	raw := env.Data
+	event.EventID = env.eventID()
+	env.setTimestamp(event)
+
+	var err error
+	if event.TransactionID, err = uuidField(raw, "transaction_id"); err != nil {
+		return err
+	}
+	if event.ActorID, err = uuidField(raw, "actor_id"); err != nil {
+		return err
+	}
+
+	event.ServiceSource = source
+	if source := env.source(); source != "" {
+		event.ServiceSource = source
+	}
+	if source := stringField(raw, "service_source"); source != "" {
+		event.ServiceSource = source
+	}
+	if result := domain.AuditResult(stringField(raw, "result")); result.IsValid() {
+		event.Result = result
+	}
+	event.IPAddress = stringField(raw, "ip_address")
+	event.RequestID = stringField(raw, "request_id")
+	event.UserAgent = optionalField(raw, "user_agent")
+	event.SessionID = optionalField(raw, "session_id")
+	event.FailureReason = optionalField(raw, "failure_reason")
+
+	if metaBytes, err := json.Marshal(raw); err == nil {
+		event.Metadata = metaBytes
+	}
+	return nil
+}
+
+// uuidField returns the UUID in field, nil when it is absent and an error when it is not
+// a UUID
+func uuidField(raw map[string]interface{}, field string) (*uuid.UUID, error) {
+	value := stringField(raw, field)
+	if value == "" {
+		return nil, nil
+	}
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s is not a UUID", ErrUnmappableEvent, field)
+	}
+	return &id, nil
+}
+
+func stringField(raw map[string]interface{}, field string) string {
+	s, _ := raw[field].(string)
+	return s
+}
+
+func optionalField(raw map[string]interface{}, field string) *string {
+	if s := stringField(raw, field); s != "" {
+		return &s
+	}
+	return nil
+}
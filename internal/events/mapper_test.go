@@ -0,0 +1,241 @@
+package events
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/banking/audit-compliance/internal/config"
+	"github.com/banking/audit-compliance/internal/domain"
+	"github.com/banking/audit-compliance/internal/schema"
+	"github.com/banking/audit-compliance/schemas"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testTopics = config.KafkaConfig{
+	AuditTopic:       "banking.audit.events",
+	TransactionTopic: "banking.transactions",
+	UserTopic:        "banking.users",
+	AlertTopic:       "banking.compliance.alerts",
+}
+
+const (
+	testUserID  = "7f1c2e4a-9b3d-4c5e-8a6f-1b2c3d4e5f60"
+	testActorID = "0d9e8f7a-6b5c-4d3e-9f1a-2b3c4d5e6f70"
+	testTxnID   = "3a4b5c6d-7e8f-4a1b-8c2d-3e4f5a6b7c8d"
+)
+
+func newTestMappers(t *testing.T) *MapperRegistry {
+	t.Helper()
+	registry, err := schema.NewFSRegistry(schemas.FS)
+	require.NoError(t, err)
+	mappers, err := NewDefaultMappers(testTopics, registry)
+	require.NoError(t, err)
+	return mappers
+}
+
+func topicRecord(topic, value string) *sarama.ConsumerMessage {
+	msg := record(7, value)
+	msg.Topic = topic
+	return msg
+}
+
+func TestDefaultMappers(t *testing.T) {
+	tests := []struct {
+		name       string
+		topic      string
+		payload    string
+		action     domain.ActionType
+		resource   domain.ResourceType
+		resourceID string
+		result     domain.AuditResult
+		source     string
+		err        error
+		field      string // Location of the first schema violation, when checked
+	}{
+		{
+			name:  "transfer completed",
+			topic: testTopics.TransactionTopic,
+			payload: `{"event_type":"TRANSFER_COMPLETED","user_id":"` + testUserID + `","transfer_id":"TRF-1",` +
+				`"transaction_id":"` + testTxnID + `","actor_id":"` + testActorID + `","ip_address":"10.0.0.1"}`,
+			action: domain.ActionTypeTransfer, resource: domain.ResourceTypeTransfer, resourceID: "TRF-1",
+			result: domain.AuditResultSuccess, source: "transaction-service",
+		},
+		{
+			name:  "payload result and source override the defaults",
+			topic: testTopics.TransactionTopic,
+			payload: `{"event_type":"TRANSACTION_CREATED","user_id":"` + testUserID + `","transaction_id":"` + testTxnID + `",` +
+				`"result":"DENIED","service_source":"payments-gateway"}`,
+			action: domain.ActionTypeCreate, resource: domain.ResourceTypeTransaction, resourceID: testTxnID,
+			result: domain.AuditResultDenied, source: "payments-gateway",
+		},
+		{
+			name:    "login",
+			topic:   testTopics.UserTopic,
+			payload: `{"event_type":"USER_LOGIN","user_id":"` + testUserID + `","session_id":"sess-9","user_agent":"curl/8"}`,
+			action:  domain.ActionTypeLogin, resource: domain.ResourceTypeSession, resourceID: "sess-9",
+			result: domain.AuditResultSuccess, source: "user-service",
+		},
+		{
+			name:    "account frozen",
+			topic:   testTopics.AlertTopic,
+			payload: `{"event_type":"ACCOUNT_FROZEN","user_id":"` + testUserID + `","account_id":"ACC-5"}`,
+			action:  domain.ActionTypeFreeze, resource: domain.ResourceTypeAccount, resourceID: "ACC-5",
+			result: domain.AuditResultSuccess, source: "compliance-service",
+		},
+		{
+			name:  "audit topic record",
+			topic: testTopics.AuditTopic,
+			payload: `{"event_type":"LOGIN","user_id":"` + testUserID + `","resource_type":"SESSION","resource_id":"sess-9",` +
+				`"result":"FAILURE"}`,
+			action: domain.ActionTypeLogin, resource: domain.ResourceTypeSession, resourceID: "sess-9",
+			result: domain.AuditResultFailure, source: testTopics.AuditTopic,
+		},
+		{
+			name:    "audit topic record of an unknown resource type",
+			topic:   testTopics.AuditTopic,
+			payload: `{"event_type":"LOGIN","user_id":"` + testUserID + `","resource_type":"PLANET","resource_id":"p-1"}`,
+			err:     schema.ErrInvalid,
+			field:   "/resource_type",
+		},
+		{
+			name:    "audit topic record without a resource ID",
+			topic:   testTopics.AuditTopic,
+			payload: `{"event_type":"LOGIN","user_id":"` + testUserID + `","resource_type":"SESSION"}`,
+			err:     schema.ErrInvalid,
+		},
+		{
+			name:    "audit topic record without an event type",
+			topic:   testTopics.AuditTopic,
+			payload: `{"user_id":"` + testUserID + `","resource_type":"SESSION","resource_id":"sess-9"}`,
+			err:     schema.ErrInvalid,
+		},
+		{
+			name:    "audit topic record without a user",
+			topic:   testTopics.AuditTopic,
+			payload: `{"event_type":"LOGIN"}`,
+			err:     schema.ErrInvalid,
+		},
+		{
+			name:    "audit topic record of an unknown action",
+			topic:   testTopics.AuditTopic,
+			payload: auditPayload("TELEPORT", ""),
+			err:     schema.ErrInvalid,
+			field:   "/event_type",
+		},
+		{
+			name:    "unregistered topic",
+			topic:   "banking.marketing",
+			payload: `{"event_type":"LOGIN","user_id":"` + testUserID + `"}`,
+			err:     ErrUnmappableEvent,
+		},
+		{
+			name:    "unknown event type",
+			topic:   testTopics.TransactionTopic,
+			payload: `{"event_type":"USER_LOGIN","user_id":"` + testUserID + `","session_id":"sess-9"}`,
+			err:     ErrUnmappableEvent,
+		},
+		{
+			name:    "missing user",
+			topic:   testTopics.UserTopic,
+			payload: `{"event_type":"USER_CREATED"}`,
+			err:     schema.ErrInvalid,
+		},
+		{
+			name:    "missing resource ID",
+			topic:   testTopics.AlertTopic,
+			payload: `{"event_type":"AML_ALERT_RAISED","user_id":"` + testUserID + `"}`,
+			err:     ErrUnmappableEvent,
+		},
+		{
+			name:    "invalid actor",
+			topic:   testTopics.UserTopic,
+			payload: `{"event_type":"USER_UPDATED","user_id":"` + testUserID + `","actor_id":"admin"}`,
+			err:     schema.ErrInvalid,
+			field:   "/actor_id",
+		},
+		{
+			name:    "invalid field type",
+			topic:   testTopics.TransactionTopic,
+			payload: `{"event_type":"TRANSACTION_CREATED","user_id":"` + testUserID + `","transaction_id":"` + testTxnID + `","amount_cents":"12.00"}`,
+			err:     schema.ErrInvalid,
+			field:   "/amount_cents",
+		},
+	}
+
+	mappers := newTestMappers(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, err := mappers.Decode(topicRecord(tt.topic, tt.payload))
+			if tt.err != nil {
+				require.ErrorIs(t, err, tt.err)
+				if tt.field != "" {
+					var verr *schema.ValidationError
+					require.True(t, errors.As(err, &verr))
+					assert.Equal(t, tt.field, verr.Violations[0].Field, verr.Error())
+				}
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.action, event.ActionType)
+			assert.Equal(t, tt.resource, event.ResourceType)
+			assert.Equal(t, tt.resourceID, event.ResourceID)
+			assert.Equal(t, tt.result, event.Result)
+			assert.Equal(t, tt.source, event.ServiceSource)
+			assert.Equal(t, testUserID, event.UserID.String())
+			assert.Equal(t, time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC), event.Timestamp)
+		})
+	}
+}
+
+func TestTransactionMapperFillsContext(t *testing.T) {
+	payload := `{"event_type":"TRANSFER_FAILED","user_id":"` + testUserID + `","transfer_id":"TRF-1",` +
+		`"transaction_id":"` + testTxnID + `","actor_id":"` + testActorID + `","ip_address":"10.0.0.1",` +
+		`"user_agent":"mobile/3.2","session_id":"sess-9","request_id":"req-1","failure_reason":"insufficient funds"}`
+	msg := topicRecord(testTopics.TransactionTopic, payload)
+
+	event, err := newTestMappers(t).Decode(msg)
+	require.NoError(t, err)
+	require.NotNil(t, event.TransactionID)
+	assert.Equal(t, testTxnID, event.TransactionID.String())
+	require.NotNil(t, event.ActorID)
+	assert.Equal(t, testActorID, event.ActorID.String())
+	assert.Equal(t, "10.0.0.1", event.IPAddress)
+	assert.Equal(t, "req-1", event.RequestID)
+	assert.Equal(t, "mobile/3.2", *event.UserAgent)
+	assert.Equal(t, "sess-9", *event.SessionID)
+	assert.Equal(t, "insufficient funds", *event.FailureReason)
+	assert.Equal(t, domain.AuditResultFailure, event.Result)
+	assert.JSONEq(t, payload, string(event.Metadata))
+	assert.NoError(t, event.Validate())
+
+	again, err := newTestMappers(t).Decode(msg)
+	require.NoError(t, err)
+	assert.Equal(t, event.SubmittedContent(), again.SubmittedContent(), "A redelivery must map to an identical event")
+}
+
+func TestMapperRegistryPrecedence(t *testing.T) {
+	custom := MapperFunc(func(env *Envelope) (*domain.AuditEvent, error) {
+		event := domain.NewAuditEvent(uuid.MustParse(testUserID), domain.ActionTypeLogin, domain.ResourceTypeUser, "legacy")
+		event.ServiceSource = "custom"
+		return event, nil
+	})
+
+	r := NewMapperRegistry(nil, nil)
+	r.RegisterTopic(testTopics.UserTopic, UserMapper)
+	r.RegisterEventType("LEGACY_LOGIN", custom)
+
+	event, err := r.Decode(topicRecord(testTopics.UserTopic, `{"event_type":"LEGACY_LOGIN"}`))
+	require.NoError(t, err)
+	assert.Equal(t, "custom", event.ServiceSource, "An event type mapper takes precedence over the topic's")
+
+	_, err = r.Decode(topicRecord(testTopics.AuditTopic, `{"event_type":"LOGIN"}`))
+	assert.ErrorIs(t, err, ErrUnmappableEvent, "Without a fallback, unregistered records are refused")
+
+	_, err = r.Decode(topicRecord(testTopics.UserTopic, `{"event_type":`))
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, ErrUnmappableEvent, "Invalid JSON is malformed, not unmappable")
+}
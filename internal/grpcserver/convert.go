@@ -0,0 +1,174 @@
+package grpcserver
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/banking/audit-compliance/internal/domain"
+	auditv1 "github.com/banking/audit-compliance/proto/audit/v1"
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// eventFromRequest maps a submitted event onto the domain model. Defaults match the
+// Kafka path: a generated ID, the receive time, SUCCESS and STANDARD retention.
+func eventFromRequest(req *auditv1.RecordEventRequest) (*domain.AuditEvent, error) {
+	event := &domain.AuditEvent{
+		ActionType:        domain.ActionType(strings.ToUpper(req.GetActionType())),
+		ResourceType:      domain.ResourceType(strings.ToUpper(req.GetResourceType())),
+		ResourceID:        req.GetResourceId(),
+		ServiceSource:     req.GetServiceSource(),
+		Result:            domain.AuditResult(strings.ToUpper(req.GetResult())),
+		FailureReason:     req.FailureReason,
+		IPAddress:         req.GetIpAddress(),
+		Geolocation:       req.Geolocation,
+		UserAgent:         req.UserAgent,
+		RequestID:         req.GetRequestId(),
+		SessionID:         req.SessionId,
+		Metadata:          req.GetMetadata(),
+		ComplianceFlags:   req.GetComplianceFlags(),
+		RetentionCategory: req.GetRetentionCategory(),
+	}
+	if event.Result == "" {
+		event.Result = domain.AuditResultSuccess
+	}
+	if event.RetentionCategory == "" {
+		event.RetentionCategory = "STANDARD"
+	}
+	if req.GetTimestamp() != nil {
+		if err := req.GetTimestamp().CheckValid(); err != nil {
+			return nil, fmt.Errorf("%w: invalid timestamp", domain.ErrInvalidEvent)
+		}
+		event.Timestamp = req.GetTimestamp().AsTime()
+	}
+
+	var err error
+	if req.GetEventId() != "" {
+		if event.EventID, err = uuid.Parse(req.GetEventId()); err != nil {
+			return nil, fmt.Errorf("%w: invalid event_id", domain.ErrInvalidEvent)
+		}
+	}
+	if event.UserID, err = uuid.Parse(req.GetUserId()); err != nil {
+		return nil, fmt.Errorf("%w: invalid user_id", domain.ErrInvalidEvent)
+	}
+	if event.TransactionID, err = optionalUUID(req.TransactionId); err != nil {
+		return nil, fmt.Errorf("%w: invalid transaction_id", domain.ErrInvalidEvent)
+	}
+	if event.ActorID, err = optionalUUID(req.ActorId); err != nil {
+		return nil, fmt.Errorf("%w: invalid actor_id", domain.ErrInvalidEvent)
+	}
+
+	if err := event.Validate(); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+// filterFromRequest maps a query onto the ledger filter
+func filterFromRequest(req *auditv1.QueryEventsRequest) (domain.AuditEventFilter, error) {
+	var filter domain.AuditEventFilter
+	var err error
+	if filter.UserID, err = optionalUUID(req.UserId); err != nil {
+		return filter, errors.New("invalid user_id")
+	}
+	if filter.ActorID, err = optionalUUID(req.ActorId); err != nil {
+		return filter, errors.New("invalid actor_id")
+	}
+	if filter.TransactionID, err = optionalUUID(req.TransactionId); err != nil {
+		return filter, errors.New("invalid transaction_id")
+	}
+	for _, v := range req.GetActionTypes() {
+		actionType := domain.ActionType(strings.ToUpper(v))
+		if !actionType.IsValid() {
+			return filter, fmt.Errorf("unknown action_type %q", v)
+		}
+		filter.ActionTypes = append(filter.ActionTypes, actionType)
+	}
+	for _, v := range req.GetResourceTypes() {
+		resourceType := domain.ResourceType(strings.ToUpper(v))
+		if !resourceType.IsValid() {
+			return filter, fmt.Errorf("unknown resource_type %q", v)
+		}
+		filter.ResourceTypes = append(filter.ResourceTypes, resourceType)
+	}
+	if req.Result != nil {
+		result := domain.AuditResult(strings.ToUpper(req.GetResult()))
+		if !result.IsValid() {
+			return filter, fmt.Errorf("unknown result %q", req.GetResult())
+		}
+		filter.Result = &result
+	}
+	if req.GetStartTime() != nil {
+		t := req.GetStartTime().AsTime()
+		filter.StartTime = &t
+	}
+	if req.GetEndTime() != nil {
+		t := req.GetEndTime().AsTime()
+		filter.EndTime = &t
+	}
+	filter.ResourceID = req.ResourceId
+	filter.ServiceSource = req.ServiceSource
+	return filter, nil
+}
+
+// eventToProto maps a stored event to its wire form
+func eventToProto(e *domain.AuditEvent) *auditv1.AuditEvent {
+	return &auditv1.AuditEvent{
+		EventId:            e.EventID.String(),
+		TransactionId:      uuidString(e.TransactionID),
+		UserId:             e.UserID.String(),
+		ActorId:            uuidString(e.ActorID),
+		ActionType:         string(e.ActionType),
+		ResourceType:       string(e.ResourceType),
+		ResourceId:         e.ResourceID,
+		ServiceSource:      e.ServiceSource,
+		Timestamp:          timestamppb.New(e.Timestamp),
+		Result:             string(e.Result),
+		FailureReason:      e.FailureReason,
+		IpAddress:          e.IPAddress,
+		Geolocation:        e.Geolocation,
+		UserAgent:          e.UserAgent,
+		RequestId:          e.RequestID,
+		SessionId:          e.SessionID,
+		Metadata:           e.Metadata,
+		ComplianceFlags:    e.ComplianceFlags,
+		RetentionCategory:  e.RetentionCategory,
+		CreatedAt:          timestamppb.New(e.CreatedAt),
+		SequenceNum:        e.SequenceNum,
+		PrevHash:           e.PrevHash,
+		RecordHash:         e.RecordHash,
+		DigitalSignature:   e.DigitalSignature,
+		SignatureAlgorithm: e.SignatureAlgorithm,
+		SigningKeyId:       e.SigningKeyID,
+	}
+}
+
+// receipt acknowledges a persisted event with its place in the hash chain
+func receipt(e *domain.AuditEvent) *auditv1.RecordEventResponse {
+	return &auditv1.RecordEventResponse{
+		EventId:     e.EventID.String(),
+		SequenceNum: e.SequenceNum,
+		RecordHash:  e.RecordHash,
+		CreatedAt:   timestamppb.New(e.CreatedAt),
+	}
+}
+
+func optionalUUID(s *string) (*uuid.UUID, error) {
+	if s == nil || *s == "" {
+		return nil, nil
+	}
+	id, err := uuid.Parse(*s)
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
+func uuidString(id *uuid.UUID) *string {
+	if id == nil {
+		return nil
+	}
+	s := id.String()
+	return &s
+}
@@ -0,0 +1,131 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+
+	"github.com/banking/audit-compliance/internal/auth"
+	auditv1 "github.com/banking/audit-compliance/proto/audit/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// Metadata keys mirroring the HTTP headers
+const (
+	authorizationKey = "authorization"
+	apiKeyKey        = "x-api-key"
+	purposeKey       = "x-access-purpose"
+)
+
+// methodPolicy is what an RPC requires of its caller
+type methodPolicy struct {
+	permission auth.Permission
+	read       bool // Reads of audit data must state a purpose of access
+}
+
+var methodPolicies = map[string]methodPolicy{
+	auditv1.AuditService_RecordEvent_FullMethodName:  {permission: auth.PermissionWriteEvents},
+	auditv1.AuditService_RecordEvents_FullMethodName: {permission: auth.PermissionWriteEvents},
+	auditv1.AuditService_QueryEvents_FullMethodName:  {permission: auth.PermissionReadEvents, read: true},
+	auditv1.AuditService_VerifyEvent_FullMethodName:  {permission: auth.PermissionVerifyLedger, read: true},
+}
+
+// authorizer authenticates each call as AuthMiddleware and PurposeMiddleware do for
+// HTTP, and checks the RPC's permission. Methods without a policy, such as reflection,
+// only require authentication.
+type authorizer struct {
+	authenticator *auth.Authenticator
+	policy        *auth.Policy
+	purposes      *auth.PurposePolicy
+}
+
+func (a *authorizer) authorize(ctx context.Context, fullMethod string) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	creds := auth.Credentials{
+		Authorization: first(md, authorizationKey),
+		APIKey:        first(md, apiKeyKey),
+	}
+	p, hasPeer := peer.FromContext(ctx)
+	if hasPeer {
+		if info, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+			creds.TLS = &info.State
+		}
+	}
+
+	id, err := a.authenticator.AuthenticateCredentials(creds)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "unauthenticated")
+	}
+	id.Role = a.policy.ResolveRole(id.Roles)
+	if hasPeer {
+		id.IPAddress = peerIP(p.Addr)
+	}
+
+	mp, ok := methodPolicies[fullMethod]
+	if ok && !a.policy.Allows(id.Role, mp.permission) {
+		return nil, status.Error(codes.PermissionDenied, "role may not "+string(mp.permission))
+	}
+	if ok && mp.read {
+		purpose, err := a.purposes.Resolve(first(md, purposeKey), id.Role)
+		if errors.Is(err, auth.ErrPurposeRequired) {
+			return nil, status.Error(codes.PermissionDenied, "an "+purposeKey+" entry with a case or ticket reference is required")
+		}
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		id.Purpose = purpose
+	}
+	return auth.WithIdentity(ctx, id), nil
+}
+
+// UnaryInterceptor authorizes unary calls
+func (a *authorizer) UnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, err := a.authorize(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// StreamInterceptor authorizes streaming calls
+func (a *authorizer) StreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := a.authorize(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &identityStream{ServerStream: ss, ctx: ctx})
+}
+
+// identityStream carries the authorized context into stream handlers
+type identityStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *identityStream) Context() context.Context {
+	return s.ctx
+}
+
+func first(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func peerIP(addr net.Addr) string {
+	if addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return strings.Trim(addr.String(), "[]")
+	}
+	return host
+}
@@ -0,0 +1,188 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/banking/audit-compliance/internal/auth"
+	"github.com/banking/audit-compliance/internal/domain"
+	"github.com/banking/audit-compliance/internal/service"
+	auditv1 "github.com/banking/audit-compliance/proto/audit/v1"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+)
+
+// queryBatchSize is how many events QueryEvents reads from the ledger per page
+const queryBatchSize = 500
+
+// auditService is the part of service.AuditService the gRPC API is backed by
+type auditService interface {
+	ProcessAndStoreEvent(ctx context.Context, event *domain.AuditEvent) error
+	GetAuditTrail(ctx context.Context, filter domain.AuditEventFilter) (*domain.AuditEventPage, error)
+	VerifyEventIntegrity(ctx context.Context, eventID uuid.UUID) (*domain.AuditEvent, error)
+}
+
+// Server implements the gRPC AuditService. Writes are acknowledged only once the event
+// is committed to the ledger; reads are scoped and redacted by the caller's role.
+type Server struct {
+	auditv1.UnimplementedAuditServiceServer
+	auditService auditService
+	policy       *auth.Policy
+	logger       *zap.Logger
+}
+
+func NewServer(auditService *service.AuditService, policy *auth.Policy, logger *zap.Logger) *Server {
+	return &Server{
+		auditService: auditService,
+		policy:       policy,
+		logger:       logger,
+	}
+}
+
+// NewGRPCServer returns a gRPC server exposing srv and reflection, with every call
+// authenticated and authorized
+func NewGRPCServer(srv *Server, authenticator *auth.Authenticator, purposes *auth.PurposePolicy, opts ...grpc.ServerOption) *grpc.Server {
+	a := &authorizer{authenticator: authenticator, policy: srv.policy, purposes: purposes}
+	opts = append(opts,
+		grpc.ChainUnaryInterceptor(a.UnaryInterceptor),
+		grpc.ChainStreamInterceptor(a.StreamInterceptor),
+	)
+	s := grpc.NewServer(opts...)
+	auditv1.RegisterAuditServiceServer(s, srv)
+	reflection.Register(s)
+	return s
+}
+
+// RecordEvent persists one event synchronously
+func (s *Server) RecordEvent(ctx context.Context, req *auditv1.RecordEventRequest) (*auditv1.RecordEventResponse, error) {
+	event, err := s.record(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return receipt(event), nil
+}
+
+// RecordEvents persists a client stream of events in arrival order
+func (s *Server) RecordEvents(stream grpc.ClientStreamingServer[auditv1.RecordEventRequest, auditv1.RecordEventsResponse]) error {
+	resp := &auditv1.RecordEventsResponse{}
+	for i := 0; ; i++ {
+		req, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return stream.SendAndClose(resp)
+		}
+		if err != nil {
+			return err
+		}
+
+		event, err := s.record(stream.Context(), req)
+		if err != nil {
+			st := status.Convert(err)
+			return status.Errorf(st.Code(), "event %d not recorded (%d recorded before it): %s", i, len(resp.Events), st.Message())
+		}
+		resp.Events = append(resp.Events, receipt(event))
+	}
+}
+
+func (s *Server) record(ctx context.Context, req *auditv1.RecordEventRequest) (*domain.AuditEvent, error) {
+	event, err := eventFromRequest(req)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	id, _ := auth.FromContext(ctx)
+	if event.ServiceSource == "" {
+		event.ServiceSource = id.Subject
+	}
+
+	if err := s.auditService.ProcessAndStoreEvent(ctx, event); err != nil {
+		s.logger.Error("Failed to record audit event over gRPC",
+			zap.String("event_id", event.EventID.String()),
+			zap.String("caller", id.Subject),
+			zap.Error(err),
+		)
+		return nil, status.Error(codes.Internal, "failed to record event")
+	}
+	return event, nil
+}
+
+// QueryEvents streams matching events page by page, newest first
+func (s *Server) QueryEvents(req *auditv1.QueryEventsRequest, stream grpc.ServerStreamingServer[auditv1.AuditEvent]) error {
+	ctx := stream.Context()
+	filter, err := filterFromRequest(req)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	id, _ := auth.FromContext(ctx)
+	if err := s.policy.ScopeFilter(id.Role, &filter); err != nil {
+		return status.Error(codes.PermissionDenied, err.Error())
+	}
+
+	remaining := int(req.GetLimit())
+	for {
+		filter.Limit = queryBatchSize
+		if remaining > 0 && remaining < queryBatchSize {
+			filter.Limit = remaining
+		}
+
+		page, err := s.auditService.GetAuditTrail(ctx, filter)
+		if err != nil {
+			return queryError(err)
+		}
+		for _, event := range page.Events {
+			if err := stream.Send(eventToProto(s.policy.Redact(id.Role, event))); err != nil {
+				return err
+			}
+		}
+
+		if req.GetLimit() > 0 {
+			if remaining -= len(page.Events); remaining <= 0 {
+				return nil
+			}
+		}
+		if !page.HasMore || len(page.Events) == 0 {
+			return nil
+		}
+		cursor := domain.CursorAfter(page.Events[len(page.Events)-1])
+		filter.Cursor = &cursor
+	}
+}
+
+// VerifyEvent checks one event's signature and chain hash
+func (s *Server) VerifyEvent(ctx context.Context, req *auditv1.VerifyEventRequest) (*auditv1.VerifyEventResponse, error) {
+	eventID, err := uuid.Parse(req.GetEventId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid event_id")
+	}
+
+	event, err := s.auditService.VerifyEventIntegrity(ctx, eventID)
+	if err != nil && !errors.Is(err, service.ErrIntegrityFailure) {
+		return nil, queryError(err)
+	}
+	// Out-of-scope events are reported as missing, tampered or not, so their existence
+	// is not disclosed
+	id, _ := auth.FromContext(ctx)
+	if event == nil || !s.policy.CanAccessResource(id.Role, event.ResourceType) {
+		return nil, status.Error(codes.NotFound, "event not found")
+	}
+	return &auditv1.VerifyEventResponse{EventId: eventID.String(), Valid: err == nil}, nil
+}
+
+// queryError maps read errors to gRPC statuses
+func queryError(err error) error {
+	switch {
+	case errors.Is(err, service.ErrEventNotFound):
+		return status.Error(codes.NotFound, "event not found")
+	case errors.Is(err, service.ErrInvalidFilter):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, service.ErrIntegrityFailure):
+		return status.Error(codes.DataLoss, err.Error())
+	case errors.Is(err, auth.ErrNoIdentity):
+		return status.Error(codes.Unauthenticated, "unauthenticated")
+	default:
+		return status.Error(codes.Internal, "failed to query events")
+	}
+}
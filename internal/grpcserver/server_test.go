@@ -0,0 +1,276 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/banking/audit-compliance/internal/auth"
+	"github.com/banking/audit-compliance/internal/config"
+	"github.com/banking/audit-compliance/internal/domain"
+	"github.com/banking/audit-compliance/internal/service"
+	auditv1 "github.com/banking/audit-compliance/proto/audit/v1"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// fakeAuditService keeps events in memory, newest last
+type fakeAuditService struct {
+	mu       sync.Mutex
+	events   []*domain.AuditEvent
+	tampered map[uuid.UUID]bool
+}
+
+func (f *fakeAuditService) ProcessAndStoreEvent(_ context.Context, event *domain.AuditEvent) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if event.EventID == uuid.Nil {
+		event.EventID = uuid.New()
+	}
+	event.SequenceNum = int64(len(f.events) + 1)
+	event.RecordHash = fmt.Sprintf("hash-%d", event.SequenceNum)
+	f.events = append(f.events, event)
+	return nil
+}
+
+func (f *fakeAuditService) GetAuditTrail(_ context.Context, filter domain.AuditEventFilter) (*domain.AuditEventPage, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	start := 0
+	if filter.Cursor != nil {
+		for i, e := range f.events {
+			if e.EventID == filter.Cursor.EventID {
+				start = i + 1
+			}
+		}
+	}
+	end := start + filter.Limit
+	if end > len(f.events) {
+		end = len(f.events)
+	}
+	return &domain.AuditEventPage{Events: f.events[start:end], PageSize: filter.Limit, HasMore: end < len(f.events)}, nil
+}
+
+func (f *fakeAuditService) VerifyEventIntegrity(_ context.Context, eventID uuid.UUID) (*domain.AuditEvent, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, e := range f.events {
+		if e.EventID != eventID {
+			continue
+		}
+		if f.tampered[eventID] {
+			return e, fmt.Errorf("%w: event %s signature invalid", service.ErrIntegrityFailure, eventID)
+		}
+		return e, nil
+	}
+	return nil, service.ErrEventNotFound
+}
+
+// newTestClient serves a Server backed by fake over an in-memory connection. Callers
+// authenticate with the API keys "service-key" (SERVICE), "auditor-key" (AUDITOR) and
+// "account-key" (ACCOUNT_VERIFIER, scoped to ACCOUNT events).
+func newTestClient(t *testing.T, fake *fakeAuditService) auditv1.AuditServiceClient {
+	t.Helper()
+	cfg, err := config.Load()
+	require.NoError(t, err)
+	cfg.Auth.RBAC.Roles["ACCOUNT_VERIFIER"] = config.RoleConfig{Permissions: []string{"integrity:verify"}, ResourceTypes: []string{"ACCOUNT"}}
+	policy, err := auth.NewPolicy(cfg.Auth.RBAC)
+	require.NoError(t, err)
+	authenticator, err := auth.NewAuthenticator(config.AuthConfig{ServiceAPIKeys: []config.ServiceAPIKeyConfig{
+		{ID: "svc", SHA256: auth.HashAPIKey("service-key"), Subject: "payments-service", Roles: []string{"SERVICE"}},
+		{ID: "aud", SHA256: auth.HashAPIKey("auditor-key"), Subject: "auditor", Roles: []string{"AUDITOR"}},
+		{ID: "acc", SHA256: auth.HashAPIKey("account-key"), Subject: "account-verifier", Roles: []string{"ACCOUNT_VERIFIER"}},
+	}}, nil)
+	require.NoError(t, err)
+
+	srv := &Server{auditService: fake, policy: policy, logger: zap.NewNop()}
+	s := NewGRPCServer(srv, authenticator, auth.NewPurposePolicy(cfg.Auth.Purpose))
+	lis := bufconn.Listen(1 << 20)
+	go func() { _ = s.Serve(lis) }()
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return auditv1.NewAuditServiceClient(conn)
+}
+
+func withKey(key string, pairs ...string) context.Context {
+	return metadata.NewOutgoingContext(context.Background(), metadata.Pairs(append([]string{apiKeyKey, key}, pairs...)...))
+}
+
+func eventRequest() *auditv1.RecordEventRequest {
+	return &auditv1.RecordEventRequest{
+		UserId:       uuid.NewString(),
+		ActionType:   "transfer",
+		ResourceType: "TRANSFER",
+		ResourceId:   "tr-1",
+		IpAddress:    "10.0.0.7",
+		Metadata:     []byte(`{"amount_cents":1200}`),
+	}
+}
+
+func TestRecordEvent(t *testing.T) {
+	fake := &fakeAuditService{}
+	client := newTestClient(t, fake)
+
+	resp, err := client.RecordEvent(withKey("service-key"), eventRequest())
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), resp.SequenceNum)
+	assert.Equal(t, "hash-1", resp.RecordHash)
+	require.Len(t, fake.events, 1)
+	assert.Equal(t, domain.ActionTypeTransfer, fake.events[0].ActionType)
+	assert.Equal(t, domain.AuditResultSuccess, fake.events[0].Result)
+	assert.Equal(t, "payments-service", fake.events[0].ServiceSource, "source defaults to the caller")
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		req  *auditv1.RecordEventRequest
+		code codes.Code
+	}{
+		{"no credentials", context.Background(), eventRequest(), codes.Unauthenticated},
+		{"unknown key", withKey("guess"), eventRequest(), codes.Unauthenticated},
+		{"role without write", withKey("auditor-key"), eventRequest(), codes.PermissionDenied},
+		{"unknown action type", withKey("service-key"), func() *auditv1.RecordEventRequest {
+			r := eventRequest()
+			r.ActionType = "DANCE"
+			return r
+		}(), codes.InvalidArgument},
+		{"metadata not an object", withKey("service-key"), func() *auditv1.RecordEventRequest {
+			r := eventRequest()
+			r.Metadata = []byte(`[1,2]`)
+			return r
+		}(), codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := client.RecordEvent(tt.ctx, tt.req)
+			assert.Equal(t, tt.code, status.Code(err))
+		})
+	}
+	assert.Len(t, fake.events, 1, "rejected events are not stored")
+}
+
+func TestRecordEventsStream(t *testing.T) {
+	fake := &fakeAuditService{}
+	client := newTestClient(t, fake)
+
+	stream, err := client.RecordEvents(withKey("service-key"))
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		require.NoError(t, stream.Send(eventRequest()))
+	}
+	resp, err := stream.CloseAndRecv()
+	require.NoError(t, err)
+	require.Len(t, resp.Events, 3)
+	assert.Equal(t, int64(3), resp.Events[2].SequenceNum)
+
+	// A bad event stops the stream and reports how far it got
+	stream, err = client.RecordEvents(withKey("service-key"))
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(eventRequest()))
+	bad := eventRequest()
+	bad.UserId = "nope"
+	require.NoError(t, stream.Send(bad))
+	_, err = stream.CloseAndRecv()
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Contains(t, status.Convert(err).Message(), "event 1 not recorded (1 recorded before it)")
+	assert.Len(t, fake.events, 4)
+}
+
+func TestQueryEvents(t *testing.T) {
+	fake := &fakeAuditService{}
+	for i := 0; i < queryBatchSize+20; i++ {
+		require.NoError(t, fake.ProcessAndStoreEvent(context.Background(), &domain.AuditEvent{
+			UserID: uuid.New(), ActionType: domain.ActionTypeRead, ResourceType: domain.ResourceTypeAccount, IPAddress: "10.0.0.7",
+		}))
+	}
+	client := newTestClient(t, fake)
+
+	collect := func(ctx context.Context, req *auditv1.QueryEventsRequest) ([]*auditv1.AuditEvent, error) {
+		stream, err := client.QueryEvents(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		var events []*auditv1.AuditEvent
+		for {
+			event, err := stream.Recv()
+			if errors.Is(err, io.EOF) {
+				return events, nil
+			}
+			if err != nil {
+				return events, err
+			}
+			events = append(events, event)
+		}
+	}
+
+	t.Run("streams every page", func(t *testing.T) {
+		events, err := collect(withKey("auditor-key", purposeKey, "CASE:FRAUD-1"), &auditv1.QueryEventsRequest{})
+		require.NoError(t, err)
+		assert.Len(t, events, queryBatchSize+20)
+		assert.Equal(t, auth.RedactedValue, events[0].IpAddress, "auditors see redacted IPs")
+	})
+
+	t.Run("limit", func(t *testing.T) {
+		events, err := collect(withKey("auditor-key", purposeKey, "CASE:FRAUD-1"), &auditv1.QueryEventsRequest{Limit: 7})
+		require.NoError(t, err)
+		assert.Len(t, events, 7)
+	})
+
+	t.Run("purpose required", func(t *testing.T) {
+		_, err := collect(withKey("auditor-key"), &auditv1.QueryEventsRequest{})
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	})
+
+	t.Run("invalid filter", func(t *testing.T) {
+		_, err := collect(withKey("auditor-key", purposeKey, "CASE:FRAUD-1"), &auditv1.QueryEventsRequest{ActionTypes: []string{"DANCE"}})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
+
+func TestVerifyEvent(t *testing.T) {
+	fake := &fakeAuditService{tampered: map[uuid.UUID]bool{}}
+	client := newTestClient(t, fake)
+
+	good, err := client.RecordEvent(withKey("service-key"), eventRequest())
+	require.NoError(t, err)
+	bad, err := client.RecordEvent(withKey("service-key"), eventRequest())
+	require.NoError(t, err)
+	fake.tampered[uuid.MustParse(bad.EventId)] = true
+
+	ctx := withKey("auditor-key", purposeKey, "AUDIT:Q3-2026")
+	resp, err := client.VerifyEvent(ctx, &auditv1.VerifyEventRequest{EventId: good.EventId})
+	require.NoError(t, err)
+	assert.True(t, resp.Valid)
+
+	resp, err = client.VerifyEvent(ctx, &auditv1.VerifyEventRequest{EventId: bad.EventId})
+	require.NoError(t, err)
+	assert.False(t, resp.Valid)
+
+	_, err = client.VerifyEvent(ctx, &auditv1.VerifyEventRequest{EventId: uuid.NewString()})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	scoped := withKey("account-key", purposeKey, "AUDIT:Q3-2026")
+	for _, eventID := range []string{good.EventId, bad.EventId} {
+		_, err = client.VerifyEvent(scoped, &auditv1.VerifyEventRequest{EventId: eventID})
+		assert.Equal(t, codes.NotFound, status.Code(err), "An out-of-scope event is missing whether or not it verifies")
+	}
+}
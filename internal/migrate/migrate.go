@@ -0,0 +1,243 @@
+// Package migrate applies the numbered SQL migrations embedded in package migrations.
+// Applied versions are recorded in schema_migrations with a checksum of the file, so an
+// edited migration is detected instead of silently diverging between environments.
+package migrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+// migrationLockKey is the session advisory lock that keeps concurrent runners apart
+const migrationLockKey int64 = 0x6d69677261746520 // "migrate "
+
+var fileNamePattern = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.sql$`)
+
+var (
+	// ErrChecksumMismatch is returned when an applied migration file has been edited
+	ErrChecksumMismatch = errors.New("applied migration has been modified")
+	// ErrUnknownMigration is returned when the database records a version with no file
+	ErrUnknownMigration = errors.New("database has a migration unknown to this build")
+)
+
+// Migration is one numbered SQL file
+type Migration struct {
+	Version  int
+	Name     string
+	SQL      string
+	Checksum string // Hex SHA-256 of the file
+}
+
+// AppliedMigration is a row of schema_migrations
+type AppliedMigration struct {
+	Version   int
+	Name      string
+	Checksum  string
+	AppliedAt time.Time
+}
+
+// Load reads and orders every NNN_name.sql file in fsys
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list migrations: %w", err)
+	}
+
+	var migrations []Migration
+	seen := map[int]string{}
+	for _, entry := range entries {
+		if entry.IsDir() || path.Ext(entry.Name()) != ".sql" {
+			continue
+		}
+		m := fileNamePattern.FindStringSubmatch(entry.Name())
+		if m == nil {
+			return nil, fmt.Errorf("migration %q must be named NNN_description.sql", entry.Name())
+		}
+		version, _ := strconv.Atoi(m[1])
+		if version <= 0 {
+			return nil, fmt.Errorf("migration %q has a non-positive version", entry.Name())
+		}
+		if other, ok := seen[version]; ok {
+			return nil, fmt.Errorf("migrations %q and %q share version %d", other, entry.Name(), version)
+		}
+		seen[version] = entry.Name()
+
+		data, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %q: %w", entry.Name(), err)
+		}
+		sum := sha256.Sum256(data)
+		migrations = append(migrations, Migration{
+			Version:  version,
+			Name:     m[2],
+			SQL:      string(data),
+			Checksum: hex.EncodeToString(sum[:]),
+		})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Plan checks applied migrations against the files and returns the ones still pending.
+// Every applied version must exist with an identical checksum.
+func Plan(migrations []Migration, applied []AppliedMigration) ([]Migration, error) {
+	byVersion := make(map[int]Migration, len(migrations))
+	for _, m := range migrations {
+		byVersion[m.Version] = m
+	}
+
+	done := make(map[int]bool, len(applied))
+	for _, a := range applied {
+		m, ok := byVersion[a.Version]
+		if !ok {
+			return nil, fmt.Errorf("%w: version %d (%s)", ErrUnknownMigration, a.Version, a.Name)
+		}
+		if m.Checksum != a.Checksum {
+			return nil, fmt.Errorf("%w: version %d (%s) applied with checksum %s, file is %s",
+				ErrChecksumMismatch, a.Version, a.Name, a.Checksum, m.Checksum)
+		}
+		done[a.Version] = true
+	}
+
+	var pending []Migration
+	for _, m := range migrations {
+		if !done[m.Version] {
+			pending = append(pending, m)
+		}
+	}
+	return pending, nil
+}
+
+// Hook runs Go code in a migration's transaction, after its SQL, for steps SQL cannot
+// express. It must do nothing on a database that needs nothing done.
+type Hook func(ctx context.Context, tx pgx.Tx) error
+
+// Runner applies migrations with the schema owner's connection
+type Runner struct {
+	pool       *pgxpool.Pool
+	migrations []Migration
+	hooks      map[int]Hook
+	logger     *zap.Logger
+}
+
+// NewRunner creates a migration runner. pool must connect as the role that owns the schema.
+func NewRunner(pool *pgxpool.Pool, migrations []Migration, logger *zap.Logger) *Runner {
+	return &Runner{
+		pool:       pool,
+		migrations: migrations,
+		hooks:      map[int]Hook{},
+		logger:     logger,
+	}
+}
+
+// AfterMigration runs hook in the transaction of migration version, after its SQL
+func (r *Runner) AfterMigration(version int, hook Hook) *Runner {
+	r.hooks[version] = hook
+	return r
+}
+
+// Up applies every pending migration in order, each in its own transaction, and returns
+// what was applied. With dryRun set it only verifies checksums and returns what would run.
+func (r *Runner) Up(ctx context.Context, dryRun bool) ([]Migration, error) {
+	conn, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, `SELECT pg_advisory_lock($1)`, migrationLockKey); err != nil {
+		return nil, fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	defer func() {
+		// Unlock on a fresh context so a cancelled ctx cannot leave the session lock held
+		if _, err := conn.Exec(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationLockKey); err != nil {
+			r.logger.Error("Failed to release migration lock", zap.Error(err))
+		}
+	}()
+
+	if _, err := conn.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INT PRIMARY KEY,
+			name TEXT NOT NULL,
+			checksum CHAR(64) NOT NULL,
+			applied_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+		)`); err != nil {
+		return nil, fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	rows, err := conn.Query(ctx, `SELECT version, name, checksum, applied_at FROM schema_migrations ORDER BY version`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+	var applied []AppliedMigration
+	for rows.Next() {
+		var a AppliedMigration
+		if err := rows.Scan(&a.Version, &a.Name, &a.Checksum, &a.AppliedAt); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan schema_migrations: %w", err)
+		}
+		applied = append(applied, a)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+
+	pending, err := Plan(r.migrations, applied)
+	if err != nil {
+		return nil, err
+	}
+	if dryRun {
+		return pending, nil
+	}
+
+	for i, m := range pending {
+		started := time.Now()
+		tx, err := conn.Begin(ctx)
+		if err != nil {
+			return pending[:i], fmt.Errorf("failed to begin migration %d: %w", m.Version, err)
+		}
+		if _, err := tx.Exec(ctx, m.SQL); err != nil {
+			_ = tx.Rollback(ctx)
+			return pending[:i], fmt.Errorf("migration %d (%s) failed: %w", m.Version, m.Name, err)
+		}
+		if hook, ok := r.hooks[m.Version]; ok {
+			if err := hook(ctx, tx); err != nil {
+				_ = tx.Rollback(ctx)
+				return pending[:i], fmt.Errorf("migration %d (%s) failed: %w", m.Version, m.Name, err)
+			}
+		}
+		if _, err := tx.Exec(ctx,
+			`INSERT INTO schema_migrations (version, name, checksum) VALUES ($1, $2, $3)`,
+			m.Version, m.Name, m.Checksum,
+		); err != nil {
+			_ = tx.Rollback(ctx)
+			return pending[:i], fmt.Errorf("failed to record migration %d: %w", m.Version, err)
+		}
+		if err := tx.Commit(ctx); err != nil {
+			return pending[:i], fmt.Errorf("failed to commit migration %d: %w", m.Version, err)
+		}
+
+		r.logger.Info("Applied migration",
+			zap.Int("version", m.Version),
+			zap.String("name", m.Name),
+			zap.Duration("duration", time.Since(started)),
+		)
+	}
+
+	return pending, nil
+}
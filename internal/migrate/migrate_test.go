@@ -0,0 +1,87 @@
+package migrate_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/banking/audit-compliance/internal/migrate"
+	"github.com/banking/audit-compliance/migrations"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadOrdersByVersion(t *testing.T) {
+	fsys := fstest.MapFS{
+		"010_later.sql":  {Data: []byte("SELECT 10;")},
+		"002_second.sql": {Data: []byte("SELECT 2;")},
+		"001_first.sql":  {Data: []byte("SELECT 1;")},
+		"README.md":      {Data: []byte("ignored")},
+	}
+
+	loaded, err := migrate.Load(fsys)
+	require.NoError(t, err)
+	require.Len(t, loaded, 3)
+	assert.Equal(t, []int{1, 2, 10}, []int{loaded[0].Version, loaded[1].Version, loaded[2].Version})
+	assert.Equal(t, "first", loaded[0].Name)
+	assert.Len(t, loaded[0].Checksum, 64)
+}
+
+func TestLoadRejectsBadFiles(t *testing.T) {
+	tests := map[string]fstest.MapFS{
+		"unnumbered":        {"init.sql": {Data: []byte("SELECT 1;")}},
+		"duplicate version": {"001_a.sql": {Data: []byte("SELECT 1;")}, "1_b.sql": {Data: []byte("SELECT 1;")}},
+		"zero version":      {"000_zero.sql": {Data: []byte("SELECT 1;")}},
+	}
+	for name, fsys := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := migrate.Load(fsys)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestPlan(t *testing.T) {
+	loaded, err := migrate.Load(fstest.MapFS{
+		"001_first.sql":  {Data: []byte("SELECT 1;")},
+		"002_second.sql": {Data: []byte("SELECT 2;")},
+	})
+	require.NoError(t, err)
+	first, second := loaded[0], loaded[1]
+
+	t.Run("fresh database", func(t *testing.T) {
+		pending, err := migrate.Plan(loaded, nil)
+		require.NoError(t, err)
+		assert.Equal(t, loaded, pending)
+	})
+
+	t.Run("partially applied", func(t *testing.T) {
+		pending, err := migrate.Plan(loaded, []migrate.AppliedMigration{
+			{Version: 1, Name: first.Name, Checksum: first.Checksum},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []migrate.Migration{second}, pending)
+	})
+
+	t.Run("edited after apply", func(t *testing.T) {
+		_, err := migrate.Plan(loaded, []migrate.AppliedMigration{
+			{Version: 1, Name: first.Name, Checksum: second.Checksum},
+		})
+		assert.ErrorIs(t, err, migrate.ErrChecksumMismatch)
+	})
+
+	t.Run("unknown applied version", func(t *testing.T) {
+		_, err := migrate.Plan(loaded, []migrate.AppliedMigration{
+			{Version: 7, Name: "from_a_newer_build"},
+		})
+		assert.ErrorIs(t, err, migrate.ErrUnknownMigration)
+	})
+}
+
+func TestEmbeddedMigrationsAreContiguous(t *testing.T) {
+	loaded, err := migrate.Load(migrations.FS)
+	require.NoError(t, err)
+	require.NotEmpty(t, loaded)
+	for i, m := range loaded {
+		assert.Equal(t, i+1, m.Version, "migration %s breaks the version sequence", m.Name)
+	}
+}
@@ -148,7 +148,7 @@ func (r *SearchRepository) SearchEvents(ctx context.Context, query string, from,
 
 	return &domain.AuditEventPage{
 		Events:     events,
-		TotalCount: total,
+		TotalCount: &total,
 		Page:       from/size + 1,
 		PageSize:   size,
 		HasMore:    total > int64(from+size),
@@ -24,19 +24,92 @@ func NewAccessLogRepository(pool *pgxpool.Pool) *AccessLogRepository {
 func (r *AccessLogRepository) LogAccess(ctx context.Context, entry *domain.AuditAccessLog) error {
 	const query = `
 		INSERT INTO access_logs (
-			access_id, accessor_id, accessor_role, access_type, 
-			query_filter, records_viewed, ip_address, timestamp, purpose
+			access_id, accessor_id, accessor_subject, accessor_role, access_type, 
+			query_filter, records_viewed, ip_address, timestamp, purpose,
+			purpose_code, purpose_reference
 		) VALUES (
-			$1, $2, $3, $4, 
-			$5, $6, $7, $8, $9
+			$1, $2, $3, $4, $5, 
+			$6, $7, $8, $9, $10,
+			NULLIF($11, ''), NULLIF($12, '')
 		)
 	`
 	_, err := r.pool.Exec(ctx, query,
-		entry.AccessID, entry.AccessorID, entry.AccessorRole, entry.AccessType,
+		entry.AccessID, entry.AccessorID, entry.AccessorSubject, entry.AccessorRole, entry.AccessType,
 		entry.QueryFilter, entry.RecordsViewed, entry.IPAddress, entry.Timestamp, entry.Purpose,
+		entry.PurposeCode, entry.PurposeReference,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to insert access log: %w", err)
 	}
 	return nil
 }
+
+// SearchAccessLogs returns access log entries matching filter, newest first
+func (r *AccessLogRepository) SearchAccessLogs(ctx context.Context, filter domain.AccessLogFilter) ([]*domain.AuditAccessLog, error) {
+	query := `
+		SELECT access_id, accessor_id, COALESCE(accessor_subject, ''), COALESCE(accessor_role, ''), access_type,
+		       COALESCE(query_filter, ''), COALESCE(records_viewed, 0), COALESCE(ip_address, ''), timestamp,
+		       COALESCE(purpose, ''), COALESCE(purpose_code, ''), COALESCE(purpose_reference, '')
+		FROM access_logs
+		WHERE 1=1
+	`
+	args := []interface{}{}
+	argIdx := 1
+
+	if filter.AccessorID != nil {
+		query += fmt.Sprintf(" AND accessor_id = $%d", argIdx)
+		args = append(args, *filter.AccessorID)
+		argIdx++
+	}
+	if filter.AccessType != nil {
+		query += fmt.Sprintf(" AND access_type = $%d", argIdx)
+		args = append(args, *filter.AccessType)
+		argIdx++
+	}
+	if filter.PurposeCode != nil {
+		query += fmt.Sprintf(" AND purpose_code = $%d", argIdx)
+		args = append(args, *filter.PurposeCode)
+		argIdx++
+	}
+	if filter.PurposeReference != nil {
+		query += fmt.Sprintf(" AND purpose_reference = $%d", argIdx)
+		args = append(args, *filter.PurposeReference)
+		argIdx++
+	}
+	if filter.StartTime != nil {
+		query += fmt.Sprintf(" AND timestamp >= $%d", argIdx)
+		args = append(args, *filter.StartTime)
+		argIdx++
+	}
+	if filter.EndTime != nil {
+		query += fmt.Sprintf(" AND timestamp <= $%d", argIdx)
+		args = append(args, *filter.EndTime)
+		argIdx++
+	}
+
+	query += fmt.Sprintf(" ORDER BY timestamp DESC LIMIT $%d", argIdx)
+	args = append(args, filter.Limit)
+
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query access logs: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []*domain.AuditAccessLog
+	for rows.Next() {
+		var e domain.AuditAccessLog
+		if err := rows.Scan(
+			&e.AccessID, &e.AccessorID, &e.AccessorSubject, &e.AccessorRole, &e.AccessType,
+			&e.QueryFilter, &e.RecordsViewed, &e.IPAddress, &e.Timestamp,
+			&e.Purpose, &e.PurposeCode, &e.PurposeReference,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan access log: %w", err)
+		}
+		entries = append(entries, &e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate access logs: %w", err)
+	}
+	return entries, nil
+}
@@ -2,16 +2,30 @@ package postgres
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/banking/audit-compliance/internal/config"
 	"github.com/banking/audit-compliance/internal/crypto"
 	"github.com/banking/audit-compliance/internal/domain"
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// ErrInvalidFilter is returned when Postgres rejects a filter value, e.g. a malformed JSONPath
+var ErrInvalidFilter = errors.New("invalid event filter")
+
+// ErrDuplicateEvent is returned when an event with the same ID is already in the ledger
+var ErrDuplicateEvent = errors.New("event already recorded")
+
+// defaultPageSize applies when a filter sets no limit
+const defaultPageSize = 100
+
 // AuditRepository implements repository for audit events
 type AuditRepository struct {
 	pool      *pgxpool.Pool
@@ -41,54 +55,207 @@ func NewAuditRepository(cfg config.DatabaseConfig, encryptor *crypto.FieldEncryp
 	}, nil
 }
 
-// CreateEvent inserts a new audit event. This is an APPEND-ONLY operation.
-// No Updates or Deletes are ever performed on this table.
-func (r *AuditRepository) CreateEvent(ctx context.Context, event *domain.AuditEvent) error {
-	const query = `
-		INSERT INTO audit_events (
+// ledgerChainLockKey is the transaction-scoped advisory lock that serializes chain appends
+const ledgerChainLockKey int64 = 0x6175646974 // "audit"
+
+// auditEventColumns lists every persisted column in scan order
+const auditEventColumns = `
 			event_id, transaction_id, user_id, actor_id, action_type, 
 			resource_type, resource_id, service_source, timestamp, result,
 			failure_reason, ip_address, geolocation, user_agent, request_id, 
 			session_id, digital_signature, metadata, data_before, data_after,
-			compliance_flags, retention_category, encryption_key_id, created_at
+			compliance_flags, retention_category, encryption_key_id, created_at,
+			sequence_num, prev_hash, record_hash, signature_version,
+			signature_algorithm, signing_key_id`
+
+// SealFunc signs an event once the ledger has assigned its CreatedAt, before its record
+// hash is computed
+type SealFunc func(event *domain.AuditEvent) error
+
+// CreateEvent appends a new audit event to the hash chain. This is an APPEND-ONLY operation.
+// No Updates or Deletes are ever performed on this table.
+//
+// Appends are serialized with an advisory lock so every event links to exactly one
+// predecessor. CreatedAt, SequenceNum, PrevHash and RecordHash are assigned on the event
+// under the lock, so created_at follows sequence order, and seal is called in between.
+// An event whose ID is already in the ledger returns ErrDuplicateEvent.
+func (r *AuditRepository) CreateEvent(ctx context.Context, event *domain.AuditEvent, seal SealFunc) error {
+	const query = `
+		INSERT INTO audit_events (` + auditEventColumns + `
 		) VALUES (
 			$1, $2, $3, $4, $5, 
 			$6, $7, $8, $9, $10,
 			$11, $12, $13, $14, $15,
 			$16, $17, $18, $19, $20,
-			$21, $22, $23, $24
+			$21, $22, $23, $24,
+			$25, $26, $27, $28,
+			$29, $30
 		)
 	`
-	// Encrypt sensitive data payloads if present
-	// (Assumed already encrypted or we encrypt here? Domain object has []byte, assuming raw or already handled.
-	// To be safe and strict, let's assume the service layer handles the logic of what to encrypt,
-	// but here we just store it. However, the struct has EncryptionKeyID, so maybe we should check.)
 
-	_, err := r.pool.Exec(ctx, query,
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin chain append: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1)`, ledgerChainLockKey); err != nil {
+		return fmt.Errorf("failed to acquire chain lock: %w", err)
+	}
+
+	headSeq, headHash, err := chainHead(ctx, tx)
+	if err != nil {
+		return err
+	}
+	createdAt, err := appendTime(ctx, tx, headSeq)
+	if err != nil {
+		return err
+	}
+
+	event.CreatedAt = createdAt
+	if err := seal(event); err != nil {
+		return err
+	}
+	event.SequenceNum = headSeq + 1
+	event.PrevHash = headHash
+	event.RecordHash = r.encryptor.GenerateHashChain(event.PrevHash, event.CanonicalRecord())
+
+	// The index enforces global uniqueness that the partitioned table cannot. A redelivered
+	// event ID appends nothing and is left for the caller to compare.
+	tag, err := tx.Exec(ctx, `
+		INSERT INTO audit_event_index (sequence_num, event_id, record_hash, timestamp)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (event_id) DO NOTHING
+	`, event.SequenceNum, event.EventID, event.RecordHash, event.Timestamp)
+	if err != nil {
+		return fmt.Errorf("failed to index audit event: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%w: %s", ErrDuplicateEvent, event.EventID)
+	}
+
+	_, err = tx.Exec(ctx, query, eventValues(event)...)
+
+	if err != nil {
+		return fmt.Errorf("failed to insert audit event: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit chain append: %w", err)
+	}
+
+	return nil
+}
+
+// eventValues returns the persisted columns of event in auditEventColumns order
+func eventValues(event *domain.AuditEvent) []interface{} {
+	return []interface{}{
 		event.EventID, event.TransactionID, event.UserID, event.ActorID, event.ActionType,
 		event.ResourceType, event.ResourceID, event.ServiceSource, event.Timestamp, event.Result,
 		event.FailureReason, event.IPAddress, event.Geolocation, event.UserAgent, event.RequestID,
 		event.SessionID, event.DigitalSignature, event.Metadata, event.DataBefore, event.DataAfter,
 		event.ComplianceFlags, event.RetentionCategory, event.EncryptionKeyID, event.CreatedAt,
-	)
+		event.SequenceNum, event.PrevHash, event.RecordHash, event.SignatureVersion,
+		event.SignatureAlgorithm, event.SigningKeyID,
+	}
+}
+
+// auditEventColumnNames lists auditEventColumns for COPY
+var auditEventColumnNames = strings.Fields(strings.ReplaceAll(auditEventColumns, ",", " "))
+
+// CreateEvents appends events to the hash chain in order, in one transaction holding the
+// chain lock, using COPY for both the index and the ledger. Events whose ID is already in
+// the ledger, or earlier in the batch, are skipped and reported as duplicates; the rest
+// are assigned CreatedAt, sealed and chained exactly as CreateEvent would.
+func (r *AuditRepository) CreateEvents(ctx context.Context, events []*domain.AuditEvent, seal SealFunc) ([]bool, error) {
+	duplicates := make([]bool, len(events))
+	if len(events) == 0 {
+		return duplicates, nil
+	}
 
+	tx, err := r.pool.Begin(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to insert audit event: %w", err)
+		return nil, fmt.Errorf("failed to begin chain append: %w", err)
 	}
+	defer tx.Rollback(ctx)
 
-	return nil
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1)`, ledgerChainLockKey); err != nil {
+		return nil, fmt.Errorf("failed to acquire chain lock: %w", err)
+	}
+
+	// Under the chain lock no other append can add an ID between this check and the COPY
+	ids := make([]uuid.UUID, len(events))
+	for i, event := range events {
+		ids[i] = event.EventID
+	}
+	rows, err := tx.Query(ctx, `SELECT event_id FROM audit_event_index WHERE event_id = ANY($1)`, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check for recorded events: %w", err)
+	}
+	seen, err := pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+	if err != nil {
+		return nil, fmt.Errorf("failed to check for recorded events: %w", err)
+	}
+	recorded := make(map[uuid.UUID]bool, len(events))
+	for _, id := range seen {
+		recorded[id] = true
+	}
+
+	headSeq, headHash, err := chainHead(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	createdAt, err := appendTime(ctx, tx, headSeq)
+	if err != nil {
+		return nil, err
+	}
+
+	var indexRows, eventRows [][]interface{}
+	for i, event := range events {
+		if recorded[event.EventID] {
+			duplicates[i] = true
+			continue
+		}
+		recorded[event.EventID] = true
+
+		event.CreatedAt = createdAt
+		if err := seal(event); err != nil {
+			return nil, err
+		}
+		headSeq++
+		event.SequenceNum = headSeq
+		event.PrevHash = headHash
+		event.RecordHash = r.encryptor.GenerateHashChain(event.PrevHash, event.CanonicalRecord())
+		headHash = event.RecordHash
+
+		indexRows = append(indexRows, []interface{}{event.SequenceNum, event.EventID, event.RecordHash, event.Timestamp})
+		eventRows = append(eventRows, eventValues(event))
+	}
+	if len(eventRows) == 0 {
+		return duplicates, nil
+	}
+
+	_, err = tx.CopyFrom(ctx, pgx.Identifier{"audit_event_index"},
+		[]string{"sequence_num", "event_id", "record_hash", "timestamp"}, pgx.CopyFromRows(indexRows))
+	if err != nil {
+		return nil, fmt.Errorf("failed to index audit events: %w", err)
+	}
+	_, err = tx.CopyFrom(ctx, pgx.Identifier{"audit_events"}, auditEventColumnNames, pgx.CopyFromRows(eventRows))
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert audit events: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit chain append: %w", err)
+	}
+	return duplicates, nil
 }
 
 // GetEvents retrieves audit events based on filter
 func (r *AuditRepository) GetEvents(ctx context.Context, filter domain.AuditEventFilter) (*domain.AuditEventPage, error) {
 	// Build query dynamically
 	query := `
-		SELECT 
-			event_id, transaction_id, user_id, actor_id, action_type, 
-			resource_type, resource_id, service_source, timestamp, result,
-			failure_reason, ip_address, geolocation, user_agent, request_id, 
-			session_id, digital_signature, metadata, data_before, data_after,
-			compliance_flags, retention_category, encryption_key_id, created_at
+		SELECT ` + auditEventColumns + `
 		FROM audit_events
 		WHERE 1=1
 	`
@@ -96,7 +263,8 @@ func (r *AuditRepository) GetEvents(ctx context.Context, filter domain.AuditEven
 	argIdx := 1
 
 	if filter.EventID != nil {
-		query += fmt.Sprintf(" AND event_id = $%d", argIdx)
+		// Resolving the timestamp through the index lets the planner prune to one partition
+		query += fmt.Sprintf(" AND event_id = $%d AND timestamp = (SELECT timestamp FROM audit_event_index WHERE event_id = $%d)", argIdx, argIdx)
 		args = append(args, *filter.EventID)
 		argIdx++
 	}
@@ -105,6 +273,11 @@ func (r *AuditRepository) GetEvents(ctx context.Context, filter domain.AuditEven
 		args = append(args, *filter.UserID)
 		argIdx++
 	}
+	if filter.ActorID != nil {
+		query += fmt.Sprintf(" AND actor_id = $%d", argIdx)
+		args = append(args, *filter.ActorID)
+		argIdx++
+	}
 	if filter.TransactionID != nil {
 		query += fmt.Sprintf(" AND transaction_id = $%d", argIdx)
 		args = append(args, *filter.TransactionID)
@@ -115,6 +288,59 @@ func (r *AuditRepository) GetEvents(ctx context.Context, filter domain.AuditEven
 		args = append(args, *filter.ResourceID)
 		argIdx++
 	}
+	if len(filter.ActionTypes) > 0 {
+		actions := make([]string, len(filter.ActionTypes))
+		for i, a := range filter.ActionTypes {
+			actions[i] = string(a)
+		}
+		query += fmt.Sprintf(" AND action_type = ANY($%d)", argIdx)
+		args = append(args, actions)
+		argIdx++
+	}
+	if len(filter.ResourceTypes) > 0 {
+		resources := make([]string, len(filter.ResourceTypes))
+		for i, rt := range filter.ResourceTypes {
+			resources[i] = string(rt)
+		}
+		query += fmt.Sprintf(" AND resource_type = ANY($%d)", argIdx)
+		args = append(args, resources)
+		argIdx++
+	}
+	if filter.Result != nil {
+		query += fmt.Sprintf(" AND result = $%d", argIdx)
+		args = append(args, string(*filter.Result))
+		argIdx++
+	}
+	if filter.ServiceSource != nil {
+		query += fmt.Sprintf(" AND service_source = $%d", argIdx)
+		args = append(args, *filter.ServiceSource)
+		argIdx++
+	}
+	if filter.IPAddress != nil {
+		query += fmt.Sprintf(" AND ip_address = $%d", argIdx)
+		args = append(args, *filter.IPAddress)
+		argIdx++
+	}
+	if len(filter.ComplianceFlags) > 0 {
+		query += fmt.Sprintf(" AND compliance_flags @> $%d", argIdx)
+		args = append(args, filter.ComplianceFlags)
+		argIdx++
+	}
+	if filter.RetentionCategory != nil {
+		query += fmt.Sprintf(" AND retention_category = $%d", argIdx)
+		args = append(args, *filter.RetentionCategory)
+		argIdx++
+	}
+	if len(filter.MetadataContains) > 0 {
+		query += fmt.Sprintf(" AND metadata @> $%d", argIdx)
+		args = append(args, filter.MetadataContains)
+		argIdx++
+	}
+	if filter.MetadataPath != nil {
+		query += fmt.Sprintf(" AND metadata @@ $%d::jsonpath", argIdx)
+		args = append(args, *filter.MetadataPath)
+		argIdx++
+	}
 	if filter.StartTime != nil {
 		query += fmt.Sprintf(" AND timestamp >= $%d", argIdx)
 		args = append(args, *filter.StartTime)
@@ -126,64 +352,296 @@ func (r *AuditRepository) GetEvents(ctx context.Context, filter domain.AuditEven
 		argIdx++
 	}
 
-	// Count total
-	countQuery := "SELECT COUNT(*) FROM (" + query + ") as total"
-	var totalCount int64
-	err := r.pool.QueryRow(ctx, countQuery, args...).Scan(&totalCount)
-	if err != nil {
-		return nil, fmt.Errorf("failed to count events: %w", err)
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultPageSize
+	}
+	page := &domain.AuditEventPage{PageSize: limit}
+
+	switch filter.Count {
+	case domain.CountExact:
+		var total int64
+		if err := r.pool.QueryRow(ctx, "SELECT COUNT(*) FROM ("+query+") AS total", args...).Scan(&total); err != nil {
+			return nil, filterError("failed to count events", err)
+		}
+		page.TotalCount = &total
+	case domain.CountEstimated:
+		total, err := r.estimateRows(ctx, query, args)
+		if err != nil {
+			return nil, err
+		}
+		page.TotalCount = &total
+		page.CountEstimated = true
 	}
 
-	// Add ordering and pagination
-	query += fmt.Sprintf(" ORDER BY timestamp DESC LIMIT $%d OFFSET $%d", argIdx, argIdx+1)
-	args = append(args, filter.Limit, filter.Offset)
+	if filter.Cursor != nil {
+		// The plain bound lets the planner prune partitions; the row comparison breaks timestamp ties
+		query += fmt.Sprintf(" AND timestamp <= $%d AND (timestamp, event_id) < ($%d, $%d)", argIdx, argIdx, argIdx+1)
+		args = append(args, filter.Cursor.Timestamp, filter.Cursor.EventID)
+		argIdx += 2
+	}
+
+	// One extra row tells whether another page exists without counting
+	query += fmt.Sprintf(" ORDER BY timestamp DESC, event_id DESC LIMIT $%d", argIdx)
+	args = append(args, limit+1)
+	argIdx++
+	if filter.Cursor == nil {
+		page.Page = filter.Offset/limit + 1
+		if filter.Offset > 0 {
+			query += fmt.Sprintf(" OFFSET $%d", argIdx)
+			args = append(args, filter.Offset)
+		}
+	}
 
 	rows, err := r.pool.Query(ctx, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query events: %w", err)
+		return nil, filterError("failed to query events", err)
 	}
 	defer rows.Close()
 
-	var events []*domain.AuditEvent
 	for rows.Next() {
-		var e domain.AuditEvent
-		err := rows.Scan(
-			&e.EventID, &e.TransactionID, &e.UserID, &e.ActorID, &e.ActionType,
-			&e.ResourceType, &e.ResourceID, &e.ServiceSource, &e.Timestamp, &e.Result,
-			&e.FailureReason, &e.IPAddress, &e.Geolocation, &e.UserAgent, &e.RequestID,
-			&e.SessionID, &e.DigitalSignature, &e.Metadata, &e.DataBefore, &e.DataAfter,
-			&e.ComplianceFlags, &e.RetentionCategory, &e.EncryptionKeyID, &e.CreatedAt,
-		)
+		e, err := scanAuditEvent(rows)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan event: %w", err)
+			return nil, err
 		}
-		events = append(events, &e)
+		page.Events = append(page.Events, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, filterError("failed to iterate events", err)
 	}
 
-	return &domain.AuditEventPage{
-		Events:     events,
-		TotalCount: totalCount,
-		PageSize:   filter.Limit,
-		HasMore:    totalCount > int64(filter.Offset+filter.Limit),
-	}, nil
+	if len(page.Events) > limit {
+		page.Events = page.Events[:limit]
+		page.HasMore = true
+		page.NextCursor = domain.CursorAfter(page.Events[limit-1]).Encode()
+	}
+	return page, nil
+}
+
+// estimateRows returns the planner's row estimate for query, which costs no table scan
+func (r *AuditRepository) estimateRows(ctx context.Context, query string, args []interface{}) (int64, error) {
+	var raw []byte
+	if err := r.pool.QueryRow(ctx, "EXPLAIN (FORMAT JSON) "+query, args...).Scan(&raw); err != nil {
+		return 0, filterError("failed to estimate event count", err)
+	}
+	var plans []struct {
+		Plan struct {
+			Rows float64 `json:"Plan Rows"`
+		} `json:"Plan"`
+	}
+	if err := json.Unmarshal(raw, &plans); err != nil {
+		return 0, fmt.Errorf("failed to parse query plan: %w", err)
+	}
+	if len(plans) == 0 {
+		return 0, errors.New("failed to parse query plan: empty plan")
+	}
+	return int64(plans[0].Plan.Rows), nil
 }
 
-// GetLastEventHash retrieves the hash/signature of the most recent event for chaining
-// In a real blockchain-like implement, we'd need a robust way to traverse back.
-// Here we might use the DigitalSignature of the last inserted event as a proxy for "Previous Hash"
-// or a specific separate hash column if we decided to implement a strict blockchain.
-// Given strict reqs, let's assume we use DigitalSignature as link.
-func (r *AuditRepository) GetLastEventSignature(ctx context.Context) (string, error) {
-	query := `SELECT digital_signature FROM audit_events ORDER BY timestamp DESC LIMIT 1`
-	var signature string
-	err := r.pool.QueryRow(ctx, query).Scan(&signature)
+// filterError maps syntax (42601) and data exception (class 22) errors, which only
+// caller-supplied filter values can cause, to ErrInvalidFilter
+func filterError(msg string, err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && (pgErr.Code == "42601" || strings.HasPrefix(pgErr.Code, "22")) {
+		return fmt.Errorf("%w: %s", ErrInvalidFilter, pgErr.Message)
+	}
+	return fmt.Errorf("%s: %w", msg, err)
+}
+
+// GetChainHead returns the sequence number and record hash of the last event in the chain.
+// Chain positions are read from audit_event_index, which outlives detached partitions.
+// An empty ledger returns sequence 0 and the genesis hash.
+func (r *AuditRepository) GetChainHead(ctx context.Context) (int64, string, error) {
+	return chainHead(ctx, r.pool)
+}
+
+// rowQuerier is satisfied by both *pgxpool.Pool and pgx.Tx
+type rowQuerier interface {
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+}
+
+func chainHead(ctx context.Context, q rowQuerier) (int64, string, error) {
+	query := `SELECT sequence_num, record_hash FROM audit_event_index ORDER BY sequence_num DESC LIMIT 1`
+	var seq int64
+	var hash string
+	err := q.QueryRow(ctx, query).Scan(&seq, &hash)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return "", nil // Genesis block case
+			return 0, domain.LedgerGenesisHash, nil // Genesis block case
 		}
+		return 0, "", fmt.Errorf("failed to read chain head: %w", err)
+	}
+	return seq, hash, nil
+}
+
+// appendTime returns the created_at of the next append: the database clock, held back
+// to the head event's created_at should the clock step backwards. Called under the chain
+// lock, it keeps created_at in sequence order.
+func appendTime(ctx context.Context, q rowQuerier, headSeq int64) (time.Time, error) {
+	var now time.Time
+	err := q.QueryRow(ctx, `
+		SELECT GREATEST(clock_timestamp(), (SELECT MAX(created_at) FROM audit_events WHERE sequence_num = $1))
+	`, headSeq).Scan(&now)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to read ledger clock: %w", err)
+	}
+	return now.UTC().Truncate(time.Microsecond), nil
+}
+
+// LastSequenceSignedWith returns the last chain position signed with algorithm, by an
+// event or a checkpoint. Checkpoints outlive detached partitions, so positions of
+// archived events are still found. 0 when nothing was signed with it.
+func (r *AuditRepository) LastSequenceSignedWith(ctx context.Context, algorithm string) (int64, error) {
+	query := `
+		SELECT GREATEST(
+			COALESCE((SELECT MAX(sequence_num) FROM audit_events WHERE signature_algorithm = $1), 0),
+			COALESCE((SELECT MAX(tree_size) FROM ledger_checkpoints WHERE signature_algorithm = $1), 0))
+	`
+	var seq int64
+	if err := r.pool.QueryRow(ctx, query, algorithm).Scan(&seq); err != nil {
+		return 0, fmt.Errorf("failed to read last %s signature: %w", algorithm, err)
+	}
+	return seq, nil
+}
+
+// GetSequenceRange returns the chain positions of events ingested between from and to:
+// from just after the last event ingested before from, through just before the first
+// event ingested after to, or the chain head when there is none. Bounding by sequence
+// keeps events deleted from the window inside the range, where they are reported
+// missing. Both are 0 when the range is empty.
+func (r *AuditRepository) GetSequenceRange(ctx context.Context, from, to time.Time) (int64, int64, error) {
+	query := `
+		SELECT
+			COALESCE((SELECT sequence_num FROM audit_events WHERE created_at < $1
+				ORDER BY created_at DESC, sequence_num DESC LIMIT 1), 0) + 1,
+			COALESCE((SELECT sequence_num FROM audit_events WHERE created_at > $2
+				ORDER BY created_at ASC, sequence_num ASC LIMIT 1) - 1,
+				(SELECT MAX(sequence_num) FROM audit_event_index), 0)
+	`
+	var first, last int64
+	if err := r.pool.QueryRow(ctx, query, from, to).Scan(&first, &last); err != nil {
+		return 0, 0, fmt.Errorf("failed to read sequence range: %w", err)
+	}
+	if first > last {
+		return 0, 0, nil
+	}
+	return first, last, nil
+}
+
+// GetRecordHash returns the record hash stored at a sequence number.
+// Sequence 0 is the genesis position. Returns pgx.ErrNoRows if the row is missing.
+func (r *AuditRepository) GetRecordHash(ctx context.Context, sequenceNum int64) (string, error) {
+	if sequenceNum == 0 {
+		return domain.LedgerGenesisHash, nil
+	}
+	var hash string
+	err := r.pool.QueryRow(ctx, `SELECT record_hash FROM audit_event_index WHERE sequence_num = $1`, sequenceNum).Scan(&hash)
+	if err != nil {
 		return "", err
 	}
-	return signature, nil
+	return hash, nil
+}
+
+// StreamChain calls fn for every event with a sequence number in [fromSeq, toSeq],
+// in chain order. Rows are read from a single cursor so memory use stays flat.
+func (r *AuditRepository) StreamChain(ctx context.Context, fromSeq, toSeq int64, fn func(*domain.AuditEvent) error) error {
+	query := `
+		SELECT ` + auditEventColumns + `
+		FROM audit_events
+		WHERE sequence_num >= $1 AND sequence_num <= $2
+		ORDER BY sequence_num ASC
+	`
+	rows, err := r.pool.Query(ctx, query, fromSeq, toSeq)
+	if err != nil {
+		return fmt.Errorf("failed to stream chain: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		e, err := scanAuditEvent(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to stream chain: %w", err)
+	}
+	return nil
+}
+
+// StreamRecordHashes calls fn with the sequence number and record hash of every indexed
+// chain position in [fromSeq, toSeq], in chain order
+func (r *AuditRepository) StreamRecordHashes(ctx context.Context, fromSeq, toSeq int64, fn func(seq int64, hash string) error) error {
+	query := `
+		SELECT sequence_num, record_hash FROM audit_event_index
+		WHERE sequence_num >= $1 AND sequence_num <= $2
+		ORDER BY sequence_num ASC
+	`
+	rows, err := r.pool.Query(ctx, query, fromSeq, toSeq)
+	if err != nil {
+		return fmt.Errorf("failed to query record hashes: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var seq int64
+		var hash string
+		if err := rows.Scan(&seq, &hash); err != nil {
+			return fmt.Errorf("failed to scan record hash: %w", err)
+		}
+		if err := fn(seq, hash); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to iterate record hashes: %w", err)
+	}
+	return nil
+}
+
+// CountArchivedSequences counts sequence numbers in [fromSeq, toSeq] whose events
+// belong to partitions that were archived and detached from the ledger
+func (r *AuditRepository) CountArchivedSequences(ctx context.Context, fromSeq, toSeq int64) (int64, error) {
+	query := `
+		SELECT COUNT(*) FROM audit_event_index i
+		WHERE i.sequence_num >= $1 AND i.sequence_num <= $2
+		AND EXISTS (
+			SELECT 1 FROM audit_partitions p
+			WHERE p.detached_at IS NOT NULL
+			AND i.timestamp >= p.range_start AND i.timestamp < p.range_end
+		)
+	`
+	var count int64
+	if err := r.pool.QueryRow(ctx, query, fromSeq, toSeq).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count archived sequences: %w", err)
+	}
+	return count, nil
+}
+
+// scanAuditEvent scans a row selected with auditEventColumns
+func scanAuditEvent(row pgx.Row) (*domain.AuditEvent, error) {
+	var e domain.AuditEvent
+	err := row.Scan(
+		&e.EventID, &e.TransactionID, &e.UserID, &e.ActorID, &e.ActionType,
+		&e.ResourceType, &e.ResourceID, &e.ServiceSource, &e.Timestamp, &e.Result,
+		&e.FailureReason, &e.IPAddress, &e.Geolocation, &e.UserAgent, &e.RequestID,
+		&e.SessionID, &e.DigitalSignature, &e.Metadata, &e.DataBefore, &e.DataAfter,
+		&e.ComplianceFlags, &e.RetentionCategory, &e.EncryptionKeyID, &e.CreatedAt,
+		&e.SequenceNum, &e.PrevHash, &e.RecordHash, &e.SignatureVersion,
+		&e.SignatureAlgorithm, &e.SigningKeyID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan event: %w", err)
+	}
+	return &e, nil
+}
+
+// Pool exposes the connection pool so sibling repositories share it
+func (r *AuditRepository) Pool() *pgxpool.Pool {
+	return r.pool
 }
 
 // Close closes the database connection pool
@@ -0,0 +1,108 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/banking/audit-compliance/internal/crypto"
+	"github.com/banking/audit-compliance/internal/domain"
+	"github.com/jackc/pgx/v5"
+)
+
+// ChainBackfillMigration is the migration that adds the chain columns to a ledger
+// created before the hash chain; BackfillHashChain runs after it
+const ChainBackfillMigration = 16
+
+// chainBackfillBatch is how many unchained events are read at a time
+const chainBackfillBatch = 1000
+
+// unchainedEventColumns is auditEventColumns for rows whose chain columns are still NULL
+var unchainedEventColumns = strings.NewReplacer(
+	"sequence_num,", "0,",
+	"prev_hash,", "'',",
+	"record_hash,", "'',",
+).Replace(auditEventColumns)
+
+// BackfillHashChain links events recorded before the hash chain existed. Unchained rows
+// are appended after any chained ones in created_at order, exactly as CreateEvent would
+// have chained them, and entered in audit_event_index. It runs in the migration
+// transaction with the append-only trigger disabled for its updates, and does nothing on
+// a ledger that is fully chained.
+func BackfillHashChain(ctx context.Context, tx pgx.Tx) error {
+	var headSeq int64
+	var headHash string
+	err := tx.QueryRow(ctx, `
+		SELECT sequence_num, record_hash FROM audit_events
+		WHERE sequence_num IS NOT NULL ORDER BY sequence_num DESC LIMIT 1
+	`).Scan(&headSeq, &headHash)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		headHash = domain.LedgerGenesisHash
+	case err != nil:
+		return fmt.Errorf("failed to read chain head: %w", err)
+	}
+
+	var unchained bool
+	err = tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM audit_events WHERE record_hash IS NULL)`).Scan(&unchained)
+	if err != nil {
+		return fmt.Errorf("failed to look for unchained events: %w", err)
+	}
+	if !unchained {
+		return nil
+	}
+	if _, err := tx.Exec(ctx, `ALTER TABLE audit_events DISABLE TRIGGER audit_events_append_only`); err != nil {
+		return fmt.Errorf("failed to suspend append-only trigger: %w", err)
+	}
+
+	for {
+		rows, err := tx.Query(ctx, `
+			SELECT `+unchainedEventColumns+`
+			FROM audit_events
+			WHERE record_hash IS NULL
+			ORDER BY created_at ASC, event_id ASC
+			LIMIT $1
+		`, chainBackfillBatch)
+		if err != nil {
+			return fmt.Errorf("failed to read unchained events: %w", err)
+		}
+		events, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*domain.AuditEvent, error) {
+			return scanAuditEvent(row)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to read unchained events: %w", err)
+		}
+		if len(events) == 0 {
+			break
+		}
+
+		batch := &pgx.Batch{}
+		for _, event := range events {
+			headSeq++
+			event.SequenceNum = headSeq
+			event.PrevHash = headHash
+			event.RecordHash = crypto.HashChain(event.PrevHash, event.CanonicalRecord())
+			headHash = event.RecordHash
+			batch.Queue(`UPDATE audit_events SET sequence_num = $1, prev_hash = $2, record_hash = $3 WHERE event_id = $4`,
+				event.SequenceNum, event.PrevHash, event.RecordHash, event.EventID)
+			batch.Queue(`INSERT INTO audit_event_index (sequence_num, event_id, record_hash, timestamp) VALUES ($1, $2, $3, $4)`,
+				event.SequenceNum, event.EventID, event.RecordHash, event.Timestamp)
+		}
+		if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+			return fmt.Errorf("failed to chain events: %w", err)
+		}
+	}
+
+	_, err = tx.Exec(ctx, `
+		ALTER TABLE audit_events
+			ALTER COLUMN sequence_num SET NOT NULL,
+			ALTER COLUMN prev_hash SET NOT NULL,
+			ALTER COLUMN record_hash SET NOT NULL,
+			ENABLE TRIGGER audit_events_append_only
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to require chain columns: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/banking/audit-compliance/internal/crypto"
+	"github.com/banking/audit-compliance/internal/domain"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrCheckpointNotFound is returned when no checkpoint matches the lookup
+var ErrCheckpointNotFound = errors.New("checkpoint not found")
+
+// CheckpointRepository implements repository for signed ledger checkpoints
+type CheckpointRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewCheckpointRepository creates a new checkpoint repository
+func NewCheckpointRepository(pool *pgxpool.Pool) *CheckpointRepository {
+	return &CheckpointRepository{
+		pool: pool,
+	}
+}
+
+const checkpointColumns = `
+			checkpoint_id, tree_size, root_hash, signature,
+			signature_algorithm, signing_key_id, created_at,
+			tsa_token, tsa_time`
+
+// CreateCheckpoint stores a signed tree head together with the Merkle nodes its tree
+// completed. Checkpoints are append-only; a second checkpoint for the same tree size is
+// rejected by the unique constraint, and with it the nodes.
+func (r *CheckpointRepository) CreateCheckpoint(ctx context.Context, cp *domain.LedgerCheckpoint, nodes []crypto.MerkleNode) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin checkpoint: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.CopyFrom(ctx, pgx.Identifier{"merkle_nodes"}, []string{"level", "node_index", "hash"},
+		pgx.CopyFromSlice(len(nodes), func(i int) ([]interface{}, error) {
+			return []interface{}{int16(nodes[i].Level), nodes[i].Index, nodes[i].Hash}, nil
+		}))
+	if err != nil {
+		return fmt.Errorf("failed to insert merkle nodes: %w", err)
+	}
+
+	const query = `
+		INSERT INTO ledger_checkpoints (` + checkpointColumns + `
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+	_, err = tx.Exec(ctx, query,
+		cp.CheckpointID, cp.TreeSize, cp.RootHash, cp.Signature,
+		cp.SignatureAlgorithm, cp.SigningKeyID, cp.CreatedAt,
+		cp.TimestampToken, cp.TimestampedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert checkpoint: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit checkpoint: %w", err)
+	}
+	return nil
+}
+
+// GetMerkleNodes returns the stored hashes of the requested perfect subtrees. Nodes that
+// were never stored are absent from the result.
+func (r *CheckpointRepository) GetMerkleNodes(ctx context.Context, ids []crypto.MerkleNodeID) (map[crypto.MerkleNodeID][]byte, error) {
+	levels := make([]int16, len(ids))
+	indexes := make([]int64, len(ids))
+	for i, id := range ids {
+		levels[i] = int16(id.Level)
+		indexes[i] = id.Index
+	}
+
+	query := `
+		SELECT n.level, n.node_index, n.hash
+		FROM merkle_nodes n
+		JOIN unnest($1::smallint[], $2::bigint[]) AS want(level, node_index)
+		  ON n.level = want.level AND n.node_index = want.node_index
+	`
+	rows, err := r.pool.Query(ctx, query, levels, indexes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query merkle nodes: %w", err)
+	}
+	defer rows.Close()
+
+	nodes := make(map[crypto.MerkleNodeID][]byte, len(ids))
+	for rows.Next() {
+		var level int16
+		var id crypto.MerkleNodeID
+		var hash []byte
+		if err := rows.Scan(&level, &id.Index, &hash); err != nil {
+			return nil, fmt.Errorf("failed to scan merkle node: %w", err)
+		}
+		id.Level = int(level)
+		nodes[id] = hash
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate merkle nodes: %w", err)
+	}
+	return nodes, nil
+}
+
+// GetLatest returns the checkpoint with the largest tree size
+func (r *CheckpointRepository) GetLatest(ctx context.Context) (*domain.LedgerCheckpoint, error) {
+	query := `SELECT ` + checkpointColumns + ` FROM ledger_checkpoints ORDER BY tree_size DESC LIMIT 1`
+	return scanCheckpoint(r.pool.QueryRow(ctx, query))
+}
+
+// GetByTreeSize returns the checkpoint covering exactly treeSize events
+func (r *CheckpointRepository) GetByTreeSize(ctx context.Context, treeSize int64) (*domain.LedgerCheckpoint, error) {
+	query := `SELECT ` + checkpointColumns + ` FROM ledger_checkpoints WHERE tree_size = $1`
+	return scanCheckpoint(r.pool.QueryRow(ctx, query, treeSize))
+}
+
+// ListCreatedBetween returns checkpoints created in [from, to], smallest tree first
+func (r *CheckpointRepository) ListCreatedBetween(ctx context.Context, from, to time.Time) ([]*domain.LedgerCheckpoint, error) {
+	query := `SELECT ` + checkpointColumns + ` FROM ledger_checkpoints
+		WHERE created_at BETWEEN $1 AND $2 ORDER BY tree_size`
+	rows, err := r.pool.Query(ctx, query, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query checkpoints: %w", err)
+	}
+	defer rows.Close()
+
+	var checkpoints []*domain.LedgerCheckpoint
+	for rows.Next() {
+		cp, err := scanCheckpoint(rows)
+		if err != nil {
+			return nil, err
+		}
+		checkpoints = append(checkpoints, cp)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate checkpoints: %w", err)
+	}
+	return checkpoints, nil
+}
+
+func scanCheckpoint(row pgx.Row) (*domain.LedgerCheckpoint, error) {
+	var cp domain.LedgerCheckpoint
+	err := row.Scan(
+		&cp.CheckpointID, &cp.TreeSize, &cp.RootHash, &cp.Signature,
+		&cp.SignatureAlgorithm, &cp.SigningKeyID, &cp.CreatedAt,
+		&cp.TimestampToken, &cp.TimestampedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrCheckpointNotFound
+		}
+		return nil, fmt.Errorf("failed to scan checkpoint: %w", err)
+	}
+	return &cp, nil
+}
@@ -0,0 +1,135 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/banking/audit-compliance/internal/domain"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrDeadLetterReplayed is returned when a dead letter has already been replayed
+var ErrDeadLetterReplayed = errors.New("dead letter already replayed")
+
+// defaultDeadLetterLimit applies when a filter sets no limit
+const defaultDeadLetterLimit = 100
+
+// DLQRepository stores Kafka records the consumer could not record in the ledger
+type DLQRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewDLQRepository creates a new dead letter repository
+func NewDLQRepository(pool *pgxpool.Pool) *DLQRepository {
+	return &DLQRepository{
+		pool: pool,
+	}
+}
+
+const deadLetterColumns = `
+		dlq_id, source_topic, source_partition, source_offset, message_key,
+		payload, headers, message_timestamp, error_class, error_message,
+		violations, failed_at, replayed_at, replayed_event_id`
+
+// SaveDeadLetter records a dead letter. A record already dead-lettered from the same
+// topic, partition and offset is kept as first recorded.
+func (r *DLQRepository) SaveDeadLetter(ctx context.Context, dl *domain.DeadLetter) error {
+	headers := dl.Headers
+	if headers == nil {
+		headers = []domain.DeadLetterHeader{}
+	}
+	violations := dl.Violations
+	if violations == nil {
+		violations = []domain.DeadLetterViolation{}
+	}
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO dlq_events (
+			dlq_id, source_topic, source_partition, source_offset, message_key,
+			payload, headers, message_timestamp, error_class, error_message,
+			violations, failed_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		ON CONFLICT (source_topic, source_partition, source_offset) DO NOTHING
+	`,
+		dl.DLQID, dl.SourceTopic, dl.SourcePartition, dl.SourceOffset, dl.MessageKey,
+		dl.Payload, headers, dl.MessageTimestamp, dl.ErrorClass, dl.ErrorMessage,
+		violations, dl.FailedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert dead letter: %w", err)
+	}
+	return nil
+}
+
+// ListDeadLetters returns dead letters matching filter, oldest first
+func (r *DLQRepository) ListDeadLetters(ctx context.Context, filter domain.DeadLetterFilter) ([]*domain.DeadLetter, error) {
+	query := `SELECT ` + deadLetterColumns + ` FROM dlq_events WHERE 1=1`
+	args := []interface{}{}
+	argIdx := 1
+
+	if filter.ErrorClass != nil {
+		query += fmt.Sprintf(" AND error_class = $%d", argIdx)
+		args = append(args, *filter.ErrorClass)
+		argIdx++
+	}
+	if filter.PendingOnly {
+		query += " AND replayed_at IS NULL"
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultDeadLetterLimit
+	}
+	query += fmt.Sprintf(" ORDER BY failed_at, dlq_id LIMIT $%d", argIdx)
+	args = append(args, limit)
+
+	return r.queryDeadLetters(ctx, query, args...)
+}
+
+// GetDeadLetters returns the dead letters with the given IDs; unknown IDs are skipped
+func (r *DLQRepository) GetDeadLetters(ctx context.Context, ids []uuid.UUID) ([]*domain.DeadLetter, error) {
+	return r.queryDeadLetters(ctx, `SELECT `+deadLetterColumns+` FROM dlq_events WHERE dlq_id = ANY($1) ORDER BY failed_at, dlq_id`, ids)
+}
+
+func (r *DLQRepository) queryDeadLetters(ctx context.Context, query string, args ...interface{}) ([]*domain.DeadLetter, error) {
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query dead letters: %w", err)
+	}
+	defer rows.Close()
+
+	var letters []*domain.DeadLetter
+	for rows.Next() {
+		var dl domain.DeadLetter
+		if err := rows.Scan(
+			&dl.DLQID, &dl.SourceTopic, &dl.SourcePartition, &dl.SourceOffset, &dl.MessageKey,
+			&dl.Payload, &dl.Headers, &dl.MessageTimestamp, &dl.ErrorClass, &dl.ErrorMessage,
+			&dl.Violations, &dl.FailedAt, &dl.ReplayedAt, &dl.ReplayedEventID,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan dead letter: %w", err)
+		}
+		letters = append(letters, &dl)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate dead letters: %w", err)
+	}
+	return letters, nil
+}
+
+// MarkReplayed records that a dead letter was replayed as eventID. A dead letter can be
+// marked once; a second mark returns ErrDeadLetterReplayed.
+func (r *DLQRepository) MarkReplayed(ctx context.Context, id, eventID uuid.UUID, at time.Time) error {
+	tag, err := r.pool.Exec(ctx, `
+		UPDATE dlq_events SET replayed_at = $2, replayed_event_id = $3
+		WHERE dlq_id = $1 AND replayed_at IS NULL
+	`, id, at, eventID)
+	if err != nil {
+		return fmt.Errorf("failed to mark dead letter replayed: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrDeadLetterReplayed
+	}
+	return nil
+}
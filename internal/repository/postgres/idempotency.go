@@ -0,0 +1,50 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrIdempotencyKeyReused is returned when a caller sends an idempotency key again with
+// a different request
+var ErrIdempotencyKeyReused = errors.New("idempotency key reused for a different request")
+
+// ClaimIdempotencyKey binds key, scoped to caller, to requestHash. It reports whether the
+// key is new; a key already bound to a different hash returns ErrIdempotencyKeyReused.
+func (r *AuditRepository) ClaimIdempotencyKey(ctx context.Context, caller, key, requestHash string) (bool, error) {
+	tag, err := r.pool.Exec(ctx, `
+		INSERT INTO idempotency_keys (caller, idempotency_key, request_hash)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (caller, idempotency_key) DO NOTHING
+	`, caller, key, requestHash)
+	if err != nil {
+		return false, fmt.Errorf("failed to claim idempotency key: %w", err)
+	}
+	if tag.RowsAffected() == 1 {
+		return true, nil
+	}
+
+	var stored string
+	err = r.pool.QueryRow(ctx, `
+		SELECT request_hash FROM idempotency_keys WHERE caller = $1 AND idempotency_key = $2
+	`, caller, key).Scan(&stored)
+	if err != nil {
+		return false, fmt.Errorf("failed to read idempotency key: %w", err)
+	}
+	if stored != requestHash {
+		return false, ErrIdempotencyKeyReused
+	}
+	return false, nil
+}
+
+// DeleteExpiredIdempotencyKeys deletes keys claimed before cutoff and returns how many
+// were deleted
+func (r *AuditRepository) DeleteExpiredIdempotencyKeys(ctx context.Context, cutoff time.Time) (int64, error) {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM idempotency_keys WHERE created_at < $1`, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired idempotency keys: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
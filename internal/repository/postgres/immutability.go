@@ -0,0 +1,78 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ledgerTables are the append-only tables guarded by triggers from migrations 003, 005 and 015
+var ledgerTables = []string{"audit_events", "audit_event_index", "access_logs", "ledger_checkpoints", "merkle_nodes"}
+
+// ErrLedgerMutable is returned when the connected role could rewrite ledger rows
+var ErrLedgerMutable = errors.New("ledger is mutable by the connected role")
+
+// CheckAppendOnly verifies that the connected role can only read and append to the
+// ledger: it must not be a superuser or a member of the owning role (either could
+// disable the triggers), must hold no UPDATE, DELETE or TRUNCATE privilege, and every
+// append-only trigger must be installed and enabled.
+func (r *AuditRepository) CheckAppendOnly(ctx context.Context) error {
+	var problems []string
+
+	var role string
+	var superuser bool
+	err := r.pool.QueryRow(ctx,
+		`SELECT rolname, rolsuper FROM pg_roles WHERE rolname = current_user`,
+	).Scan(&role, &superuser)
+	if err != nil {
+		return fmt.Errorf("failed to inspect database role: %w", err)
+	}
+	if superuser {
+		problems = append(problems, fmt.Sprintf("role %q is a superuser", role))
+	}
+
+	const query = `
+		SELECT pg_has_role(current_user, c.relowner, 'MEMBER'),
+		       has_table_privilege(c.oid, 'UPDATE'),
+		       has_table_privilege(c.oid, 'DELETE'),
+		       has_table_privilege(c.oid, 'TRUNCATE'),
+		       (SELECT count(*) FROM pg_trigger t
+		        WHERE t.tgrelid = c.oid AND t.tgname = ANY($2) AND t.tgenabled <> 'D')
+		FROM pg_class c
+		WHERE c.oid = to_regclass($1)
+	`
+	for _, table := range ledgerTables {
+		triggers := []string{table + "_append_only", table + "_no_truncate"}
+
+		var owner, canUpdate, canDelete, canTruncate bool
+		var enabledTriggers int64
+		err := r.pool.QueryRow(ctx, query, table, triggers).Scan(
+			&owner, &canUpdate, &canDelete, &canTruncate, &enabledTriggers,
+		)
+		if errors.Is(err, pgx.ErrNoRows) {
+			problems = append(problems, fmt.Sprintf("table %s does not exist", table))
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to inspect %s: %w", table, err)
+		}
+
+		if owner {
+			problems = append(problems, fmt.Sprintf("role %q owns %s", role, table))
+		}
+		if canUpdate || canDelete || canTruncate {
+			problems = append(problems, fmt.Sprintf("role %q may UPDATE, DELETE or TRUNCATE %s", role, table))
+		}
+		if enabledTriggers != int64(len(triggers)) {
+			problems = append(problems, fmt.Sprintf("append-only triggers on %s are missing or disabled", table))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrLedgerMutable, strings.Join(problems, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,223 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/banking/audit-compliance/internal/domain"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrPartitionNotArchivable is returned when a partition is unknown or still current
+var ErrPartitionNotArchivable = errors.New("partition cannot be marked archived")
+
+// PartitionManager maintains the monthly partitions of audit_events. DDL requires the
+// schema owner, so it must be given a pool connected as the owner role, never the app pool.
+type PartitionManager struct {
+	pool *pgxpool.Pool
+}
+
+// NewPartitionManager creates a partition manager on the owner's pool
+func NewPartitionManager(ownerPool *pgxpool.Pool) *PartitionManager {
+	return &PartitionManager{
+		pool: ownerPool,
+	}
+}
+
+// PartitionName returns the partition holding events of month's calendar month (UTC)
+func PartitionName(month time.Time) string {
+	month = month.UTC()
+	return fmt.Sprintf("audit_events_y%04dm%02d", month.Year(), int(month.Month()))
+}
+
+// monthStart truncates t to the first instant of its calendar month in UTC
+func monthStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// EnsurePartitions creates any missing partitions from the month of now through
+// monthsAhead months later, and returns the names it created.
+//
+// A month whose events already landed in the default partition cannot be split out
+// (rows are append-only), so it is reported as an error and left for an operator.
+func (m *PartitionManager) EnsurePartitions(ctx context.Context, now time.Time, monthsAhead int) ([]string, error) {
+	var created []string
+	var errs []error
+
+	start := monthStart(now)
+	for i := 0; i <= monthsAhead; i++ {
+		from := start.AddDate(0, i, 0)
+		to := from.AddDate(0, 1, 0)
+		name := PartitionName(from)
+
+		ok, err := m.createPartition(ctx, name, from, to)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if ok {
+			created = append(created, name)
+		}
+	}
+	return created, errors.Join(errs...)
+}
+
+func (m *PartitionManager) createPartition(ctx context.Context, name string, from, to time.Time) (bool, error) {
+	tx, err := m.pool.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin partition creation: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var exists bool
+	if err := tx.QueryRow(ctx,
+		`SELECT EXISTS (SELECT 1 FROM audit_partitions WHERE partition_name = $1)`, name,
+	).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to read partition registry: %w", err)
+	}
+	if exists {
+		return false, nil
+	}
+
+	var stranded bool
+	if err := tx.QueryRow(ctx,
+		`SELECT EXISTS (SELECT 1 FROM audit_events_default WHERE timestamp >= $1 AND timestamp < $2)`, from, to,
+	).Scan(&stranded); err != nil {
+		return false, fmt.Errorf("failed to inspect default partition: %w", err)
+	}
+	if stranded {
+		return false, fmt.Errorf("cannot create %s: the default partition already holds events for that month", name)
+	}
+
+	// DDL takes no bind parameters; both bounds are generated here, never user input
+	ddl := fmt.Sprintf(`CREATE TABLE %s PARTITION OF audit_events FOR VALUES FROM ('%s') TO ('%s')`,
+		pgx.Identifier{name}.Sanitize(), from.Format(time.RFC3339), to.Format(time.RFC3339))
+	if _, err := tx.Exec(ctx, ddl); err != nil {
+		return false, fmt.Errorf("failed to create partition %s: %w", name, err)
+	}
+	if _, err := tx.Exec(ctx,
+		`INSERT INTO audit_partitions (partition_name, range_start, range_end) VALUES ($1, $2, $3)`,
+		name, from, to,
+	); err != nil {
+		return false, fmt.Errorf("failed to register partition %s: %w", name, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("failed to commit partition %s: %w", name, err)
+	}
+	return true, nil
+}
+
+// ListArchivable returns the partitions not yet archived whose month ended at least
+// afterMonths whole months before the month of now, oldest first
+func (m *PartitionManager) ListArchivable(ctx context.Context, now time.Time, afterMonths int) ([]string, error) {
+	rows, err := m.pool.Query(ctx, `
+		SELECT partition_name FROM audit_partitions
+		WHERE archived_at IS NULL AND range_end <= $1
+		ORDER BY range_start
+	`, monthStart(now).AddDate(0, -afterMonths, 0))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list archivable partitions: %w", err)
+	}
+	names, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("failed to list archivable partitions: %w", err)
+	}
+	return names, nil
+}
+
+// StreamPartition calls fn with every event of a partition in chain order
+func (m *PartitionManager) StreamPartition(ctx context.Context, name string, fn func(*domain.AuditEvent) error) error {
+	rows, err := m.pool.Query(ctx, `
+		SELECT `+auditEventColumns+`
+		FROM `+pgx.Identifier{name}.Sanitize()+`
+		ORDER BY sequence_num ASC
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to stream partition %s: %w", name, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		e, err := scanAuditEvent(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to stream partition %s: %w", name, err)
+	}
+	return nil
+}
+
+// MarkArchived records that a closed month has been exported to archiveRef, which makes
+// the partition eligible for detachment. The current and future months are refused.
+func (m *PartitionManager) MarkArchived(ctx context.Context, name, archiveRef string) error {
+	tag, err := m.pool.Exec(ctx, `
+		UPDATE audit_partitions SET archived_at = NOW(), archive_ref = $2
+		WHERE partition_name = $1 AND range_end <= NOW() AND archived_at IS NULL
+	`, name, archiveRef)
+	if err != nil {
+		return fmt.Errorf("failed to mark partition archived: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%w: %s", ErrPartitionNotArchivable, name)
+	}
+	return nil
+}
+
+// DetachArchived detaches every archived partition still attached to audit_events and
+// returns their names. Detached tables are kept, not dropped; their chain positions stay
+// in audit_event_index so ledger verification and checkpoints are unaffected.
+func (m *PartitionManager) DetachArchived(ctx context.Context) ([]string, error) {
+	rows, err := m.pool.Query(ctx, `
+		SELECT partition_name FROM audit_partitions
+		WHERE archived_at IS NOT NULL AND detached_at IS NULL
+		ORDER BY range_start
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list archived partitions: %w", err)
+	}
+	names, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("failed to list archived partitions: %w", err)
+	}
+
+	var detached []string
+	for _, name := range names {
+		if err := m.detach(ctx, name); err != nil {
+			return detached, err
+		}
+		detached = append(detached, name)
+	}
+	return detached, nil
+}
+
+func (m *PartitionManager) detach(ctx context.Context, name string) error {
+	tx, err := m.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin detach of %s: %w", name, err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `ALTER TABLE audit_events DETACH PARTITION `+pgx.Identifier{name}.Sanitize()); err != nil {
+		return fmt.Errorf("failed to detach %s: %w", name, err)
+	}
+	if _, err := tx.Exec(ctx,
+		`UPDATE audit_partitions SET detached_at = NOW() WHERE partition_name = $1`, name,
+	); err != nil {
+		return fmt.Errorf("failed to record detach of %s: %w", name, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit detach of %s: %w", name, err)
+	}
+	return nil
+}
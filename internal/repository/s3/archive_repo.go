@@ -54,6 +54,18 @@ func NewArchiveRepository(ctx context.Context, cfg appConfig.S3Config) (*Archive
 	}, nil
 }
 
+// EnsureBucket creates the archive bucket when it does not exist yet, for local
+// environments where nothing provisions it
+func (r *ArchiveRepository) EnsureBucket(ctx context.Context) error {
+	if _, err := r.client.HeadBucket(ctx, &s3.HeadBucketInput{Bucket: aws.String(r.bucket)}); err == nil {
+		return nil
+	}
+	if _, err := r.client.CreateBucket(ctx, &s3.CreateBucketInput{Bucket: aws.String(r.bucket)}); err != nil {
+		return fmt.Errorf("failed to create archive bucket: %w", err)
+	}
+	return nil
+}
+
 // ArchiveBatch uploads a batch of audit events to S3
 func (r *ArchiveRepository) ArchiveBatch(ctx context.Context, events []*domain.AuditEvent, batchID string) error {
 	if len(events) == 0 {
@@ -82,6 +94,50 @@ func (r *ArchiveRepository) ArchiveBatch(ctx context.Context, events []*domain.A
 	return nil
 }
 
+// ArchivePartitionBatch uploads one batch of a partition's events. Keys depend only on the
+// partition and batch number, so a retried archival overwrites rather than duplicates.
+func (r *ArchiveRepository) ArchivePartitionBatch(ctx context.Context, partition string, batch int, events []*domain.AuditEvent) error {
+	data, err := json.Marshal(events)
+	if err != nil {
+		return fmt.Errorf("failed to marshal events for archive: %w", err)
+	}
+
+	_, err = r.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(r.bucket),
+		Key:    aws.String(fmt.Sprintf("partitions/%s/%06d.json", partition, batch)),
+		Body:   bytes.NewReader(data),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upload partition batch to s3: %w", err)
+	}
+	return nil
+}
+
+// CompletePartitionArchive writes the manifest of an archived partition and returns its
+// location, which is recorded as the partition's archive reference
+func (r *ArchiveRepository) CompletePartitionArchive(ctx context.Context, partition string, events int64, batches int) (string, error) {
+	data, err := json.Marshal(map[string]interface{}{
+		"partition":   partition,
+		"events":      events,
+		"batches":     batches,
+		"archived_at": time.Now().UTC(),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal partition manifest: %w", err)
+	}
+
+	key := fmt.Sprintf("partitions/%s/manifest.json", partition)
+	_, err = r.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(r.bucket),
+		Key:    aws.String(key),
+		Body:   bytes.NewReader(data),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to upload partition manifest to s3: %w", err)
+	}
+	return fmt.Sprintf("s3://%s/%s", r.bucket, key), nil
+}
+
 // StoreReport uploads a compliance report to S3
 func (r *ArchiveRepository) StoreReport(ctx context.Context, reportName string, reportData []byte) error {
 	now := time.Now().UTC()
@@ -0,0 +1,74 @@
+package schema
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// ErrUnknownSchema is returned for a schema name the registry does not hold
+var ErrUnknownSchema = errors.New("unknown schema")
+
+// schemaName matches versioned schema files, <payload>.v<major>.json
+var schemaName = regexp.MustCompile(`^[a-z][a-z0-9_]*\.v[1-9][0-9]*\.json$`)
+
+// Registry resolves versioned schemas by name, e.g. "transaction_event.v1.json"
+type Registry interface {
+	Validator(name string) (*Validator, error)
+}
+
+// FSRegistry holds every schema of a file system, compiled up front so a broken schema
+// fails at startup rather than on the first payload that needs it
+type FSRegistry struct {
+	validators map[string]*Validator
+}
+
+// NewFSRegistry compiles every schema file at the root of fsys. Files must be named
+// <payload>.v<major>.json.
+func NewFSRegistry(fsys fs.FS) (*FSRegistry, error) {
+	names, err := fs.Glob(fsys, "*.json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list schemas: %w", err)
+	}
+	r := &FSRegistry{validators: make(map[string]*Validator, len(names))}
+	for _, name := range names {
+		if !schemaName.MatchString(name) {
+			return nil, fmt.Errorf("schema file %s is not named <payload>.v<major>.json", name)
+		}
+		v, err := NewValidator(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+		r.validators[name] = v
+	}
+	return r, nil
+}
+
+// Validator returns the validator for the schema name
+func (r *FSRegistry) Validator(name string) (*Validator, error) {
+	v, ok := r.validators[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownSchema, name)
+	}
+	return v, nil
+}
+
+// NameFromURI returns the schema name a schema URI refers to: its last path segment, so
+// that a schema's $id and its file name resolve alike
+func NameFromURI(uri string) string {
+	uri, _, _ = strings.Cut(uri, "#")
+	uri, _, _ = strings.Cut(uri, "?")
+	return path.Base(uri)
+}
+
+// Payload returns the payload a versioned schema name describes, "transaction_event" for
+// "transaction_event.v2.json", or "" when name is not of the form <payload>.v<major>.json
+func Payload(name string) string {
+	if !schemaName.MatchString(name) {
+		return ""
+	}
+	return name[:strings.LastIndex(name, ".v")]
+}
@@ -0,0 +1,48 @@
+package schema_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/banking/audit-compliance/internal/schema"
+	"github.com/banking/audit-compliance/schemas"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFSRegistry(t *testing.T) {
+	registry, err := schema.NewFSRegistry(schemas.FS)
+	require.NoError(t, err)
+
+	for _, name := range []string{"audit_event.v1.json", "cloudevent.v1.json", "transaction_event.v1.json", "user_event.v1.json", "alert_event.v1.json", "audit_topic_event.v1.json"} {
+		_, err := registry.Validator(name)
+		assert.NoError(t, err, name)
+	}
+	_, err = registry.Validator("audit_event.v2.json")
+	assert.ErrorIs(t, err, schema.ErrUnknownSchema)
+
+	_, err = schema.NewFSRegistry(fstest.MapFS{"audit-event.json": {Data: []byte(`{}`)}})
+	assert.Error(t, err, "Unversioned schema files are refused")
+}
+
+func TestNameFromURI(t *testing.T) {
+	tests := []struct {
+		uri  string
+		name string
+	}{
+		{"https://schemas.banking.internal/audit/transaction_event.v1.json", "transaction_event.v1.json"},
+		{"https://schemas.banking.internal/audit/user_event.v2.json#/properties", "user_event.v2.json"},
+		{"alert_event.v1.json", "alert_event.v1.json"},
+		{"urn:schema:alert_event.v1.json?rev=3", "urn:schema:alert_event.v1.json"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.name, schema.NameFromURI(tt.uri), tt.uri)
+	}
+}
+
+func TestPayload(t *testing.T) {
+	assert.Equal(t, "transaction_event", schema.Payload("transaction_event.v1.json"))
+	assert.Equal(t, "transaction_event", schema.Payload("transaction_event.v12.json"))
+	assert.Equal(t, "", schema.Payload("transaction_event.json"))
+	assert.Equal(t, "", schema.Payload("urn:schema:alert_event.v1.json"))
+}
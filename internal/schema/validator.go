@@ -0,0 +1,102 @@
+package schema
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+// ErrInvalid is returned when a payload is not JSON or does not match its schema
+var ErrInvalid = errors.New("payload does not match schema")
+
+// Violation is one way a payload fails its schema
+type Violation struct {
+	Field   string `json:"field"` // JSON pointer into the payload; "" for the payload itself
+	Message string `json:"message"`
+}
+
+// ValidationError lists every violation found in a payload
+type ValidationError struct {
+	Schema     string
+	Violations []Violation
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		msgs[i] = v.Field + ": " + v.Message
+		if v.Field == "" {
+			msgs[i] = v.Message
+		}
+	}
+	return fmt.Sprintf("payload does not match %s: %s", e.Schema, strings.Join(msgs, "; "))
+}
+
+func (e *ValidationError) Unwrap() error {
+	return ErrInvalid
+}
+
+// Validator checks payloads against one compiled schema
+type Validator struct {
+	name   string
+	schema *jsonschema.Schema
+}
+
+// NewValidator compiles the schema file name from fsys, asserting formats such as uuid
+// and date-time
+func NewValidator(fsys fs.FS, name string) (*Validator, error) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema %s: %w", name, err)
+	}
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse schema %s: %w", name, err)
+	}
+
+	c := jsonschema.NewCompiler()
+	c.AssertFormat()
+	if err := c.AddResource(name, doc); err != nil {
+		return nil, fmt.Errorf("failed to load schema %s: %w", name, err)
+	}
+	compiled, err := c.Compile(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile schema %s: %w", name, err)
+	}
+	return &Validator{name: name, schema: compiled}, nil
+}
+
+// Validate checks a JSON payload, returning a *ValidationError listing each violation
+func (v *Validator) Validate(payload []byte) error {
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(payload))
+	if err != nil {
+		return &ValidationError{Schema: v.name, Violations: []Violation{{Message: "invalid JSON: " + err.Error()}}}
+	}
+	return v.ValidateValue(doc)
+}
+
+// ValidateValue checks an already decoded JSON value. Numbers must be json.Number, as
+// produced by jsonschema.UnmarshalJSON or a decoder with UseNumber.
+func (v *Validator) ValidateValue(doc interface{}) error {
+	err := v.schema.Validate(doc)
+	if err == nil {
+		return nil
+	}
+	var verr *jsonschema.ValidationError
+	if !errors.As(err, &verr) {
+		return fmt.Errorf("failed to validate against %s: %w", v.name, err)
+	}
+
+	result := &ValidationError{Schema: v.name}
+	for _, unit := range verr.BasicOutput().Errors {
+		if unit.Error == nil {
+			continue
+		}
+		result.Violations = append(result.Violations, Violation{Field: unit.InstanceLocation, Message: unit.Error.String()})
+	}
+	return result
+}
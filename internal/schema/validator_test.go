@@ -0,0 +1,51 @@
+package schema_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/banking/audit-compliance/internal/schema"
+	"github.com/banking/audit-compliance/schemas"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditEventSchema(t *testing.T) {
+	v, err := schema.NewValidator(schemas.FS, "audit_event.v1.json")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		payload string
+		valid   bool
+		field   string // Location of the first violation, when checked
+	}{
+		{"minimal", `{"user_id":"6f0b6c1e-3c1a-4d2e-9a51-0c2f8e3b7d41","action_type":"LOGIN","resource_type":"SESSION","resource_id":"s-1"}`, true, ""},
+		{"full", `{"user_id":"6f0b6c1e-3c1a-4d2e-9a51-0c2f8e3b7d41","action_type":"TRANSFER","resource_type":"TRANSFER","resource_id":"tr-1",
+			"timestamp":"2026-10-01T12:00:00Z","result":"DENIED","ip_address":"2001:db8::1","metadata":{"amount_cents":1200},"compliance_flags":["CTR"]}`, true, ""},
+		{"missing user", `{"action_type":"LOGIN","resource_type":"SESSION","resource_id":"s-1"}`, false, ""},
+		{"bad uuid", `{"user_id":"42","action_type":"LOGIN","resource_type":"SESSION","resource_id":"s-1"}`, false, "/user_id"},
+		{"unknown action", `{"user_id":"6f0b6c1e-3c1a-4d2e-9a51-0c2f8e3b7d41","action_type":"DANCE","resource_type":"SESSION","resource_id":"s-1"}`, false, "/action_type"},
+		{"bad timestamp", `{"user_id":"6f0b6c1e-3c1a-4d2e-9a51-0c2f8e3b7d41","action_type":"LOGIN","resource_type":"SESSION","resource_id":"s-1","timestamp":"yesterday"}`, false, "/timestamp"},
+		{"bad ip", `{"user_id":"6f0b6c1e-3c1a-4d2e-9a51-0c2f8e3b7d41","action_type":"LOGIN","resource_type":"SESSION","resource_id":"s-1","ip_address":"10.0.0"}`, false, "/ip_address"},
+		{"metadata array", `{"user_id":"6f0b6c1e-3c1a-4d2e-9a51-0c2f8e3b7d41","action_type":"LOGIN","resource_type":"SESSION","resource_id":"s-1","metadata":[1]}`, false, "/metadata"},
+		{"unknown field", `{"user_id":"6f0b6c1e-3c1a-4d2e-9a51-0c2f8e3b7d41","action_type":"LOGIN","resource_type":"SESSION","resource_id":"s-1","amount":5}`, false, ""},
+		{"not json", `{"user_id":`, false, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := v.Validate([]byte(tt.payload))
+			if tt.valid {
+				assert.NoError(t, err)
+				return
+			}
+			require.ErrorIs(t, err, schema.ErrInvalid)
+			var verr *schema.ValidationError
+			require.True(t, errors.As(err, &verr))
+			require.NotEmpty(t, verr.Violations)
+			if tt.field != "" {
+				assert.Equal(t, tt.field, verr.Violations[0].Field, verr.Error())
+			}
+		})
+	}
+}
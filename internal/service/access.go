@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/banking/audit-compliance/internal/auth"
+	"github.com/banking/audit-compliance/internal/domain"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// recordAccess writes the audit-of-audits entry for a read of audit data. It fails
+// closed: a read without a caller identity, or one that cannot be recorded, is
+// withheld from the caller.
+func (s *AuditService) recordAccess(ctx context.Context, accessType string, filter interface{}, records int) error {
+	id, ok := auth.FromContext(ctx)
+	if !ok {
+		return auth.ErrNoIdentity
+	}
+
+	queryFilter, err := json.Marshal(filter)
+	if err != nil {
+		return fmt.Errorf("failed to encode access filter: %w", err)
+	}
+
+	entry := &domain.AuditAccessLog{
+		AccessID:         uuid.New(),
+		AccessorID:       id.AccessorID,
+		AccessorSubject:  id.Subject,
+		AccessorRole:     id.Role,
+		AccessType:       accessType,
+		QueryFilter:      string(queryFilter),
+		RecordsViewed:    records,
+		IPAddress:        id.IPAddress,
+		Timestamp:        time.Now().UTC(),
+		Purpose:          id.Purpose.String(),
+		PurposeCode:      id.Purpose.Code,
+		PurposeReference: id.Purpose.Reference,
+	}
+	if err := s.accessLogRepo.LogAccess(ctx, entry); err != nil {
+		s.logger.Error("Failed to record audit data access; withholding result",
+			zap.String("accessor", id.Subject),
+			zap.String("access_type", accessType),
+			zap.Error(err),
+		)
+		return fmt.Errorf("failed to record access: %w", err)
+	}
+	return nil
+}
+
+// SearchAccessLogs searches the access log. The search is itself recorded.
+func (s *AuditService) SearchAccessLogs(ctx context.Context, filter domain.AccessLogFilter) ([]*domain.AuditAccessLog, error) {
+	entries, err := s.accessLogRepo.SearchAccessLogs(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.recordAccess(ctx, domain.AccessTypeSearch, filter, len(entries)); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -10,62 +11,67 @@ import (
 	"github.com/banking/audit-compliance/internal/repository/elasticsearch"
 	"github.com/banking/audit-compliance/internal/repository/postgres"
 	"github.com/banking/audit-compliance/internal/repository/s3"
+	"github.com/banking/audit-compliance/internal/timestamp"
 	"github.com/google/uuid"
 	"go.uber.org/zap"
 )
 
+var (
+	// ErrEventNotFound is returned when a requested audit event does not exist
+	ErrEventNotFound = errors.New("event not found")
+	// ErrIntegrityFailure is returned when a stored event no longer matches its signature
+	// or hash-chain link
+	ErrIntegrityFailure = errors.New("audit integrity failure")
+	// ErrInvalidFilter is returned when a filter value is rejected by the ledger store
+	ErrInvalidFilter = postgres.ErrInvalidFilter
+)
+
 type AuditService struct {
-	pgRepo    *postgres.AuditRepository
-	esRepo    *elasticsearch.SearchRepository
-	s3Repo    *s3.ArchiveRepository
-	encryptor *crypto.FieldEncryptor
-	logger    *zap.Logger
+	pgRepo         *postgres.AuditRepository
+	checkpointRepo *postgres.CheckpointRepository
+	accessLogRepo  *postgres.AccessLogRepository
+	esRepo         *elasticsearch.SearchRepository
+	s3Repo         *s3.ArchiveRepository
+	encryptor      *crypto.FieldEncryptor
+	keyring        *crypto.Keyring
+	tsa            *timestamp.Client // nil when trusted timestamping is disabled
+	logger         *zap.Logger
 }
 
 func NewAuditService(
 	pgRepo *postgres.AuditRepository,
+	checkpointRepo *postgres.CheckpointRepository,
+	accessLogRepo *postgres.AccessLogRepository,
 	esRepo *elasticsearch.SearchRepository,
 	s3Repo *s3.ArchiveRepository,
 	encryptor *crypto.FieldEncryptor,
+	keyring *crypto.Keyring,
+	tsa *timestamp.Client,
 	logger *zap.Logger,
 ) *AuditService {
 	return &AuditService{
-		pgRepo:    pgRepo,
-		esRepo:    esRepo,
-		s3Repo:    s3Repo,
-		encryptor: encryptor,
-		logger:    logger,
+		pgRepo:         pgRepo,
+		checkpointRepo: checkpointRepo,
+		accessLogRepo:  accessLogRepo,
+		esRepo:         esRepo,
+		s3Repo:         s3Repo,
+		encryptor:      encryptor,
+		keyring:        keyring,
+		tsa:            tsa,
+		logger:         logger,
 	}
 }
 
 // ProcessAndStoreEvent is the main entry point for ingesting audit events
 func (s *AuditService) ProcessAndStoreEvent(ctx context.Context, event *domain.AuditEvent) error {
-	// 1. Ensure IDs and Timestamps
-	if event.EventID == uuid.Nil {
-		event.EventID = uuid.New()
-	}
-	if event.CreatedAt.IsZero() {
-		event.CreatedAt = time.Now().UTC()
+	if err := s.prepareEvent(event); err != nil {
+		return err
 	}
-	if event.Timestamp.IsZero() {
-		event.Timestamp = event.CreatedAt
-	}
-
-	// 2. Cryptographic Signing
-	// Sign critical fields to ensure non-repudiation
-	sig := s.encryptor.GenerateDigitalSignature(
-		event.EventID.String(),
-		event.UserID.String(),
-		string(event.ActionType),
-		event.Timestamp.Format(time.RFC3339),
-		string(event.Result),
-	)
-	event.DigitalSignature = sig
-	event.EncryptionKeyID = s.encryptor.CurrentKeyVersion()
 
 	// 3. Store in Immutable Ledger (PostgreSQL) - Critical Path
 	// This must succeed. If this fails, we cannot proceed.
-	if err := s.pgRepo.CreateEvent(ctx, event); err != nil {
+	// The repository links the event into the hash chain inside the same transaction.
+	if err := s.pgRepo.CreateEvent(ctx, event, s.signEvent); err != nil {
 		s.logger.Error("Failed to persist audit event to ledger",
 			zap.String("event_id", event.EventID.String()),
 			zap.Error(err),
@@ -86,6 +92,39 @@ func (s *AuditService) ProcessAndStoreEvent(ctx context.Context, event *domain.A
 	return nil
 }
 
+// prepareEvent fills in the ID and times of event. The ledger assigns CreatedAt when it
+// appends the event, and signEvent then signs it.
+func (s *AuditService) prepareEvent(event *domain.AuditEvent) error {
+	// 1. Ensure IDs and Timestamps
+	if event.EventID == uuid.Nil {
+		event.EventID = uuid.New()
+	}
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now().UTC()
+	}
+	// Normalize to the precision PostgreSQL stores so signatures and chain hashes
+	// computed now still match the row when it is read back
+	event.Timestamp = event.Timestamp.UTC().Truncate(time.Microsecond)
+	event.EncryptionKeyID = s.encryptor.CurrentKeyVersion()
+	return nil
+}
+
+// signEvent signs every persisted content field of event, including the CreatedAt the
+// ledger assigned, to ensure non-repudiation
+func (s *AuditService) signEvent(event *domain.AuditEvent) error {
+	signer := s.keyring.Active()
+	event.SignatureVersion = domain.CurrentSignatureVersion
+	event.SignatureAlgorithm = signer.Algorithm()
+	event.SigningKeyID = signer.KeyID()
+	payload, _ := event.SignaturePayload(event.SignatureVersion)
+	sig, err := signer.Sign(payload)
+	if err != nil {
+		return fmt.Errorf("failed to sign audit event: %w", err)
+	}
+	event.DigitalSignature = sig
+	return nil
+}
+
 // asyncIndexEvent handles background indexing with panic protection
 func (s *AuditService) asyncIndexEvent(event *domain.AuditEvent) {
 	go func() {
@@ -111,12 +150,26 @@ func (s *AuditService) asyncIndexEvent(event *domain.AuditEvent) {
 
 // GetAuditTrail retrieves the full history for a transaction or entity
 func (s *AuditService) GetAuditTrail(ctx context.Context, filter domain.AuditEventFilter) (*domain.AuditEventPage, error) {
+	page, err := s.pgRepo.GetEvents(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	// The access is recorded before verification so reads of tampered events are logged too
+	if err := s.recordAccess(ctx, domain.AccessTypeView, filter, len(page.Events)); err != nil {
+		return nil, err
+	}
+	if err := s.verifyEvents(page.Events); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// getAuditTrail reads and verifies events without recording the access
+func (s *AuditService) getAuditTrail(ctx context.Context, filter domain.AuditEventFilter) (*domain.AuditEventPage, error) {
 	// 1. Try to search in Elasticsearch for performance if it's a complex query
 	// Ideally, recent/simple queries go to DB, text search/aggregations go to ES.
 	// For now, let's route essentially everything to DB for strong consistency assurance
 	// unless it's a full-text search scenario which isn't strictly defined in filter yet.
-	// Actually, the requirement says "Fast search", so let's default to DB for now for simplicity of "Get"
-	// and use ES for "Search".
 
 	// Direct DB access for Audit Trail to ensure we see the immutable truth
 	page, err := s.pgRepo.GetEvents(ctx, filter)
@@ -124,56 +177,142 @@ func (s *AuditService) GetAuditTrail(ctx context.Context, filter domain.AuditEve
 		return nil, err
 	}
 
-	// Verify signatures for the retrieved events (On-the-fly verification)
-	for _, event := range page.Events {
-		valid := s.encryptor.VerifyDigitalSignature(
-			event.EventID.String(),
-			event.UserID.String(),
-			string(event.ActionType),
-			event.Timestamp.Format(time.RFC3339),
-			string(event.Result),
-			event.DigitalSignature,
-		)
-		if !valid {
-			s.logger.Error("CRYPTOGRAPHIC VALIDATION FAILURE",
-				zap.String("event_id", event.EventID.String()),
-				zap.String("reason", "Signature mismatch - POTENTIAL TAMPERING DETECTED"),
-			)
-			// In production, this might trigger a massive alert or panic the service
-			// For now, we log Error instead of Fatal so the service kept running
-			return nil, fmt.Errorf("audit integrity failure: event %s signature invalid", event.EventID)
+	if err := s.verifyEvents(page.Events); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// verifyEvents verifies the retrieved events on the fly. Canonical signatures cover every
+// content field and the record hash covers the signature and chain position, so an edit
+// to any column is detected; legacy rows are only covered by their five signed fields
+// and the chain.
+func (s *AuditService) verifyEvents(events []*domain.AuditEvent) error {
+	for _, event := range events {
+		if err := s.verifyEvent(event); err != nil {
+			return err
 		}
 	}
+	return nil
+}
+
+// verifyEvent checks the event's signature and record hash, returning ErrIntegrityFailure
+// when either does not match
+func (s *AuditService) verifyEvent(event *domain.AuditEvent) error {
+	if !s.verifySignature(event) {
+		s.logger.Error("CRYPTOGRAPHIC VALIDATION FAILURE",
+			zap.String("event_id", event.EventID.String()),
+			zap.String("reason", "Signature mismatch - POTENTIAL TAMPERING DETECTED"),
+		)
+		// In production, this might trigger a massive alert or panic the service
+		// For now, we log Error instead of Fatal so the service kept running
+		return fmt.Errorf("%w: event %s signature invalid", ErrIntegrityFailure, event.EventID)
+	}
 
-	return page, nil
+	if !s.verifyRecordHash(event) {
+		s.logger.Error("CRYPTOGRAPHIC VALIDATION FAILURE",
+			zap.String("event_id", event.EventID.String()),
+			zap.Int64("sequence_num", event.SequenceNum),
+			zap.String("reason", "Record hash mismatch - POTENTIAL TAMPERING DETECTED"),
+		)
+		return fmt.Errorf("%w: event %s record hash invalid", ErrIntegrityFailure, event.EventID)
+	}
+	return nil
 }
 
-// SearchEvents uses Elasticsearch for broader queries
-func (s *AuditService) SearchEvents(ctx context.Context, query string, from, size int) (*domain.AuditEventPage, error) {
-	return s.esRepo.SearchEvents(ctx, query, from, size)
+// GetEvent returns a single verified event
+func (s *AuditService) GetEvent(ctx context.Context, eventID uuid.UUID) (*domain.AuditEvent, error) {
+	return s.getEventRecorded(ctx, eventID, domain.AccessTypeView)
+}
+
+// getEventRecorded reads one verified event and records the access as accessType,
+// including lookups of events that do not exist or fail verification
+func (s *AuditService) getEventRecorded(ctx context.Context, eventID uuid.UUID, accessType string) (*domain.AuditEvent, error) {
+	filter := domain.AuditEventFilter{EventID: &eventID, Limit: 1}
+	page, err := s.pgRepo.GetEvents(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.recordAccess(ctx, accessType, filter, len(page.Events)); err != nil {
+		return nil, err
+	}
+	if len(page.Events) == 0 {
+		return nil, ErrEventNotFound
+	}
+	if err := s.verifyEvent(page.Events[0]); err != nil {
+		return nil, err
+	}
+	return page.Events[0], nil
 }
 
-// VerifyEventIntegrity allows manual verification of a specific event
-func (s *AuditService) VerifyEventIntegrity(ctx context.Context, eventID string) (bool, error) {
-	uuidVal, err := uuid.Parse(eventID)
+// PublicSigningKeys returns the key set external auditors use to verify exported events
+func (s *AuditService) PublicSigningKeys() crypto.PublicKeySet {
+	return s.keyring.PublicKeys()
+}
+
+// GetEventAttestation returns a verified event together with the exact payload its
+// signature covers, for offline verification against PublicSigningKeys
+func (s *AuditService) GetEventAttestation(ctx context.Context, eventID uuid.UUID) (*domain.EventAttestation, error) {
+	event, err := s.getEventRecorded(ctx, eventID, domain.AccessTypeExport)
 	if err != nil {
-		return false, fmt.Errorf("invalid event ID: %w", err)
+		return nil, err
 	}
 
-	filter := domain.AuditEventFilter{
-		EventID: &uuidVal,
-		Limit:   1,
+	payload, _ := event.SignaturePayload(event.SignatureVersion)
+
+	return &domain.EventAttestation{
+		Event:              event,
+		SignatureVersion:   event.SignatureVersion,
+		SignatureAlgorithm: event.SignatureAlgorithm,
+		SigningKeyID:       event.SigningKeyID,
+		Signature:          event.DigitalSignature,
+		SignedPayload:      payload,
+	}, nil
+}
+
+// verifySignature checks the event's digital signature against the fields covered by its
+// signature version. Rows signed before full-field coverage still verify as version 1.
+func (s *AuditService) verifySignature(event *domain.AuditEvent) bool {
+	payload, ok := event.SignaturePayload(event.SignatureVersion)
+	if !ok {
+		return false
 	}
+	return s.keyring.Verify(event.SignatureAlgorithm, event.SigningKeyID, event.SequenceNum, payload, event.DigitalSignature)
+}
+
+// verifyRecordHash checks that the stored record hash matches the event and its chain link
+func (s *AuditService) verifyRecordHash(event *domain.AuditEvent) bool {
+	return s.encryptor.VerifyHashChain(event.PrevHash, event.CanonicalRecord(), event.RecordHash)
+}
 
-	page, err := s.GetAuditTrail(ctx, filter)
+// SearchEvents uses Elasticsearch for broader queries
+func (s *AuditService) SearchEvents(ctx context.Context, query string, from, size int) (*domain.AuditEventPage, error) {
+	page, err := s.esRepo.SearchEvents(ctx, query, from, size)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
+	filter := map[string]interface{}{"query": query, "from": from, "size": size}
+	if err := s.recordAccess(ctx, domain.AccessTypeSearch, filter, len(page.Events)); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
 
+// VerifyEventIntegrity reads and verifies one event, recording the access as a
+// verification. An event that fails verification is returned with ErrIntegrityFailure,
+// so callers can still apply their access scope before disclosing the result.
+func (s *AuditService) VerifyEventIntegrity(ctx context.Context, eventID uuid.UUID) (*domain.AuditEvent, error) {
+	filter := domain.AuditEventFilter{EventID: &eventID, Limit: 1}
+	page, err := s.pgRepo.GetEvents(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.recordAccess(ctx, domain.AccessTypeVerify, filter, len(page.Events)); err != nil {
+		return nil, err
+	}
 	if len(page.Events) == 0 {
-		return false, fmt.Errorf("event not found")
+		return nil, ErrEventNotFound
 	}
-
-	// GetAuditTrail already verifies signature
-	return true, nil
+	event := page.Events[0]
+	return event, s.verifyEvent(event)
 }
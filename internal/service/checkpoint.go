@@ -0,0 +1,384 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/banking/audit-compliance/internal/crypto"
+	"github.com/banking/audit-compliance/internal/domain"
+	"github.com/banking/audit-compliance/internal/repository/postgres"
+	"github.com/banking/audit-compliance/internal/timestamp"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+var (
+	// ErrCheckpointNotFound is returned when no checkpoint covers the requested tree size or event
+	ErrCheckpointNotFound = postgres.ErrCheckpointNotFound
+	// ErrLedgerEmpty is returned when a checkpoint is requested before any event was stored
+	ErrLedgerEmpty = errors.New("ledger is empty")
+	// ErrInvalidProofRequest is returned for tree sizes that cannot form a proof
+	ErrInvalidProofRequest = errors.New("invalid proof request")
+)
+
+// CreateCheckpoint signs a Merkle tree head over the whole chain. The tree is extended
+// from the nodes stored with the previous checkpoint, which must still hash to its signed
+// root and end in the leaf the ledger continues from, so a rewritten history is refused
+// rather than silently re-signed. Only the events appended since are read.
+func (s *AuditService) CreateCheckpoint(ctx context.Context) (*domain.LedgerCheckpoint, error) {
+	treeSize, _, err := s.pgRepo.GetChainHead(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if treeSize == 0 {
+		return nil, ErrLedgerEmpty
+	}
+
+	latest, err := s.checkpointRepo.GetLatest(ctx)
+	if err != nil && !errors.Is(err, ErrCheckpointNotFound) {
+		return nil, err
+	}
+	if latest != nil && latest.TreeSize == treeSize {
+		return latest, nil
+	}
+
+	tree, err := s.resumeMerkleTree(ctx, latest)
+	if err != nil {
+		return nil, err
+	}
+	var nodes []crypto.MerkleNode
+	err = s.streamLeaves(ctx, tree.Size()+1, treeSize, func(leaf []byte) error {
+		nodes = append(nodes, tree.Append(leaf)...)
+		if latest != nil && tree.Size() == latest.TreeSize {
+			// Rebuilding a tree checkpointed before nodes were stored
+			return s.checkCheckpointRoot(latest, tree.Root())
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	cp := &domain.LedgerCheckpoint{
+		CheckpointID: uuid.New(),
+		TreeSize:     treeSize,
+		RootHash:     hex.EncodeToString(tree.Root()),
+		CreatedAt:    time.Now().UTC().Truncate(time.Microsecond),
+	}
+
+	signer := s.keyring.Active()
+	sig, err := signer.Sign(cp.SignedPayload())
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign checkpoint: %w", err)
+	}
+	cp.Signature = sig
+	cp.SignatureAlgorithm = signer.Algorithm()
+	cp.SigningKeyID = signer.KeyID()
+
+	// Checkpoints are append-only, so the token must be obtained before the insert.
+	// A TSA outage fails this checkpoint and the next scheduled run retries.
+	if s.tsa != nil {
+		token, info, err := s.tsa.Timestamp(ctx, cp.TimestampDigest())
+		if err != nil {
+			return nil, fmt.Errorf("failed to timestamp checkpoint: %w", err)
+		}
+		genTime := info.GenTime.UTC().Truncate(time.Microsecond)
+		cp.TimestampToken = token
+		cp.TimestampedAt = &genTime
+	}
+
+	if err := s.checkpointRepo.CreateCheckpoint(ctx, cp, nodes); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Ledger checkpoint created",
+		zap.Int64("tree_size", cp.TreeSize),
+		zap.String("root_hash", cp.RootHash),
+	)
+	return cp, nil
+}
+
+// GetCheckpoint returns the checkpoint for treeSize, or the latest one when treeSize is 0
+func (s *AuditService) GetCheckpoint(ctx context.Context, treeSize int64) (*domain.LedgerCheckpoint, error) {
+	if treeSize == 0 {
+		return s.checkpointRepo.GetLatest(ctx)
+	}
+	return s.checkpointRepo.GetByTreeSize(ctx, treeSize)
+}
+
+// GetInclusionProof proves that an event is part of a checkpointed tree. A zero treeSize
+// uses the latest checkpoint.
+func (s *AuditService) GetInclusionProof(ctx context.Context, eventID uuid.UUID, treeSize int64) (*domain.InclusionProof, error) {
+	event, err := s.getEventRecorded(ctx, eventID, domain.AccessTypeVerify)
+	if err != nil {
+		return nil, err
+	}
+
+	cp, err := s.GetCheckpoint(ctx, treeSize)
+	if err != nil {
+		return nil, err
+	}
+	if cp.TreeSize < event.SequenceNum {
+		return nil, fmt.Errorf("%w: event %s is not covered by checkpoint of size %d", ErrInvalidProofRequest, eventID, cp.TreeSize)
+	}
+
+	index := event.SequenceNum - 1
+	hashes, err := s.subtreeHashes(ctx, append(
+		[]crypto.MerkleSubtree{{Start: 0, End: cp.TreeSize}},
+		crypto.MerkleInclusionSubtrees(index, cp.TreeSize)...,
+	))
+	if err != nil {
+		return nil, err
+	}
+	root, path := hashes[0], hashes[1:]
+	if err := s.checkCheckpointRoot(cp, root); err != nil {
+		return nil, err
+	}
+
+	recordHash, err := hex.DecodeString(event.RecordHash)
+	if err != nil {
+		return nil, fmt.Errorf("audit integrity failure: malformed record hash at sequence %d", event.SequenceNum)
+	}
+	leaf := crypto.MerkleLeafHash(recordHash)
+	if !crypto.VerifyMerkleInclusion(index, cp.TreeSize, leaf, path, root) {
+		return nil, fmt.Errorf("audit integrity failure: event %s diverges from checkpoint %d", eventID, cp.TreeSize)
+	}
+
+	return &domain.InclusionProof{
+		EventID:    event.EventID,
+		LeafIndex:  index,
+		LeafHash:   hex.EncodeToString(leaf),
+		AuditPath:  encodeHashes(path),
+		Checkpoint: cp,
+	}, nil
+}
+
+// GetConsistencyProof proves that the checkpoint of size first is a prefix of the one of size second
+func (s *AuditService) GetConsistencyProof(ctx context.Context, first, second int64) (*domain.ConsistencyProof, error) {
+	if first <= 0 || first > second {
+		return nil, fmt.Errorf("%w: need 0 < first <= second", ErrInvalidProofRequest)
+	}
+
+	firstCP, err := s.checkpointRepo.GetByTreeSize(ctx, first)
+	if err != nil {
+		return nil, err
+	}
+	secondCP, err := s.checkpointRepo.GetByTreeSize(ctx, second)
+	if err != nil {
+		return nil, err
+	}
+
+	hashes, err := s.subtreeHashes(ctx, append(
+		[]crypto.MerkleSubtree{{Start: 0, End: first}, {Start: 0, End: second}},
+		crypto.MerkleConsistencySubtrees(first, second)...,
+	))
+	if err != nil {
+		return nil, err
+	}
+	firstRoot, secondRoot, proof := hashes[0], hashes[1], hashes[2:]
+	if err := s.checkCheckpointRoot(secondCP, secondRoot); err != nil {
+		return nil, err
+	}
+	if err := s.checkCheckpointRoot(firstCP, firstRoot); err != nil {
+		return nil, err
+	}
+	if !crypto.VerifyMerkleConsistency(first, second, firstRoot, secondRoot, proof) {
+		return nil, fmt.Errorf("audit integrity failure: checkpoint %d is not a prefix of checkpoint %d", first, second)
+	}
+
+	return &domain.ConsistencyProof{
+		First:  firstCP,
+		Second: secondCP,
+		Proof:  encodeHashes(proof),
+	}, nil
+}
+
+// RunCheckpointing creates a checkpoint every interval until ctx is cancelled
+func (s *AuditService) RunCheckpointing(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		s.logger.Warn("Scheduled ledger checkpoints disabled")
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.CreateCheckpoint(ctx); err != nil && !errors.Is(err, ErrLedgerEmpty) {
+				s.logger.Error("Scheduled ledger checkpoint failed", zap.Error(err))
+			}
+		}
+	}
+}
+
+// resumeMerkleTree returns the tree of the latest checkpoint from its stored right edge,
+// after checking that it still hashes to the signed root and that the ledger still holds
+// its last leaf. As every record hash chains over all the records before it, that leaf
+// pins the checkpointed history. A checkpoint taken before nodes were stored yields an
+// empty tree, to be rebuilt from the ledger.
+func (s *AuditService) resumeMerkleTree(ctx context.Context, latest *domain.LedgerCheckpoint) (*crypto.MerkleFrontier, error) {
+	if latest == nil {
+		return crypto.NewMerkleFrontier(0, nil)
+	}
+
+	last := crypto.MerkleNodeID{Level: 0, Index: latest.TreeSize - 1}
+	edge := crypto.MerkleSubtree{Start: 0, End: latest.TreeSize}.Nodes()
+	stored, err := s.checkpointRepo.GetMerkleNodes(ctx, append(edge, last))
+	if err != nil {
+		return nil, err
+	}
+	hashes := make([][]byte, len(edge))
+	for i, id := range edge {
+		if hashes[i] = stored[id]; hashes[i] == nil {
+			return crypto.NewMerkleFrontier(0, nil)
+		}
+	}
+	tree, err := crypto.NewMerkleFrontier(latest.TreeSize, hashes)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkCheckpointRoot(latest, tree.Root()); err != nil {
+		return nil, err
+	}
+
+	var lastLeaf []byte
+	err = s.streamLeaves(ctx, latest.TreeSize, latest.TreeSize, func(leaf []byte) error {
+		lastLeaf = leaf
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(lastLeaf, stored[last]) {
+		s.logger.Error("CRYPTOGRAPHIC VALIDATION FAILURE",
+			zap.Int64("tree_size", latest.TreeSize),
+			zap.String("reason", "Ledger no longer matches checkpoint root - HISTORY REWRITTEN"),
+		)
+		return nil, fmt.Errorf("audit integrity failure: ledger diverges from checkpoint %d", latest.TreeSize)
+	}
+	return tree, nil
+}
+
+// subtreeHashes hashes each subtree of the checkpointed tree from its stored perfect
+// subtrees. Nodes checkpointed before nodes were stored are hashed from the ledger.
+func (s *AuditService) subtreeHashes(ctx context.Context, subtrees []crypto.MerkleSubtree) ([][]byte, error) {
+	var ids []crypto.MerkleNodeID
+	for _, st := range subtrees {
+		ids = append(ids, st.Nodes()...)
+	}
+	stored, err := s.checkpointRepo.GetMerkleNodes(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	hashes := make([][]byte, len(subtrees))
+	for i, st := range subtrees {
+		var nodeHashes [][]byte
+		for _, id := range st.Nodes() {
+			if stored[id] == nil {
+				first := id.Index<<id.Level + 1
+				tree, err := crypto.NewMerkleFrontier(0, nil)
+				if err != nil {
+					return nil, err
+				}
+				err = s.streamLeaves(ctx, first, first+int64(1)<<id.Level-1, func(leaf []byte) error {
+					tree.Append(leaf)
+					return nil
+				})
+				if err != nil {
+					return nil, err
+				}
+				stored[id] = tree.Root()
+			}
+			nodeHashes = append(nodeHashes, stored[id])
+		}
+		hashes[i] = crypto.MerkleFold(nodeHashes)
+	}
+	return hashes, nil
+}
+
+// streamLeaves calls fn with the Merkle leaf of every chain position in [first, last], in order
+func (s *AuditService) streamLeaves(ctx context.Context, first, last int64, fn func(leaf []byte) error) error {
+	next := first
+	err := s.pgRepo.StreamRecordHashes(ctx, first, last, func(seq int64, hash string) error {
+		if seq != next {
+			return fmt.Errorf("audit integrity failure: sequence %d missing from the chain", next)
+		}
+		raw, err := hex.DecodeString(hash)
+		if err != nil {
+			return fmt.Errorf("audit integrity failure: malformed record hash at sequence %d", seq)
+		}
+		next++
+		return fn(crypto.MerkleLeafHash(raw))
+	})
+	if err != nil {
+		return err
+	}
+	if next <= last {
+		return fmt.Errorf("audit integrity failure: ledger holds %d of %d chained events", next-1, last)
+	}
+	return nil
+}
+
+// checkCheckpointRoot verifies a checkpoint's signature and that its root matches the tree
+func (s *AuditService) checkCheckpointRoot(cp *domain.LedgerCheckpoint, root []byte) error {
+	if !s.verifyCheckpointSignature(cp) {
+		s.logger.Error("CRYPTOGRAPHIC VALIDATION FAILURE",
+			zap.Int64("tree_size", cp.TreeSize),
+			zap.String("reason", "Checkpoint signature mismatch - POTENTIAL TAMPERING DETECTED"),
+		)
+		return fmt.Errorf("audit integrity failure: checkpoint %d signature invalid", cp.TreeSize)
+	}
+	if hex.EncodeToString(root) != cp.RootHash {
+		s.logger.Error("CRYPTOGRAPHIC VALIDATION FAILURE",
+			zap.Int64("tree_size", cp.TreeSize),
+			zap.String("reason", "Ledger no longer matches checkpoint root - HISTORY REWRITTEN"),
+		)
+		return fmt.Errorf("audit integrity failure: ledger diverges from checkpoint %d", cp.TreeSize)
+	}
+	return nil
+}
+
+// verifyCheckpointTimestamp checks a checkpoint's RFC 3161 token and that the stored
+// tsa_time is the token's genTime. Checkpoints without a token are accepted unless the
+// TSA client requires one; without a TSA client a token is timestamp.ErrUnverifiable.
+func (s *AuditService) verifyCheckpointTimestamp(cp *domain.LedgerCheckpoint) error {
+	if len(cp.TimestampToken) == 0 {
+		if s.tsa != nil && s.tsa.Required() {
+			return errors.New("trusted timestamp missing")
+		}
+		return nil
+	}
+	if s.tsa == nil {
+		return timestamp.ErrUnverifiable
+	}
+	info, err := s.tsa.Verify(cp.TimestampToken, cp.TimestampDigest())
+	if err != nil {
+		return err
+	}
+	if cp.TimestampedAt == nil || !cp.TimestampedAt.Equal(info.GenTime.UTC().Truncate(time.Microsecond)) {
+		return errors.New("stored tsa_time does not match the timestamp token")
+	}
+	return nil
+}
+
+// verifyCheckpointSignature checks a checkpoint's signature with the keyring
+func (s *AuditService) verifyCheckpointSignature(cp *domain.LedgerCheckpoint) bool {
+	return s.keyring.Verify(cp.SignatureAlgorithm, cp.SigningKeyID, cp.TreeSize, cp.SignedPayload(), cp.Signature)
+}
+
+func encodeHashes(hashes [][]byte) []string {
+	encoded := make([]string, len(hashes))
+	for i, h := range hashes {
+		encoded[i] = hex.EncodeToString(h)
+	}
+	return encoded
+}
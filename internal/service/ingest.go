@@ -0,0 +1,210 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/banking/audit-compliance/internal/auth"
+	"github.com/banking/audit-compliance/internal/domain"
+	"github.com/banking/audit-compliance/internal/repository/postgres"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+var (
+	// ErrIdempotencyKeyReused is returned when an idempotency key is sent again with a
+	// different request
+	ErrIdempotencyKeyReused = postgres.ErrIdempotencyKeyReused
+	// ErrDuplicateEvent is returned when a submitted event ID is already in the ledger
+	ErrDuplicateEvent = postgres.ErrDuplicateEvent
+	// ErrConflictingEvent is returned when an event ID already in the ledger is submitted
+	// again with different content
+	ErrConflictingEvent = errors.New("event ID already recorded with different content")
+)
+
+// idempotencyNamespace derives event IDs from a caller's idempotency key
+var idempotencyNamespace = uuid.MustParse("2c8e4a61-9d3b-5f07-8e1a-4b6c0d2f9a35")
+
+// RecordEvents stores events synchronously, in order, and returns them signed and linked
+// into the hash chain. With an idempotency key, each event's ID is derived from the
+// caller, the key, the request and its position, so a retry of the same request returns
+// the events recorded the first time, and completes any it did not reach, instead of
+// duplicating them. A key reused after it expires records the new request afresh.
+// replayed reports whether any event was already recorded.
+func (s *AuditService) RecordEvents(ctx context.Context, idempotencyKey, requestHash string, events []*domain.AuditEvent) (recorded []*domain.AuditEvent, replayed bool, err error) {
+	if idempotencyKey == "" {
+		for _, event := range events {
+			if err := s.ProcessAndStoreEvent(ctx, event); err != nil {
+				return nil, false, err
+			}
+		}
+		return events, false, nil
+	}
+
+	id, ok := auth.FromContext(ctx)
+	if !ok {
+		return nil, false, auth.ErrNoIdentity
+	}
+	for _, event := range events {
+		if event.EventID != uuid.Nil {
+			return nil, false, fmt.Errorf("%w: event_id cannot be combined with an idempotency key", domain.ErrInvalidEvent)
+		}
+	}
+	if _, err := s.pgRepo.ClaimIdempotencyKey(ctx, id.Subject, idempotencyKey, requestHash); err != nil {
+		return nil, false, err
+	}
+
+	recorded = make([]*domain.AuditEvent, len(events))
+	for i, event := range events {
+		event.EventID = uuid.NewSHA1(idempotencyNamespace, []byte(id.Subject+"\x00"+idempotencyKey+"\x00"+requestHash+"\x00"+strconv.Itoa(i)))
+
+		stored, err := s.storedEvent(ctx, event.EventID)
+		if err != nil {
+			return nil, false, err
+		}
+		if stored == nil {
+			err = s.ProcessAndStoreEvent(ctx, event)
+			if err == nil {
+				recorded[i] = event
+				continue
+			}
+			if !errors.Is(err, ErrDuplicateEvent) {
+				return nil, false, err
+			}
+			// A concurrent retry recorded it first
+			if stored, err = s.storedEvent(ctx, event.EventID); err != nil {
+				return nil, false, err
+			}
+			if stored == nil {
+				return nil, false, fmt.Errorf("%w: %s", ErrDuplicateEvent, event.EventID)
+			}
+		}
+		recorded[i] = stored
+		replayed = true
+	}
+	return recorded, replayed, nil
+}
+
+// StoreEventOnce records event unless its ID is already in the ledger, for producers that
+// deliver at least once. A redelivery with the same submitted content is a success and
+// reports duplicate. Different content under a recorded ID is raised as a tamper alert
+// and returns ErrConflictingEvent.
+func (s *AuditService) StoreEventOnce(ctx context.Context, event *domain.AuditEvent) (duplicate bool, err error) {
+	err = s.ProcessAndStoreEvent(ctx, event)
+	if !errors.Is(err, ErrDuplicateEvent) {
+		return false, err
+	}
+
+	if err := s.checkRedelivery(ctx, event); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// checkRedelivery compares a redelivered event with the recorded one, raising a tamper
+// alert and returning ErrConflictingEvent when their content differs
+func (s *AuditService) checkRedelivery(ctx context.Context, event *domain.AuditEvent) error {
+	stored, err := s.storedEvent(ctx, event.EventID)
+	if err != nil {
+		return err
+	}
+	if stored == nil {
+		return fmt.Errorf("%w: %s is indexed but not readable", ErrDuplicateEvent, event.EventID)
+	}
+	if !event.IsRedeliveryOf(stored) {
+		s.logger.Error("TAMPER ALERT",
+			zap.String("event_id", event.EventID.String()),
+			zap.Int64("sequence_num", stored.SequenceNum),
+			zap.String("service_source", event.ServiceSource),
+			zap.String("reason", "Event ID redelivered with different content - POTENTIAL TAMPERING DETECTED"),
+		)
+		return fmt.Errorf("%w: %s", ErrConflictingEvent, event.EventID)
+	}
+	return nil
+}
+
+// StoreResult is the outcome of storing one event of a batch
+type StoreResult struct {
+	Duplicate bool  // Already in the ledger with the same content
+	Err       error // ErrConflictingEvent, already raised as a tamper alert
+}
+
+// StoreEventsOnce is StoreEventOnce for a batch: events are appended to the chain in
+// order in a single transaction. An error fails the whole batch and nothing is recorded;
+// per-event outcomes, including conflicts, are in the results.
+func (s *AuditService) StoreEventsOnce(ctx context.Context, events []*domain.AuditEvent) ([]StoreResult, error) {
+	for _, event := range events {
+		if err := s.prepareEvent(event); err != nil {
+			return nil, err
+		}
+	}
+
+	duplicates, err := s.pgRepo.CreateEvents(ctx, events, s.signEvent)
+	if err != nil {
+		s.logger.Error("Failed to persist audit event batch to ledger",
+			zap.Int("events", len(events)),
+			zap.Error(err),
+		)
+		return nil, fmt.Errorf("ledger persistence failed: %w", err)
+	}
+
+	results := make([]StoreResult, len(events))
+	for i, event := range events {
+		if !duplicates[i] {
+			s.asyncIndexEvent(event)
+			continue
+		}
+		results[i].Duplicate = true
+		if err := s.checkRedelivery(ctx, event); err != nil {
+			if !errors.Is(err, ErrConflictingEvent) {
+				return nil, err
+			}
+			results[i] = StoreResult{Err: err}
+		}
+	}
+	return results, nil
+}
+
+// storedEvent returns the verified ledger copy of an event, or nil when it is not
+// recorded. The lookup returns the caller's own submission and is not an access.
+func (s *AuditService) storedEvent(ctx context.Context, eventID uuid.UUID) (*domain.AuditEvent, error) {
+	page, err := s.getAuditTrail(ctx, domain.AuditEventFilter{EventID: &eventID, Limit: 1})
+	if err != nil {
+		return nil, err
+	}
+	if len(page.Events) == 0 {
+		return nil, nil
+	}
+	return page.Events[0], nil
+}
+
+// RunIdempotencyKeyExpiry deletes idempotency keys older than ttl every interval until ctx
+// is cancelled
+func (s *AuditService) RunIdempotencyKeyExpiry(ctx context.Context, interval, ttl time.Duration) {
+	if interval <= 0 || ttl <= 0 {
+		s.logger.Warn("Idempotency key expiry disabled")
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := s.pgRepo.DeleteExpiredIdempotencyKeys(ctx, time.Now().UTC().Add(-ttl))
+			if err != nil {
+				s.logger.Error("Idempotency key expiry failed", zap.Error(err))
+				continue
+			}
+			if deleted > 0 {
+				s.logger.Info("Expired idempotency keys", zap.Int64("deleted", deleted))
+			}
+		}
+	}
+}
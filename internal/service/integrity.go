@@ -0,0 +1,292 @@
+package service
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/banking/audit-compliance/internal/auth"
+	"github.com/banking/audit-compliance/internal/crypto"
+	"github.com/banking/audit-compliance/internal/domain"
+	"github.com/banking/audit-compliance/internal/timestamp"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+// integrityServiceSource identifies events written by this service about its own ledger
+const integrityServiceSource = "audit-compliance-service"
+
+// VerifyLedger re-verifies every event ingested between from and to. It streams the
+// chain in sequence order, recomputes each signature and record hash, and checks that
+// every prev_hash matches the preceding record.
+func (s *AuditService) VerifyLedger(ctx context.Context, from, to time.Time) (*domain.LedgerVerificationReport, error) {
+	report, err := s.verifyLedger(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	filter := map[string]time.Time{"from": from, "to": to}
+	if err := s.recordAccess(ctx, domain.AccessTypeVerify, filter, int(report.EventsChecked)); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func (s *AuditService) verifyLedger(ctx context.Context, from, to time.Time) (*domain.LedgerVerificationReport, error) {
+	report := &domain.LedgerVerificationReport{
+		From:      from,
+		To:        to,
+		StartedAt: time.Now().UTC(),
+	}
+
+	first, last, err := s.pgRepo.GetSequenceRange(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	report.FirstSequence = first
+	report.LastSequence = last
+
+	if first > 0 {
+		// Anchor on the record just before the range so its first link is checked too
+		expectedPrev, err := s.pgRepo.GetRecordHash(ctx, first-1)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("failed to read chain anchor: %w", err)
+		}
+		if errors.Is(err, pgx.ErrNoRows) {
+			report.MissingSequences = append(report.MissingSequences, domain.SequenceGap{From: first - 1, To: first - 1})
+		}
+		expectedSeq := first
+
+		// skip accounts for positions [from, to] absent from the ledger
+		skip := func(from, to int64) error {
+			archived, err := s.pgRepo.CountArchivedSequences(ctx, from, to)
+			if err != nil {
+				return err
+			}
+			if archived != to-from+1 {
+				report.MissingSequences = append(report.MissingSequences, domain.SequenceGap{From: from, To: to})
+				return nil
+			}
+			// Rows left with a detached partition; their chain positions remain indexed
+			report.ArchivedSequences += archived
+			expectedPrev, err = s.pgRepo.GetRecordHash(ctx, to)
+			if err != nil {
+				return fmt.Errorf("failed to read archived chain position: %w", err)
+			}
+			return nil
+		}
+
+		err = s.pgRepo.StreamChain(ctx, first, last, func(event *domain.AuditEvent) error {
+			report.EventsChecked++
+
+			if event.SequenceNum != expectedSeq {
+				if err := skip(expectedSeq, event.SequenceNum-1); err != nil {
+					return err
+				}
+			}
+			if expectedPrev != "" && event.PrevHash != expectedPrev && report.FirstBrokenLink == nil {
+				report.FirstBrokenLink = &domain.ChainBreak{
+					SequenceNum:      event.SequenceNum,
+					EventID:          event.EventID,
+					ExpectedPrevHash: expectedPrev,
+					ActualPrevHash:   event.PrevHash,
+				}
+			}
+			if !s.verifySignature(event) {
+				report.InvalidSignatures = append(report.InvalidSignatures, event.EventID)
+			}
+			if !s.verifyRecordHash(event) {
+				report.InvalidRecordHashes = append(report.InvalidRecordHashes, event.EventID)
+			}
+
+			expectedPrev = event.RecordHash
+			expectedSeq = event.SequenceNum + 1
+			return nil
+		})
+		if err == nil && expectedSeq <= last {
+			// The range ends with positions the ledger no longer holds
+			err = skip(expectedSeq, last)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("ledger verification aborted: %w", err)
+		}
+	}
+
+	if err := s.verifyChainHead(ctx, report); err != nil {
+		return nil, err
+	}
+	if err := s.verifyCheckpoints(ctx, report); err != nil {
+		return nil, err
+	}
+
+	report.Valid = report.FirstBrokenLink == nil &&
+		len(report.MissingSequences) == 0 &&
+		len(report.InvalidSignatures) == 0 &&
+		len(report.InvalidRecordHashes) == 0 &&
+		len(report.InvalidCheckpoints) == 0
+	report.CompletedAt = time.Now().UTC()
+
+	if !report.Valid {
+		s.logger.Error("LEDGER INTEGRITY FAILURE",
+			zap.Int64("first_sequence", report.FirstSequence),
+			zap.Int64("last_sequence", report.LastSequence),
+			zap.Int("missing_ranges", len(report.MissingSequences)),
+			zap.Int("invalid_signatures", len(report.InvalidSignatures)),
+			zap.Int("invalid_record_hashes", len(report.InvalidRecordHashes)),
+			zap.Int("invalid_checkpoints", len(report.InvalidCheckpoints)),
+			zap.Bool("broken_link", report.FirstBrokenLink != nil),
+		)
+	}
+	if len(report.UnverifiedTimestamps) > 0 {
+		s.logger.Warn("Checkpoint timestamps not verified: no TSA roots configured",
+			zap.Int("unverified_timestamps", len(report.UnverifiedTimestamps)),
+		)
+	}
+
+	return report, nil
+}
+
+// verifyChainHead checks that the chain still reaches the latest checkpoint. A head below
+// its tree size means events were removed from the end of the chain, which no link
+// check can see.
+func (s *AuditService) verifyChainHead(ctx context.Context, report *domain.LedgerVerificationReport) error {
+	head, _, err := s.pgRepo.GetChainHead(ctx)
+	if err != nil {
+		return err
+	}
+	report.ChainHead = head
+
+	latest, err := s.checkpointRepo.GetLatest(ctx)
+	if errors.Is(err, ErrCheckpointNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if head < latest.TreeSize {
+		report.InvalidCheckpoints = append(report.InvalidCheckpoints, domain.CheckpointFailure{
+			TreeSize: latest.TreeSize,
+			Reason:   fmt.Sprintf("ledger truncated: chain head %d is below the checkpointed tree size", head),
+		})
+	}
+	return nil
+}
+
+// verifyCheckpoints re-checks the signature, Merkle root and trusted timestamp of every
+// checkpoint created in the report's window
+func (s *AuditService) verifyCheckpoints(ctx context.Context, report *domain.LedgerVerificationReport) error {
+	checkpoints, err := s.checkpointRepo.ListCreatedBetween(ctx, report.From, report.To)
+	if err != nil {
+		return err
+	}
+	if len(checkpoints) == 0 {
+		return nil
+	}
+
+	// Verification rehashes the ledger rather than trusting stored nodes. Checkpoints are
+	// sorted by tree size, so one pass over the largest tree yields every root on the way.
+	roots := make(map[int64][]byte, len(checkpoints))
+	for _, cp := range checkpoints {
+		roots[cp.TreeSize] = nil
+	}
+	tree, err := crypto.NewMerkleFrontier(0, nil)
+	if err != nil {
+		return err
+	}
+	leavesErr := s.streamLeaves(ctx, 1, checkpoints[len(checkpoints)-1].TreeSize, func(leaf []byte) error {
+		tree.Append(leaf)
+		if _, ok := roots[tree.Size()]; ok {
+			roots[tree.Size()] = tree.Root()
+		}
+		return nil
+	})
+
+	for _, cp := range checkpoints {
+		report.CheckpointsChecked++
+
+		root := roots[cp.TreeSize]
+		var reason string
+		switch {
+		case !s.verifyCheckpointSignature(cp):
+			reason = "signature invalid"
+		case root == nil:
+			reason = leavesErr.Error()
+		case hex.EncodeToString(root) != cp.RootHash:
+			reason = "ledger diverges from checkpoint root"
+		default:
+			err := s.verifyCheckpointTimestamp(cp)
+			if errors.Is(err, timestamp.ErrUnverifiable) {
+				report.UnverifiedTimestamps = append(report.UnverifiedTimestamps, cp.TreeSize)
+			} else if err != nil {
+				reason = err.Error()
+			}
+		}
+		if reason != "" {
+			report.InvalidCheckpoints = append(report.InvalidCheckpoints, domain.CheckpointFailure{
+				TreeSize: cp.TreeSize,
+				Reason:   reason,
+			})
+		}
+	}
+	return nil
+}
+
+// RunIntegrityVerification verifies the ledger every interval until ctx is cancelled and
+// records each result in the ledger itself. A zero window verifies the whole ledger.
+func (s *AuditService) RunIntegrityVerification(ctx context.Context, interval, window time.Duration) {
+	if interval <= 0 {
+		s.logger.Warn("Scheduled ledger verification disabled")
+		return
+	}
+	ctx = auth.WithIdentity(ctx, auth.System)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			to := time.Now().UTC()
+			from := time.Time{}
+			if window > 0 {
+				from = to.Add(-window)
+			}
+
+			report, err := s.VerifyLedger(ctx, from, to)
+			if err != nil {
+				s.logger.Error("Scheduled ledger verification failed", zap.Error(err))
+				continue
+			}
+			if err := s.recordVerification(ctx, report); err != nil {
+				s.logger.Error("Failed to record ledger verification result", zap.Error(err))
+			}
+		}
+	}
+}
+
+// recordVerification writes a verification report to the ledger as an audit event
+func (s *AuditService) recordVerification(ctx context.Context, report *domain.LedgerVerificationReport) error {
+	event := domain.NewAuditEvent(uuid.Nil, domain.ActionTypeVerify, domain.ResourceTypeLedger,
+		fmt.Sprintf("%d-%d", report.FirstSequence, report.LastSequence))
+	event.ServiceSource = integrityServiceSource
+	event.RetentionCategory = "COMPLIANCE_REPORTS"
+	event.Result = domain.AuditResultSuccess
+	if !report.Valid {
+		event.Result = domain.AuditResultFailure
+		reason := "ledger integrity verification failed"
+		event.FailureReason = &reason
+	}
+
+	metadata, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("failed to marshal verification report: %w", err)
+	}
+	event.Metadata = metadata
+
+	return s.ProcessAndStoreEvent(ctx, event)
+}
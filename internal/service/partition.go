@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/banking/audit-compliance/internal/domain"
+	"github.com/banking/audit-compliance/internal/repository/postgres"
+	"github.com/banking/audit-compliance/internal/repository/s3"
+	"go.uber.org/zap"
+)
+
+// partitionArchiveBatch is how many events go into one archive object
+const partitionArchiveBatch = 10000
+
+// PartitionService keeps audit_events partitions ahead of ingestion, archives closed
+// months and detaches the archived ones
+type PartitionService struct {
+	manager            *postgres.PartitionManager
+	archive            *s3.ArchiveRepository
+	monthsAhead        int
+	archiveAfterMonths int
+	logger             *zap.Logger
+}
+
+// NewPartitionService creates a partition maintenance service. A nil archive or a zero
+// archiveAfterMonths leaves every partition attached.
+func NewPartitionService(manager *postgres.PartitionManager, archive *s3.ArchiveRepository, monthsAhead, archiveAfterMonths int, logger *zap.Logger) *PartitionService {
+	return &PartitionService{
+		manager:            manager,
+		archive:            archive,
+		monthsAhead:        monthsAhead,
+		archiveAfterMonths: archiveAfterMonths,
+		logger:             logger,
+	}
+}
+
+// Maintain runs one maintenance pass: pre-create upcoming months, archive closed ones,
+// then detach archived ones
+func (s *PartitionService) Maintain(ctx context.Context) error {
+	now := time.Now()
+	created, err := s.manager.EnsurePartitions(ctx, now, s.monthsAhead)
+	for _, name := range created {
+		s.logger.Info("Created audit_events partition", zap.String("partition", name))
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := s.archiveClosed(ctx, now); err != nil {
+		return err
+	}
+
+	detached, err := s.manager.DetachArchived(ctx)
+	for _, name := range detached {
+		s.logger.Info("Detached archived audit_events partition", zap.String("partition", name))
+	}
+	return err
+}
+
+// archiveClosed exports every partition that closed archiveAfterMonths ago and marks it
+// archived, which makes it eligible for DetachArchived
+func (s *PartitionService) archiveClosed(ctx context.Context, now time.Time) error {
+	if s.archive == nil || s.archiveAfterMonths <= 0 {
+		return nil
+	}
+
+	names, err := s.manager.ListArchivable(ctx, now, s.archiveAfterMonths)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		ref, err := s.archivePartition(ctx, name)
+		if err != nil {
+			return err
+		}
+		if err := s.manager.MarkArchived(ctx, name, ref); err != nil {
+			return err
+		}
+		s.logger.Info("Archived audit_events partition", zap.String("partition", name), zap.String("archive_ref", ref))
+	}
+	return nil
+}
+
+// archivePartition uploads a partition's events in batches followed by a manifest, and
+// returns the manifest's location
+func (s *PartitionService) archivePartition(ctx context.Context, name string) (string, error) {
+	var total int64
+	batches := 0
+	batch := make([]*domain.AuditEvent, 0, partitionArchiveBatch)
+	flush := func() error {
+		if err := s.archive.ArchivePartitionBatch(ctx, name, batches, batch); err != nil {
+			return err
+		}
+		total += int64(len(batch))
+		batches++
+		batch = batch[:0]
+		return nil
+	}
+
+	err := s.manager.StreamPartition(ctx, name, func(event *domain.AuditEvent) error {
+		batch = append(batch, event)
+		if len(batch) == partitionArchiveBatch {
+			return flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(batch) > 0 {
+		if err := flush(); err != nil {
+			return "", err
+		}
+	}
+	return s.archive.CompletePartitionArchive(ctx, name, total, batches)
+}
+
+// RunMaintenance runs Maintain immediately and then on every tick until ctx is cancelled
+func (s *PartitionService) RunMaintenance(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		s.logger.Warn("Scheduled partition maintenance disabled")
+		return
+	}
+
+	if err := s.Maintain(ctx); err != nil {
+		s.logger.Error("Partition maintenance failed", zap.Error(err))
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Maintain(ctx); err != nil {
+				s.logger.Error("Partition maintenance failed", zap.Error(err))
+			}
+		}
+	}
+}
@@ -0,0 +1,467 @@
+// Package timestamp implements an RFC 3161 Time-Stamp Protocol client. A trusted
+// timestamp authority (TSA) countersigns a digest with its own clock, proving that the
+// data existed no later than the token's genTime.
+package timestamp
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/banking/audit-compliance/internal/config"
+)
+
+const (
+	requestContentType  = "application/timestamp-query"
+	responseContentType = "application/timestamp-reply"
+	maxResponseBytes    = 1 << 20
+)
+
+var (
+	oidSHA256       = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidSHA384       = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 2}
+	oidSHA512       = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 3}
+	oidSignedData   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidTSTInfo      = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 1, 4}
+	oidContentType  = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 3}
+	oidMessageDigst = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 4}
+
+	oidRSAEncryption   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+	oidSHA256WithRSA   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 11}
+	oidSHA384WithRSA   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 12}
+	oidSHA512WithRSA   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 13}
+	oidECPublicKey     = asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}
+	oidECDSAWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}
+	oidECDSAWithSHA384 = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 3}
+	oidECDSAWithSHA512 = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 4}
+	oidEd25519         = asn1.ObjectIdentifier{1, 3, 101, 112}
+)
+
+// PKI status values from RFC 3161 section 2.4.2
+const (
+	statusGranted         = 0
+	statusGrantedWithMods = 1
+)
+
+var (
+	// ErrInvalidToken is returned when a timestamp token fails verification
+	ErrInvalidToken = errors.New("invalid timestamp token")
+	// ErrUnverifiable is returned when no trusted TSA roots are available to check a token against
+	ErrUnverifiable = errors.New("timestamp token unverifiable: no trusted TSA roots")
+)
+
+// ASN.1 structures from RFC 3161 and RFC 5652 (CMS)
+
+type messageImprint struct {
+	HashAlgorithm pkix.AlgorithmIdentifier
+	HashedMessage []byte
+}
+
+type timeStampReq struct {
+	Version        int
+	MessageImprint messageImprint
+	ReqPolicy      asn1.ObjectIdentifier `asn1:"optional"`
+	Nonce          *big.Int              `asn1:"optional"`
+	CertReq        bool                  `asn1:"optional,default:false"`
+}
+
+type pkiStatusInfo struct {
+	Status       int
+	StatusString []string       `asn1:"optional,utf8"`
+	FailInfo     asn1.BitString `asn1:"optional"`
+}
+
+type timeStampResp struct {
+	Status         pkiStatusInfo
+	TimeStampToken asn1.RawValue `asn1:"optional"`
+}
+
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"explicit,tag:0"`
+}
+
+type encapsulatedContentInfo struct {
+	EContentType asn1.ObjectIdentifier
+	EContent     []byte `asn1:"explicit,optional,tag:0"`
+}
+
+type signedData struct {
+	Version          int
+	DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+	EncapContentInfo encapsulatedContentInfo
+	Certificates     asn1.RawValue `asn1:"optional,tag:0"`
+	CRLs             asn1.RawValue `asn1:"optional,tag:1"`
+	SignerInfos      []signerInfo  `asn1:"set"`
+}
+
+type signerInfo struct {
+	Version            int
+	SID                asn1.RawValue
+	DigestAlgorithm    pkix.AlgorithmIdentifier
+	SignedAttrs        asn1.RawValue `asn1:"optional,tag:0"`
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          []byte
+	UnsignedAttrs      asn1.RawValue `asn1:"optional,tag:1"`
+}
+
+type issuerAndSerialNumber struct {
+	Issuer       asn1.RawValue
+	SerialNumber *big.Int
+}
+
+type attribute struct {
+	Type   asn1.ObjectIdentifier
+	Values []asn1.RawValue `asn1:"set"`
+}
+
+type accuracy struct {
+	Seconds int `asn1:"optional"`
+	Millis  int `asn1:"optional,tag:0"`
+	Micros  int `asn1:"optional,tag:1"`
+}
+
+type tstInfo struct {
+	Version        int
+	Policy         asn1.ObjectIdentifier
+	MessageImprint messageImprint
+	SerialNumber   *big.Int
+	GenTime        time.Time        `asn1:"generalized"`
+	Accuracy       accuracy         `asn1:"optional"`
+	Ordering       bool             `asn1:"optional,default:false"`
+	Nonce          *big.Int         `asn1:"optional"`
+	TSA            asn1.RawValue    `asn1:"optional,explicit,tag:0"`
+	Extensions     []pkix.Extension `asn1:"optional,tag:1"`
+}
+
+// TokenInfo is the verified content of a timestamp token
+type TokenInfo struct {
+	GenTime      time.Time
+	SerialNumber *big.Int
+	Policy       asn1.ObjectIdentifier
+	Signer       *x509.Certificate
+}
+
+// Client requests and verifies RFC 3161 timestamps from a configured TSA
+type Client struct {
+	url        string
+	httpClient *http.Client
+	roots      *x509.CertPool
+	required   bool
+}
+
+// NewClient creates a TSA client. It returns nil when no TSA URL is configured. A token
+// proves nothing unless its signer chains to a trusted root, so a URL without a CA
+// certificate is refused.
+func NewClient(cfg config.TimestampConfig) (*Client, error) {
+	if cfg.URL == "" {
+		return nil, nil
+	}
+	if cfg.CACertPath == "" {
+		return nil, errors.New("timestamp.ca_cert_path is required when timestamp.url is set")
+	}
+
+	pemData, err := os.ReadFile(cfg.CACertPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read TSA CA certificate: %w", err)
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(pemData) {
+		return nil, errors.New("no certificates found in TSA CA file")
+	}
+
+	return &Client{
+		url:        cfg.URL,
+		httpClient: &http.Client{Timeout: cfg.Timeout},
+		roots:      roots,
+		required:   cfg.Required,
+	}, nil
+}
+
+// Required reports whether checkpoints without a token count as integrity failures
+func (c *Client) Required() bool {
+	return c.required
+}
+
+// Timestamp obtains a token over a SHA-256 digest and verifies it before returning
+// the DER-encoded token
+func (c *Client) Timestamp(ctx context.Context, digest []byte) ([]byte, *TokenInfo, error) {
+	nonce, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 64))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	reqBytes, err := asn1.Marshal(timeStampReq{
+		Version: 1,
+		MessageImprint: messageImprint{
+			HashAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidSHA256, Parameters: asn1.NullRawValue},
+			HashedMessage: digest,
+		},
+		Nonce:   nonce,
+		CertReq: true,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encode timestamp request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(reqBytes))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to build timestamp request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", requestContentType)
+	httpReq.Header.Set("Accept", responseContentType)
+
+	res, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, nil, fmt.Errorf("timestamp request failed: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("timestamp authority returned HTTP %d", res.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(res.Body, maxResponseBytes))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read timestamp response: %w", err)
+	}
+
+	var resp timeStampResp
+	if rest, err := asn1.Unmarshal(body, &resp); err != nil || len(rest) > 0 {
+		return nil, nil, fmt.Errorf("malformed timestamp response: %v", err)
+	}
+	if resp.Status.Status != statusGranted && resp.Status.Status != statusGrantedWithMods {
+		return nil, nil, fmt.Errorf("timestamp request rejected: status %d %v", resp.Status.Status, resp.Status.StatusString)
+	}
+	token := resp.TimeStampToken.FullBytes
+	if len(token) == 0 {
+		return nil, nil, errors.New("timestamp response carries no token")
+	}
+
+	info, tokenNonce, err := verifyToken(token, digest, c.roots)
+	if err != nil {
+		return nil, nil, err
+	}
+	if tokenNonce == nil || nonce.Cmp(tokenNonce) != 0 {
+		return nil, nil, fmt.Errorf("%w: nonce mismatch", ErrInvalidToken)
+	}
+
+	return token, info, nil
+}
+
+// Verify checks a stored token against the digest it should cover
+func (c *Client) Verify(token, digest []byte) (*TokenInfo, error) {
+	return VerifyToken(token, digest, c.roots)
+}
+
+// VerifyToken checks a DER-encoded timestamp token: the CMS signature over the signed
+// attributes, the message digest of the TSTInfo, the imprint against digest, and the
+// signer's chain to a trusted TSA root with timeStamping usage. Without roots the token
+// is reported as ErrUnverifiable, since anyone can sign a well-formed token.
+func VerifyToken(token, digest []byte, roots *x509.CertPool) (*TokenInfo, error) {
+	info, _, err := verifyToken(token, digest, roots)
+	return info, err
+}
+
+// verifyToken is VerifyToken that also returns the token nonce for request matching
+func verifyToken(token, digest []byte, roots *x509.CertPool) (*TokenInfo, *big.Int, error) {
+	if roots == nil {
+		return nil, nil, ErrUnverifiable
+	}
+
+	var ci contentInfo
+	if rest, err := asn1.Unmarshal(token, &ci); err != nil || len(rest) > 0 {
+		return nil, nil, fmt.Errorf("%w: malformed content info", ErrInvalidToken)
+	}
+	if !ci.ContentType.Equal(oidSignedData) {
+		return nil, nil, fmt.Errorf("%w: content is not signed data", ErrInvalidToken)
+	}
+
+	var sd signedData
+	if _, err := asn1.Unmarshal(ci.Content.Bytes, &sd); err != nil {
+		return nil, nil, fmt.Errorf("%w: malformed signed data: %v", ErrInvalidToken, err)
+	}
+	if !sd.EncapContentInfo.EContentType.Equal(oidTSTInfo) || len(sd.EncapContentInfo.EContent) == 0 {
+		return nil, nil, fmt.Errorf("%w: missing TSTInfo", ErrInvalidToken)
+	}
+	if len(sd.SignerInfos) != 1 {
+		return nil, nil, fmt.Errorf("%w: expected exactly one signer", ErrInvalidToken)
+	}
+
+	var info tstInfo
+	if _, err := asn1.Unmarshal(sd.EncapContentInfo.EContent, &info); err != nil {
+		return nil, nil, fmt.Errorf("%w: malformed TSTInfo: %v", ErrInvalidToken, err)
+	}
+	if !info.MessageImprint.HashAlgorithm.Algorithm.Equal(oidSHA256) ||
+		!bytes.Equal(info.MessageImprint.HashedMessage, digest) {
+		return nil, nil, fmt.Errorf("%w: message imprint does not match", ErrInvalidToken)
+	}
+
+	var certs []*x509.Certificate
+	if len(sd.Certificates.Bytes) > 0 {
+		parsed, err := x509.ParseCertificates(sd.Certificates.Bytes)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: malformed certificates: %v", ErrInvalidToken, err)
+		}
+		certs = parsed
+	}
+
+	si := sd.SignerInfos[0]
+	signer, err := findSigner(si.SID, certs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := verifySignerInfo(si, sd.EncapContentInfo.EContent, signer); err != nil {
+		return nil, nil, err
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs {
+		intermediates.AddCert(cert)
+	}
+	_, err = signer.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   info.GenTime,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageTimeStamping},
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: untrusted signer: %v", ErrInvalidToken, err)
+	}
+
+	return &TokenInfo{
+		GenTime:      info.GenTime,
+		SerialNumber: info.SerialNumber,
+		Policy:       info.Policy,
+		Signer:       signer,
+	}, info.Nonce, nil
+}
+
+// findSigner locates the certificate named by a SignerIdentifier
+func findSigner(sid asn1.RawValue, certs []*x509.Certificate) (*x509.Certificate, error) {
+	switch {
+	case sid.Class == asn1.ClassUniversal && sid.Tag == asn1.TagSequence:
+		var ias issuerAndSerialNumber
+		if _, err := asn1.Unmarshal(sid.FullBytes, &ias); err != nil {
+			return nil, fmt.Errorf("%w: malformed signer identifier", ErrInvalidToken)
+		}
+		for _, cert := range certs {
+			if cert.SerialNumber.Cmp(ias.SerialNumber) == 0 && bytes.Equal(cert.RawIssuer, ias.Issuer.FullBytes) {
+				return cert, nil
+			}
+		}
+	case sid.Class == asn1.ClassContextSpecific && sid.Tag == 0:
+		for _, cert := range certs {
+			if bytes.Equal(cert.SubjectKeyId, sid.Bytes) {
+				return cert, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("%w: signer certificate not included", ErrInvalidToken)
+}
+
+// verifySignerInfo checks the signed attributes and the signature over them
+func verifySignerInfo(si signerInfo, content []byte, signer *x509.Certificate) error {
+	if len(si.SignedAttrs.FullBytes) == 0 {
+		return fmt.Errorf("%w: signed attributes missing", ErrInvalidToken)
+	}
+
+	hash, ok := digestAlgorithm(si.DigestAlgorithm.Algorithm)
+	if !ok {
+		return fmt.Errorf("%w: unsupported digest algorithm %v", ErrInvalidToken, si.DigestAlgorithm.Algorithm)
+	}
+
+	// The signature covers the attributes re-encoded with the universal SET tag
+	attrsDER := append([]byte{}, si.SignedAttrs.FullBytes...)
+	attrsDER[0] = 0x31
+
+	var attrs []attribute
+	if _, err := asn1.UnmarshalWithParams(attrsDER, &attrs, "set"); err != nil {
+		return fmt.Errorf("%w: malformed signed attributes", ErrInvalidToken)
+	}
+
+	var contentTypeOK, digestOK bool
+	h := hash.New()
+	h.Write(content)
+	contentDigest := h.Sum(nil)
+	for _, attr := range attrs {
+		if len(attr.Values) != 1 {
+			continue
+		}
+		switch {
+		case attr.Type.Equal(oidContentType):
+			var ct asn1.ObjectIdentifier
+			if _, err := asn1.Unmarshal(attr.Values[0].FullBytes, &ct); err == nil && ct.Equal(oidTSTInfo) {
+				contentTypeOK = true
+			}
+		case attr.Type.Equal(oidMessageDigst):
+			var md []byte
+			if _, err := asn1.Unmarshal(attr.Values[0].FullBytes, &md); err == nil && bytes.Equal(md, contentDigest) {
+				digestOK = true
+			}
+		}
+	}
+	if !contentTypeOK || !digestOK {
+		return fmt.Errorf("%w: signed attributes do not match TSTInfo", ErrInvalidToken)
+	}
+
+	sigAlg, ok := signatureAlgorithm(si.SignatureAlgorithm.Algorithm, hash)
+	if !ok {
+		return fmt.Errorf("%w: unsupported signature algorithm %v", ErrInvalidToken, si.SignatureAlgorithm.Algorithm)
+	}
+	if err := signer.CheckSignature(sigAlg, attrsDER, si.Signature); err != nil {
+		return fmt.Errorf("%w: signature check failed: %v", ErrInvalidToken, err)
+	}
+	return nil
+}
+
+func digestAlgorithm(oid asn1.ObjectIdentifier) (crypto.Hash, bool) {
+	switch {
+	case oid.Equal(oidSHA256):
+		return crypto.SHA256, true
+	case oid.Equal(oidSHA384):
+		return crypto.SHA384, true
+	case oid.Equal(oidSHA512):
+		return crypto.SHA512, true
+	}
+	return 0, false
+}
+
+// signatureAlgorithm maps a CMS signature algorithm, which may name only the key type,
+// to the x509 algorithm used to check it
+func signatureAlgorithm(oid asn1.ObjectIdentifier, hash crypto.Hash) (x509.SignatureAlgorithm, bool) {
+	switch {
+	case oid.Equal(oidEd25519):
+		return x509.PureEd25519, true
+	case oid.Equal(oidRSAEncryption) || oid.Equal(oidSHA256WithRSA) || oid.Equal(oidSHA384WithRSA) || oid.Equal(oidSHA512WithRSA):
+		switch hash {
+		case crypto.SHA256:
+			return x509.SHA256WithRSA, true
+		case crypto.SHA384:
+			return x509.SHA384WithRSA, true
+		case crypto.SHA512:
+			return x509.SHA512WithRSA, true
+		}
+	case oid.Equal(oidECPublicKey) || oid.Equal(oidECDSAWithSHA256) || oid.Equal(oidECDSAWithSHA384) || oid.Equal(oidECDSAWithSHA512):
+		switch hash {
+		case crypto.SHA256:
+			return x509.ECDSAWithSHA256, true
+		case crypto.SHA384:
+			return x509.ECDSAWithSHA384, true
+		case crypto.SHA512:
+			return x509.ECDSAWithSHA512, true
+		}
+	}
+	return x509.UnknownSignatureAlgorithm, false
+}
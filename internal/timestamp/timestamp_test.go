@@ -0,0 +1,135 @@
+package timestamp_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/banking/audit-compliance/internal/config"
+	"github.com/banking/audit-compliance/internal/timestamp"
+	"github.com/banking/audit-compliance/internal/timestamp/timestamptest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func digestOf(s string) []byte {
+	sum := sha256.Sum256([]byte(s))
+	return sum[:]
+}
+
+func TestNewClientDisabledWithoutURL(t *testing.T) {
+	client, err := timestamp.NewClient(config.TimestampConfig{})
+	require.NoError(t, err)
+	assert.Nil(t, client)
+}
+
+func TestNewClientRequiresRoots(t *testing.T) {
+	cfg := timestamptest.NewAuthority(t).Config()
+	cfg.CACertPath = ""
+	_, err := timestamp.NewClient(cfg)
+	assert.Error(t, err)
+}
+
+func TestTimestampRoundTrip(t *testing.T) {
+	tsa := timestamptest.NewAuthority(t)
+	client, err := timestamp.NewClient(tsa.Config())
+	require.NoError(t, err)
+
+	digest := digestOf("checkpoint")
+	before := time.Now().Add(-time.Second)
+	token, info, err := client.Timestamp(context.Background(), digest)
+	require.NoError(t, err)
+	require.NotEmpty(t, token)
+	assert.WithinRange(t, info.GenTime, before.Truncate(time.Second), time.Now().Add(time.Second))
+	assert.True(t, info.Policy.Equal(timestamptest.Policy))
+	assert.Equal(t, 1, tsa.Issued())
+
+	// A stored token verifies later, including without the TSA being reachable
+	tsa.Close()
+	verified, err := client.Verify(token, digest)
+	require.NoError(t, err)
+	assert.Equal(t, info.SerialNumber, verified.SerialNumber)
+}
+
+func TestVerifyRejectsOtherDigest(t *testing.T) {
+	tsa := timestamptest.NewAuthority(t)
+	client, err := timestamp.NewClient(tsa.Config())
+	require.NoError(t, err)
+
+	token, _, err := client.Timestamp(context.Background(), digestOf("checkpoint"))
+	require.NoError(t, err)
+
+	_, err = client.Verify(token, digestOf("rewritten checkpoint"))
+	assert.ErrorIs(t, err, timestamp.ErrInvalidToken)
+}
+
+func TestVerifyRejectsTamperedToken(t *testing.T) {
+	tsa := timestamptest.NewAuthority(t)
+	client, err := timestamp.NewClient(tsa.Config())
+	require.NoError(t, err)
+
+	digest := digestOf("checkpoint")
+	token, _, err := client.Timestamp(context.Background(), digest)
+	require.NoError(t, err)
+
+	// The signature is the last element of the token
+	tampered := append([]byte{}, token...)
+	tampered[len(tampered)-1] ^= 0xff
+	_, err = client.Verify(tampered, digest)
+	assert.ErrorIs(t, err, timestamp.ErrInvalidToken)
+}
+
+func TestVerifyRejectsUntrustedAuthority(t *testing.T) {
+	tsa := timestamptest.NewAuthority(t)
+	client, err := timestamp.NewClient(tsa.Config())
+	require.NoError(t, err)
+
+	digest := digestOf("checkpoint")
+	token, _, err := client.Timestamp(context.Background(), digest)
+	require.NoError(t, err)
+
+	// A root the token's signer does not chain to
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test Timestamp Root"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	otherRoot, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	roots := x509.NewCertPool()
+	roots.AddCert(otherRoot)
+
+	_, err = timestamp.VerifyToken(token, digest, roots)
+	assert.ErrorIs(t, err, timestamp.ErrInvalidToken)
+
+	// Without roots a well-formed token proves nothing
+	_, err = timestamp.VerifyToken(token, digest, nil)
+	assert.ErrorIs(t, err, timestamp.ErrUnverifiable)
+	assert.NotErrorIs(t, err, timestamp.ErrInvalidToken)
+}
+
+func TestTimestampRejectedByAuthority(t *testing.T) {
+	tsa := timestamptest.NewAuthority(t)
+	tsa.SetReject(true)
+	client, err := timestamp.NewClient(tsa.Config())
+	require.NoError(t, err)
+
+	_, _, err = client.Timestamp(context.Background(), digestOf("checkpoint"))
+	assert.Error(t, err)
+	assert.Equal(t, 0, tsa.Issued())
+}
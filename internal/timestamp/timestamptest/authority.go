@@ -0,0 +1,311 @@
+// Package timestamptest provides a local RFC 3161 timestamp authority for tests.
+// It issues real CMS-signed tokens, so the production client exercises the same parsing
+// and verification paths it uses against a public TSA. Keys derive from fixed seeds, so
+// tokens stored by earlier runs against a persistent database still chain to CACertPath.
+package timestamptest
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha512"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/banking/audit-compliance/internal/config"
+)
+
+var (
+	oidSHA512        = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 3}
+	oidSignedData    = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidTSTInfo       = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 1, 4}
+	oidContentType   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 3}
+	oidMessageDigest = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 4}
+	oidEd25519       = asn1.ObjectIdentifier{1, 3, 101, 112}
+
+	// Policy is the TSA policy OID stamped into every token
+	Policy = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 99999, 1}
+
+	caSeed  = bytes.Repeat([]byte{0xca}, ed25519.SeedSize)
+	tsaSeed = bytes.Repeat([]byte{0x75}, ed25519.SeedSize)
+)
+
+type messageImprint struct {
+	HashAlgorithm pkix.AlgorithmIdentifier
+	HashedMessage []byte
+}
+
+type timeStampReq struct {
+	Version        int
+	MessageImprint messageImprint
+	ReqPolicy      asn1.ObjectIdentifier `asn1:"optional"`
+	Nonce          *big.Int              `asn1:"optional"`
+	CertReq        bool                  `asn1:"optional,default:false"`
+}
+
+type pkiStatusInfo struct {
+	Status int
+}
+
+type timeStampResp struct {
+	Status         pkiStatusInfo
+	TimeStampToken asn1.RawValue `asn1:"optional"`
+}
+
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue
+}
+
+type encapsulatedContentInfo struct {
+	EContentType asn1.ObjectIdentifier
+	EContent     []byte `asn1:"explicit,tag:0"`
+}
+
+type signedData struct {
+	Version          int
+	DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+	EncapContentInfo encapsulatedContentInfo
+	Certificates     asn1.RawValue
+	SignerInfos      []signerInfo `asn1:"set"`
+}
+
+type signerInfo struct {
+	Version            int
+	SID                asn1.RawValue
+	DigestAlgorithm    pkix.AlgorithmIdentifier
+	SignedAttrs        asn1.RawValue
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          []byte
+}
+
+type issuerAndSerialNumber struct {
+	Issuer       asn1.RawValue
+	SerialNumber *big.Int
+}
+
+type attribute struct {
+	Type   asn1.ObjectIdentifier
+	Values []asn1.RawValue `asn1:"set"`
+}
+
+type tstInfo struct {
+	Version        int
+	Policy         asn1.ObjectIdentifier
+	MessageImprint messageImprint
+	SerialNumber   *big.Int
+	GenTime        time.Time `asn1:"generalized"`
+	Nonce          *big.Int  `asn1:"optional"`
+}
+
+// Authority is an httptest server answering RFC 3161 timestamp requests
+type Authority struct {
+	*httptest.Server
+
+	// CACertPath is a PEM file holding the root the TSA certificate chains to
+	CACertPath string
+
+	mu      sync.Mutex
+	serial  int64
+	reject  bool
+	granted int
+	cert    *x509.Certificate
+	key     ed25519.PrivateKey
+}
+
+// NewAuthority starts a local TSA and registers its shutdown with t
+func NewAuthority(t testing.TB) *Authority {
+	t.Helper()
+
+	caKey := ed25519.NewKeyFromSeed(caSeed)
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test Timestamp Root"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, caKey.Public(), caKey)
+	if err != nil {
+		t.Fatalf("failed to create TSA CA certificate: %v", err)
+	}
+	caCert, _ := x509.ParseCertificate(caDER)
+
+	key := ed25519.NewKeyFromSeed(tsaSeed)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "Test Timestamp Authority"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageTimeStamping},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, caCert, key.Public(), caKey)
+	if err != nil {
+		t.Fatalf("failed to create TSA certificate: %v", err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+
+	caPath := filepath.Join(t.TempDir(), "tsa-ca.pem")
+	if err := os.WriteFile(caPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER}), 0o600); err != nil {
+		t.Fatalf("failed to write TSA CA certificate: %v", err)
+	}
+
+	a := &Authority{
+		CACertPath: caPath,
+		cert:       cert,
+		key:        key,
+	}
+	a.Server = httptest.NewServer(http.HandlerFunc(a.handle))
+	t.Cleanup(a.Close)
+	return a
+}
+
+// Config returns a client configuration pointing at this authority
+func (a *Authority) Config() config.TimestampConfig {
+	return config.TimestampConfig{
+		URL:        a.URL,
+		CACertPath: a.CACertPath,
+		Timeout:    5 * time.Second,
+	}
+}
+
+// SetReject makes the authority answer every request with a rejection status
+func (a *Authority) SetReject(reject bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.reject = reject
+}
+
+// Issued returns the number of tokens granted so far
+func (a *Authority) Issued() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.granted
+}
+
+func (a *Authority) handle(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var req timeStampReq
+	if _, err := asn1.Unmarshal(body, &req); err != nil {
+		http.Error(w, "malformed request", http.StatusBadRequest)
+		return
+	}
+
+	a.mu.Lock()
+	reject := a.reject
+	a.serial++
+	serial := a.serial
+	if !reject {
+		a.granted++
+	}
+	a.mu.Unlock()
+
+	resp := timeStampResp{Status: pkiStatusInfo{Status: 2}} // rejection
+	if !reject {
+		token, err := a.issue(req, serial)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		resp = timeStampResp{TimeStampToken: asn1.RawValue{FullBytes: token}}
+	}
+
+	out, err := asn1.Marshal(resp)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/timestamp-reply")
+	_, _ = w.Write(out)
+}
+
+// issue builds a CMS SignedData token over a TSTInfo for req
+func (a *Authority) issue(req timeStampReq, serial int64) ([]byte, error) {
+	content, err := asn1.Marshal(tstInfo{
+		Version:        1,
+		Policy:         Policy,
+		MessageImprint: req.MessageImprint,
+		SerialNumber:   big.NewInt(serial),
+		GenTime:        time.Now().UTC().Truncate(time.Second),
+		Nonce:          req.Nonce,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// RFC 8419: Ed25519 signers use SHA-512 for the message digest attribute
+	digest := sha512.Sum512(content)
+	contentTypeValue, _ := asn1.Marshal(oidTSTInfo)
+	digestValue, _ := asn1.Marshal(digest[:])
+	var encodedAttrs [][]byte
+	for _, attr := range []attribute{
+		{Type: oidContentType, Values: []asn1.RawValue{{FullBytes: contentTypeValue}}},
+		{Type: oidMessageDigest, Values: []asn1.RawValue{{FullBytes: digestValue}}},
+	} {
+		der, err := asn1.Marshal(attr)
+		if err != nil {
+			return nil, err
+		}
+		encodedAttrs = append(encodedAttrs, der)
+	}
+	// DER orders SET OF members by their encodings
+	sort.Slice(encodedAttrs, func(i, j int) bool { return bytes.Compare(encodedAttrs[i], encodedAttrs[j]) < 0 })
+	attrsContent := bytes.Join(encodedAttrs, nil)
+
+	attrsSet, err := asn1.Marshal(asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true, Bytes: attrsContent})
+	if err != nil {
+		return nil, err
+	}
+	signature := ed25519.Sign(a.key, attrsSet)
+
+	sid, err := asn1.Marshal(issuerAndSerialNumber{
+		Issuer:       asn1.RawValue{FullBytes: a.cert.RawIssuer},
+		SerialNumber: a.cert.SerialNumber,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sha512Alg := pkix.AlgorithmIdentifier{Algorithm: oidSHA512}
+	sd, err := asn1.Marshal(signedData{
+		Version:          3,
+		DigestAlgorithms: []pkix.AlgorithmIdentifier{sha512Alg},
+		EncapContentInfo: encapsulatedContentInfo{EContentType: oidTSTInfo, EContent: content},
+		Certificates:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: a.cert.Raw},
+		SignerInfos: []signerInfo{{
+			Version:            1,
+			SID:                asn1.RawValue{FullBytes: sid},
+			DigestAlgorithm:    sha512Alg,
+			SignedAttrs:        asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: attrsContent},
+			SignatureAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidEd25519},
+			Signature:          signature,
+		}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return asn1.Marshal(contentInfo{
+		ContentType: oidSignedData,
+		Content:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: sd},
+	})
+}
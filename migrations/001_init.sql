@@ -19,13 +19,21 @@ CREATE TABLE IF NOT EXISTS audit_events (
     request_id VARCHAR(100),
     session_id VARCHAR(100),
     digital_signature TEXT NOT NULL,
+    -- 1 = legacy five-field signature, 2 = canonical encoding of every content field
+    signature_version SMALLINT NOT NULL DEFAULT 1,
+    signature_algorithm VARCHAR(20) NOT NULL DEFAULT 'HMAC-SHA256',
+    signing_key_id VARCHAR(100) NOT NULL DEFAULT '',
     metadata JSONB,
     data_before BYTEA,
     data_after BYTEA,
     compliance_flags TEXT [],
     retention_category VARCHAR(50) NOT NULL DEFAULT 'STANDARD',
     encryption_key_id INT,
-    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    -- Hash chain: each row commits to its predecessor so removals and reorders are detectable
+    sequence_num BIGINT NOT NULL UNIQUE,
+    prev_hash CHAR(64) NOT NULL,
+    record_hash CHAR(64) NOT NULL UNIQUE
 );
 -- Access Logs (Audit of Audits)
 CREATE TABLE IF NOT EXISTS access_logs (
@@ -39,10 +47,21 @@ CREATE TABLE IF NOT EXISTS access_logs (
     timestamp TIMESTAMP WITH TIME ZONE NOT NULL,
     purpose TEXT
 );
+-- Ledger Checkpoints (signed Merkle tree heads over the audit_events chain)
+CREATE TABLE IF NOT EXISTS ledger_checkpoints (
+    checkpoint_id UUID PRIMARY KEY,
+    tree_size BIGINT NOT NULL UNIQUE,
+    root_hash CHAR(64) NOT NULL,
+    signature TEXT NOT NULL,
+    signature_algorithm VARCHAR(20) NOT NULL,
+    signing_key_id VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    tsa_token BYTEA, -- RFC 3161 TimeStampToken over SHA-256 of the signed checkpoint
+    tsa_time TIMESTAMP WITH TIME ZONE
+);
 -- Indexes for Query Performance
 CREATE INDEX IF NOT EXISTS idx_audit_transaction_id ON audit_events(transaction_id);
 CREATE INDEX IF NOT EXISTS idx_audit_user_id ON audit_events(user_id);
 CREATE INDEX IF NOT EXISTS idx_audit_timestamp ON audit_events(timestamp DESC);
 CREATE INDEX IF NOT EXISTS idx_audit_action_type ON audit_events(action_type);
--- Constraint to simulate immutability (Trigger would be better but this is a start)
--- In production, we revoke UPDATE/DELETE permissions from the application user.
\ No newline at end of file
+-- Immutability is enforced by 003_immutability.sql (triggers) and 002_roles.sql (grants)
@@ -0,0 +1,27 @@
+-- Separate owner and application roles.
+--   audit_owner: owns the schema objects; used only to apply migrations.
+--   audit_app:   the service's runtime role; may read and append, never rewrite.
+-- Roles are normally provisioned before migrations run as audit_owner (see
+-- deployments/docker/dev-roles.sql for local dev); the guarded CREATEs only take effect
+-- when migrations are applied by a role allowed to create roles. Passwords are never set here.
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT FROM pg_roles WHERE rolname = 'audit_owner') THEN
+        CREATE ROLE audit_owner LOGIN NOSUPERUSER NOCREATEDB NOCREATEROLE NOINHERIT;
+    END IF;
+    IF NOT EXISTS (SELECT FROM pg_roles WHERE rolname = 'audit_app') THEN
+        CREATE ROLE audit_app LOGIN NOSUPERUSER NOCREATEDB NOCREATEROLE NOINHERIT;
+    END IF;
+END
+$$;
+
+ALTER TABLE audit_events OWNER TO audit_owner;
+ALTER TABLE access_logs OWNER TO audit_owner;
+ALTER TABLE ledger_checkpoints OWNER TO audit_owner;
+
+GRANT USAGE ON SCHEMA public TO audit_app;
+
+-- Ledger tables: append and read only
+REVOKE ALL ON audit_events, access_logs, ledger_checkpoints FROM PUBLIC;
+REVOKE ALL ON audit_events, access_logs, ledger_checkpoints FROM audit_app;
+GRANT SELECT, INSERT ON audit_events, access_logs, ledger_checkpoints TO audit_app;
@@ -0,0 +1,33 @@
+-- Append-only enforcement for the audit ledger. Grants keep the application role from
+-- rewriting rows; these triggers also stop the owner and any role granted privileges
+-- by mistake. Disabling them requires ALTER TABLE, which only the owner can do.
+CREATE OR REPLACE FUNCTION reject_ledger_mutation() RETURNS trigger
+LANGUAGE plpgsql AS $$
+BEGIN
+    RAISE EXCEPTION '% on % is not permitted: the audit ledger is append-only', TG_OP, TG_TABLE_NAME
+        USING ERRCODE = 'insufficient_privilege';
+END
+$$;
+
+ALTER FUNCTION reject_ledger_mutation() OWNER TO audit_owner;
+
+CREATE OR REPLACE TRIGGER audit_events_append_only
+    BEFORE UPDATE OR DELETE ON audit_events
+    FOR EACH ROW EXECUTE FUNCTION reject_ledger_mutation();
+CREATE OR REPLACE TRIGGER audit_events_no_truncate
+    BEFORE TRUNCATE ON audit_events
+    FOR EACH STATEMENT EXECUTE FUNCTION reject_ledger_mutation();
+
+CREATE OR REPLACE TRIGGER access_logs_append_only
+    BEFORE UPDATE OR DELETE ON access_logs
+    FOR EACH ROW EXECUTE FUNCTION reject_ledger_mutation();
+CREATE OR REPLACE TRIGGER access_logs_no_truncate
+    BEFORE TRUNCATE ON access_logs
+    FOR EACH STATEMENT EXECUTE FUNCTION reject_ledger_mutation();
+
+CREATE OR REPLACE TRIGGER ledger_checkpoints_append_only
+    BEFORE UPDATE OR DELETE ON ledger_checkpoints
+    FOR EACH ROW EXECUTE FUNCTION reject_ledger_mutation();
+CREATE OR REPLACE TRIGGER ledger_checkpoints_no_truncate
+    BEFORE TRUNCATE ON ledger_checkpoints
+    FOR EACH STATEMENT EXECUTE FUNCTION reject_ledger_mutation();
@@ -0,0 +1,141 @@
+-- Workflow tables for AML, KYC, GDPR and regulatory reporting. Unlike the ledger these
+-- rows move through statuses, so the application may UPDATE them; nothing is ever
+-- deleted here (retention is handled by archival, not by the service).
+
+-- AML Flags (domain.AMLFlag)
+CREATE TABLE IF NOT EXISTS aml_flags (
+    flag_id UUID PRIMARY KEY,
+    transaction_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    account_id UUID NOT NULL,
+    flag_type VARCHAR(50) NOT NULL,
+    risk_score INT NOT NULL CHECK (risk_score BETWEEN 0 AND 100),
+    status VARCHAR(20) NOT NULL DEFAULT 'PENDING',
+    detected_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    detection_method VARCHAR(20) NOT NULL,
+    detection_rule TEXT,
+    transaction_amount BIGINT NOT NULL,
+    currency CHAR(3) NOT NULL,
+    source_country VARCHAR(2),
+    dest_country VARCHAR(2),
+    assigned_to UUID,
+    assigned_at TIMESTAMP WITH TIME ZONE,
+    investigation_notes TEXT,
+    resolution TEXT,
+    resolved_at TIMESTAMP WITH TIME ZONE,
+    resolved_by UUID,
+    filed_with_fincen BOOLEAN NOT NULL DEFAULT FALSE,
+    sar_number VARCHAR(50),
+    ctr_number VARCHAR(50),
+    related_flags UUID [],
+    priority VARCHAR(10) NOT NULL DEFAULT 'MEDIUM',
+    due_date TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_aml_flags_user_id ON aml_flags(user_id);
+CREATE INDEX IF NOT EXISTS idx_aml_flags_transaction_id ON aml_flags(transaction_id);
+CREATE INDEX IF NOT EXISTS idx_aml_flags_status_due ON aml_flags(status, due_date);
+
+-- KYC Verifications (domain.KYCVerification)
+CREATE TABLE IF NOT EXISTS kyc_verifications (
+    verification_id UUID PRIMARY KEY,
+    user_id UUID NOT NULL,
+    verification_type VARCHAR(50) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    verified_by UUID,
+    verified_by_name VARCHAR(200),
+    verification_date TIMESTAMP WITH TIME ZONE,
+    expiration_date TIMESTAMP WITH TIME ZONE,
+    document_ref TEXT,
+    document_hash CHAR(64),
+    notes TEXT,
+    failure_reason TEXT,
+    risk_score INT NOT NULL DEFAULT 0 CHECK (risk_score BETWEEN 0 AND 100),
+    source_system VARCHAR(100) NOT NULL,
+    external_ref VARCHAR(200),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_kyc_verifications_user_id ON kyc_verifications(user_id);
+CREATE INDEX IF NOT EXISTS idx_kyc_verifications_expiration ON kyc_verifications(expiration_date)
+    WHERE expiration_date IS NOT NULL;
+
+-- GDPR Data Subject Requests (domain.GDPRRequest)
+CREATE TABLE IF NOT EXISTS gdpr_requests (
+    request_id UUID PRIMARY KEY,
+    user_id UUID NOT NULL,
+    request_type VARCHAR(50) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    requested_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    deadline TIMESTAMP WITH TIME ZONE NOT NULL,
+    identity_verified BOOLEAN NOT NULL DEFAULT FALSE,
+    verified_at TIMESTAMP WITH TIME ZONE,
+    verified_by UUID,
+    processed_by UUID,
+    processed_at TIMESTAMP WITH TIME ZONE,
+    completed_at TIMESTAMP WITH TIME ZONE,
+    grace_period_end TIMESTAMP WITH TIME ZONE,
+    response_s3_path TEXT,
+    rejection_reason TEXT,
+    notes TEXT,
+    source_channel VARCHAR(50) NOT NULL,
+    ip_address VARCHAR(45),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_gdpr_requests_user_id ON gdpr_requests(user_id);
+CREATE INDEX IF NOT EXISTS idx_gdpr_requests_status_deadline ON gdpr_requests(status, deadline);
+
+-- Compliance Reports (domain.ComplianceReport)
+CREATE TABLE IF NOT EXISTS compliance_reports (
+    report_id UUID PRIMARY KEY,
+    report_type VARCHAR(50) NOT NULL,
+    report_number VARCHAR(100) NOT NULL UNIQUE,
+    status VARCHAR(20) NOT NULL,
+    period VARCHAR(20) NOT NULL,
+    period_start TIMESTAMP WITH TIME ZONE NOT NULL,
+    period_end TIMESTAMP WITH TIME ZONE NOT NULL,
+    generated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    generated_by UUID NOT NULL,
+    filed_with VARCHAR(100),
+    filed_at TIMESTAMP WITH TIME ZONE,
+    filing_confirmation_number VARCHAR(100),
+    s3_path TEXT NOT NULL,
+    file_format VARCHAR(10) NOT NULL,
+    file_size_bytes BIGINT NOT NULL DEFAULT 0,
+    hash CHAR(64) NOT NULL,
+    summary TEXT NOT NULL DEFAULT '',
+    record_count INT NOT NULL DEFAULT 0,
+    error_message TEXT,
+    retention_until TIMESTAMP WITH TIME ZONE NOT NULL,
+    is_encrypted BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CHECK (period_end >= period_start)
+);
+CREATE INDEX IF NOT EXISTS idx_compliance_reports_type_period ON compliance_reports(report_type, period_start);
+
+-- Compliance Deadlines (domain.ComplianceDeadline)
+CREATE TABLE IF NOT EXISTS compliance_deadlines (
+    deadline_id UUID PRIMARY KEY,
+    report_type VARCHAR(50) NOT NULL,
+    related_id UUID,
+    due_date TIMESTAMP WITH TIME ZONE NOT NULL,
+    regulation VARCHAR(100) NOT NULL,
+    description TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'PENDING',
+    assigned_to UUID,
+    completed_at TIMESTAMP WITH TIME ZONE,
+    report_id UUID REFERENCES compliance_reports(report_id),
+    reminder_sent BOOLEAN NOT NULL DEFAULT FALSE,
+    escalated_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_compliance_deadlines_pending ON compliance_deadlines(due_date)
+    WHERE status = 'PENDING';
+
+REVOKE ALL ON aml_flags, kyc_verifications, gdpr_requests, compliance_reports, compliance_deadlines FROM PUBLIC;
+GRANT SELECT, INSERT, UPDATE ON aml_flags, kyc_verifications, gdpr_requests, compliance_reports, compliance_deadlines
+    TO audit_app;
@@ -0,0 +1,147 @@
+-- Monthly range partitioning of audit_events on timestamp. Old months can then be
+-- archived and detached instead of deleted row by row.
+--
+-- A partitioned table can only enforce uniqueness on keys that include the partition
+-- key, so global uniqueness of event_id, sequence_num and record_hash moves to the
+-- narrow audit_event_index. That table is never partitioned or detached: the hash
+-- chain and Merkle checkpoints keep verifying after a month's rows leave the ledger.
+
+ALTER TABLE audit_events RENAME TO audit_events_unpartitioned;
+
+CREATE TABLE audit_events (
+    event_id UUID NOT NULL,
+    transaction_id UUID,
+    user_id UUID NOT NULL,
+    actor_id UUID,
+    action_type VARCHAR(50) NOT NULL,
+    resource_type VARCHAR(50) NOT NULL,
+    resource_id VARCHAR(100) NOT NULL,
+    service_source VARCHAR(100),
+    timestamp TIMESTAMP WITH TIME ZONE NOT NULL,
+    result VARCHAR(20) NOT NULL,
+    failure_reason TEXT,
+    ip_address VARCHAR(45),
+    geolocation VARCHAR(100),
+    user_agent TEXT,
+    request_id VARCHAR(100),
+    session_id VARCHAR(100),
+    digital_signature TEXT NOT NULL,
+    signature_version SMALLINT NOT NULL DEFAULT 1,
+    signature_algorithm VARCHAR(20) NOT NULL DEFAULT 'HMAC-SHA256',
+    signing_key_id VARCHAR(100) NOT NULL DEFAULT '',
+    metadata JSONB,
+    data_before BYTEA,
+    data_after BYTEA,
+    compliance_flags TEXT [],
+    retention_category VARCHAR(50) NOT NULL DEFAULT 'STANDARD',
+    encryption_key_id INT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    sequence_num BIGINT NOT NULL,
+    prev_hash CHAR(64) NOT NULL,
+    record_hash CHAR(64) NOT NULL,
+    PRIMARY KEY (event_id, timestamp)
+) PARTITION BY RANGE (timestamp);
+
+-- Global identity of every event ever appended, including archived ones
+CREATE TABLE audit_event_index (
+    sequence_num BIGINT PRIMARY KEY,
+    event_id UUID NOT NULL UNIQUE,
+    record_hash CHAR(64) NOT NULL UNIQUE,
+    timestamp TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+-- Partition registry maintained by the partition manager
+CREATE TABLE audit_partitions (
+    partition_name TEXT PRIMARY KEY,
+    range_start TIMESTAMP WITH TIME ZONE NOT NULL UNIQUE,
+    range_end TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    archived_at TIMESTAMP WITH TIME ZONE,
+    archive_ref TEXT,
+    detached_at TIMESTAMP WITH TIME ZONE,
+    CHECK (range_end > range_start),
+    CHECK (detached_at IS NULL OR archived_at IS NOT NULL)
+);
+
+-- Catches events outside every monthly partition so a write is never lost
+CREATE TABLE audit_events_default PARTITION OF audit_events DEFAULT;
+
+-- Monthly partitions (UTC) from the oldest existing event through three months ahead
+DO $$
+DECLARE
+    first_month DATE;
+    last_month DATE;
+    m DATE;
+    part_name TEXT;
+BEGIN
+    SELECT date_trunc('month', COALESCE(MIN(timestamp), NOW()) AT TIME ZONE 'UTC')::date,
+           (date_trunc('month', GREATEST(COALESCE(MAX(timestamp), NOW()), NOW()) AT TIME ZONE 'UTC')
+               + INTERVAL '3 months')::date
+    INTO first_month, last_month
+    FROM audit_events_unpartitioned;
+
+    m := first_month;
+    WHILE m <= last_month LOOP
+        part_name := 'audit_events_y' || to_char(m, 'YYYY') || 'm' || to_char(m, 'MM');
+        EXECUTE format('CREATE TABLE %I PARTITION OF audit_events FOR VALUES FROM (%L) TO (%L)',
+            part_name,
+            m::timestamp AT TIME ZONE 'UTC',
+            (m + INTERVAL '1 month')::timestamp AT TIME ZONE 'UTC');
+        INSERT INTO audit_partitions (partition_name, range_start, range_end)
+        VALUES (part_name,
+                m::timestamp AT TIME ZONE 'UTC',
+                (m + INTERVAL '1 month')::timestamp AT TIME ZONE 'UTC');
+        m := (m + INTERVAL '1 month')::date;
+    END LOOP;
+END
+$$;
+
+INSERT INTO audit_events (
+    event_id, transaction_id, user_id, actor_id, action_type,
+    resource_type, resource_id, service_source, timestamp, result,
+    failure_reason, ip_address, geolocation, user_agent, request_id,
+    session_id, digital_signature, signature_version, signature_algorithm, signing_key_id,
+    metadata, data_before, data_after, compliance_flags, retention_category,
+    encryption_key_id, created_at, sequence_num, prev_hash, record_hash
+)
+SELECT
+    event_id, transaction_id, user_id, actor_id, action_type,
+    resource_type, resource_id, service_source, timestamp, result,
+    failure_reason, ip_address, geolocation, user_agent, request_id,
+    session_id, digital_signature, signature_version, signature_algorithm, signing_key_id,
+    metadata, data_before, data_after, compliance_flags, retention_category,
+    encryption_key_id, created_at, sequence_num, prev_hash, record_hash
+FROM audit_events_unpartitioned;
+
+INSERT INTO audit_event_index (sequence_num, event_id, record_hash, timestamp)
+SELECT sequence_num, event_id, record_hash, timestamp FROM audit_events_unpartitioned;
+
+-- Dropping removes the old indexes and append-only triggers along with the table
+DROP TABLE audit_events_unpartitioned;
+
+-- Indexes are created on every partition, including future ones
+CREATE INDEX idx_audit_transaction_id ON audit_events(transaction_id);
+CREATE INDEX idx_audit_user_id ON audit_events(user_id);
+CREATE INDEX idx_audit_timestamp ON audit_events(timestamp DESC);
+CREATE INDEX idx_audit_action_type ON audit_events(action_type);
+CREATE INDEX idx_audit_sequence_num ON audit_events(sequence_num);
+CREATE INDEX idx_audit_event_index_timestamp ON audit_event_index(timestamp);
+
+REVOKE ALL ON audit_events, audit_event_index, audit_partitions FROM PUBLIC;
+GRANT SELECT, INSERT ON audit_events, audit_event_index TO audit_app;
+GRANT SELECT ON audit_partitions TO audit_app;
+
+-- Append-only triggers; row triggers on the partitioned table are cloned to every partition
+CREATE TRIGGER audit_events_append_only
+    BEFORE UPDATE OR DELETE ON audit_events
+    FOR EACH ROW EXECUTE FUNCTION reject_ledger_mutation();
+CREATE TRIGGER audit_events_no_truncate
+    BEFORE TRUNCATE ON audit_events
+    FOR EACH STATEMENT EXECUTE FUNCTION reject_ledger_mutation();
+
+CREATE TRIGGER audit_event_index_append_only
+    BEFORE UPDATE OR DELETE ON audit_event_index
+    FOR EACH ROW EXECUTE FUNCTION reject_ledger_mutation();
+CREATE TRIGGER audit_event_index_no_truncate
+    BEFORE TRUNCATE ON audit_event_index
+    FOR EACH STATEMENT EXECUTE FUNCTION reject_ledger_mutation();
@@ -0,0 +1,4 @@
+-- Keyset pagination orders by (timestamp DESC, event_id DESC); the composite index
+-- serves both the ordering and the cursor bound, and supersedes idx_audit_timestamp.
+DROP INDEX IF EXISTS idx_audit_timestamp;
+CREATE INDEX idx_audit_timestamp_event_id ON audit_events(timestamp DESC, event_id DESC);
@@ -0,0 +1,10 @@
+-- Indexes backing the AuditEventFilter predicates that had none. Each is created on
+-- every partition of audit_events, including future ones.
+CREATE INDEX idx_audit_actor_id ON audit_events(actor_id) WHERE actor_id IS NOT NULL;
+CREATE INDEX idx_audit_resource ON audit_events(resource_type, resource_id);
+CREATE INDEX idx_audit_service_source ON audit_events(service_source);
+CREATE INDEX idx_audit_ip_address ON audit_events(ip_address);
+
+-- GIN indexes for array containment (@>) and JSONB containment / jsonpath (@>, @?, @@)
+CREATE INDEX idx_audit_compliance_flags ON audit_events USING GIN (compliance_flags);
+CREATE INDEX idx_audit_metadata ON audit_events USING GIN (metadata jsonb_path_ops);
@@ -0,0 +1,5 @@
+-- Access logs record the token subject alongside accessor_id, which is derived from the
+-- subject when it is not a UUID, so an entry can always be traced to the issued identity.
+ALTER TABLE access_logs ADD COLUMN accessor_subject VARCHAR(255);
+
+CREATE INDEX IF NOT EXISTS idx_access_logs_accessor ON access_logs(accessor_id, timestamp DESC);
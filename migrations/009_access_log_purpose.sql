@@ -0,0 +1,7 @@
+-- Purpose-of-access justification, split out of the free-form purpose column so
+-- compliance can find every read made under a given case or ticket.
+ALTER TABLE access_logs ADD COLUMN purpose_code VARCHAR(50);
+ALTER TABLE access_logs ADD COLUMN purpose_reference VARCHAR(100);
+
+CREATE INDEX IF NOT EXISTS idx_access_logs_purpose ON access_logs(purpose_code, purpose_reference, timestamp DESC);
+CREATE INDEX IF NOT EXISTS idx_access_logs_timestamp ON access_logs(timestamp DESC);
@@ -0,0 +1,13 @@
+-- Idempotency keys for synchronous ingestion. A key is scoped to the caller that sent it
+-- and bound to a hash of the request, so a retry returns the events recorded the first
+-- time while reuse of the key for a different request is refused.
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    caller VARCHAR(255) NOT NULL,
+    idempotency_key VARCHAR(255) NOT NULL,
+    request_hash CHAR(64) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (caller, idempotency_key)
+);
+
+REVOKE ALL ON idempotency_keys FROM PUBLIC;
+GRANT SELECT, INSERT ON idempotency_keys TO audit_app;
@@ -0,0 +1,25 @@
+-- Kafka records the consumer could not record in the ledger, kept byte for byte with
+-- their headers and the class of failure so they can be inspected and replayed. Only the
+-- replay bookkeeping columns are ever updated.
+CREATE TABLE IF NOT EXISTS dlq_events (
+    dlq_id UUID PRIMARY KEY,
+    source_topic VARCHAR(255) NOT NULL,
+    source_partition INTEGER NOT NULL,
+    source_offset BIGINT NOT NULL,
+    message_key BYTEA,
+    payload BYTEA NOT NULL,
+    headers JSONB NOT NULL DEFAULT '[]',
+    message_timestamp TIMESTAMP WITH TIME ZONE,
+    error_class VARCHAR(50) NOT NULL,
+    error_message TEXT NOT NULL,
+    failed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    replayed_at TIMESTAMP WITH TIME ZONE,
+    replayed_event_id UUID,
+    UNIQUE (source_topic, source_partition, source_offset)
+);
+CREATE INDEX IF NOT EXISTS idx_dlq_events_pending ON dlq_events(failed_at)
+    WHERE replayed_at IS NULL;
+
+REVOKE ALL ON dlq_events FROM PUBLIC;
+GRANT SELECT, INSERT ON dlq_events TO audit_app;
+GRANT UPDATE (replayed_at, replayed_event_id) ON dlq_events TO audit_app;
@@ -0,0 +1,5 @@
+-- Schema violations of quarantined Kafka records, each with the JSON pointer of the
+-- failing field, so a producer bug can be located without re-validating the payload.
+ALTER TABLE dlq_events ADD COLUMN violations JSONB NOT NULL DEFAULT '[]';
+
+CREATE INDEX IF NOT EXISTS idx_dlq_events_class ON dlq_events(error_class, failed_at);
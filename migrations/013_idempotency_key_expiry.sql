@@ -0,0 +1,5 @@
+-- Idempotency keys expire. The service deletes keys older than the configured TTL, after
+-- which a caller may use the key again for a new request.
+GRANT DELETE ON idempotency_keys TO audit_app;
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_created_at ON idempotency_keys(created_at);
@@ -0,0 +1,3 @@
+-- Ledger verification resolves a time window to chain positions by created_at, which
+-- the ledger assigns in sequence order under the chain lock.
+CREATE INDEX IF NOT EXISTS idx_audit_created_at ON audit_events(created_at);
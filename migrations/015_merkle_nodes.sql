@@ -0,0 +1,21 @@
+-- Perfect subtree hashes of the checkpoint Merkle tree. A perfect subtree never changes
+-- once its last leaf is appended, so each checkpoint extends the tree from the stored
+-- right edge and proofs read O(log n) nodes instead of rehashing every leaf. Nodes are
+-- written with the checkpoint that completes them and are append-only like the ledger.
+CREATE TABLE IF NOT EXISTS merkle_nodes (
+    level SMALLINT NOT NULL,
+    node_index BIGINT NOT NULL,
+    hash BYTEA NOT NULL,
+    PRIMARY KEY (level, node_index)
+);
+
+ALTER TABLE merkle_nodes OWNER TO audit_owner;
+REVOKE ALL ON merkle_nodes FROM PUBLIC;
+GRANT SELECT, INSERT ON merkle_nodes TO audit_app;
+
+CREATE OR REPLACE TRIGGER merkle_nodes_append_only
+    BEFORE UPDATE OR DELETE ON merkle_nodes
+    FOR EACH ROW EXECUTE FUNCTION reject_ledger_mutation();
+CREATE OR REPLACE TRIGGER merkle_nodes_no_truncate
+    BEFORE TRUNCATE ON merkle_nodes
+    FOR EACH STATEMENT EXECUTE FUNCTION reject_ledger_mutation();
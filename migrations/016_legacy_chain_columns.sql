@@ -0,0 +1,14 @@
+-- Ledgers created before signature versions and the hash chain gain the columns here.
+-- The chain is backfilled in created_at order by the migration runner, which alone can
+-- reproduce the canonical record encoding the chain hashes. A ledger created by
+-- 001_init.sql already has every column and this does nothing.
+ALTER TABLE audit_events
+    ADD COLUMN IF NOT EXISTS signature_version SMALLINT NOT NULL DEFAULT 1,
+    ADD COLUMN IF NOT EXISTS signature_algorithm VARCHAR(20) NOT NULL DEFAULT 'HMAC-SHA256',
+    ADD COLUMN IF NOT EXISTS signing_key_id VARCHAR(100) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS sequence_num BIGINT,
+    ADD COLUMN IF NOT EXISTS prev_hash CHAR(64),
+    ADD COLUMN IF NOT EXISTS record_hash CHAR(64);
+ALTER TABLE audit_events DISABLE TRIGGER audit_events_append_only;
+UPDATE audit_events SET created_at = timestamp WHERE created_at IS NULL;
+ALTER TABLE audit_events ENABLE TRIGGER audit_events_append_only;
@@ -0,0 +1,10 @@
+// Package migrations embeds the numbered schema migrations. Files are named
+// NNN_description.sql and applied in version order by internal/migrate.
+package migrations
+
+import "embed"
+
+// FS holds every migration file
+//
+//go:embed *.sql
+var FS embed.FS
@@ -0,0 +1,989 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        v5.29.3
+// source: audit/v1/audit.proto
+
+package auditv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type RecordEventRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Producer-assigned ID; generated when empty.
+	EventId       string  `protobuf:"bytes,1,opt,name=event_id,json=eventId,proto3" json:"event_id,omitempty"`
+	TransactionId *string `protobuf:"bytes,2,opt,name=transaction_id,json=transactionId,proto3,oneof" json:"transaction_id,omitempty"`
+	UserId        string  `protobuf:"bytes,3,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	ActorId       *string `protobuf:"bytes,4,opt,name=actor_id,json=actorId,proto3,oneof" json:"actor_id,omitempty"`
+	ActionType    string  `protobuf:"bytes,5,opt,name=action_type,json=actionType,proto3" json:"action_type,omitempty"`
+	ResourceType  string  `protobuf:"bytes,6,opt,name=resource_type,json=resourceType,proto3" json:"resource_type,omitempty"`
+	ResourceId    string  `protobuf:"bytes,7,opt,name=resource_id,json=resourceId,proto3" json:"resource_id,omitempty"`
+	ServiceSource string  `protobuf:"bytes,8,opt,name=service_source,json=serviceSource,proto3" json:"service_source,omitempty"`
+	// When the audited action happened; the receive time when unset.
+	Timestamp *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	// SUCCESS, FAILURE, PENDING or DENIED.
+	Result        string  `protobuf:"bytes,10,opt,name=result,proto3" json:"result,omitempty"`
+	FailureReason *string `protobuf:"bytes,11,opt,name=failure_reason,json=failureReason,proto3,oneof" json:"failure_reason,omitempty"`
+	IpAddress     string  `protobuf:"bytes,12,opt,name=ip_address,json=ipAddress,proto3" json:"ip_address,omitempty"`
+	Geolocation   *string `protobuf:"bytes,13,opt,name=geolocation,proto3,oneof" json:"geolocation,omitempty"`
+	UserAgent     *string `protobuf:"bytes,14,opt,name=user_agent,json=userAgent,proto3,oneof" json:"user_agent,omitempty"`
+	RequestId     string  `protobuf:"bytes,15,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	SessionId     *string `protobuf:"bytes,16,opt,name=session_id,json=sessionId,proto3,oneof" json:"session_id,omitempty"`
+	// JSON object with additional context.
+	Metadata          []byte   `protobuf:"bytes,17,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	ComplianceFlags   []string `protobuf:"bytes,18,rep,name=compliance_flags,json=complianceFlags,proto3" json:"compliance_flags,omitempty"`
+	RetentionCategory string   `protobuf:"bytes,19,opt,name=retention_category,json=retentionCategory,proto3" json:"retention_category,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *RecordEventRequest) Reset() {
+	*x = RecordEventRequest{}
+	mi := &file_audit_v1_audit_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RecordEventRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RecordEventRequest) ProtoMessage() {}
+
+func (x *RecordEventRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_audit_v1_audit_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RecordEventRequest.ProtoReflect.Descriptor instead.
+func (*RecordEventRequest) Descriptor() ([]byte, []int) {
+	return file_audit_v1_audit_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *RecordEventRequest) GetEventId() string {
+	if x != nil {
+		return x.EventId
+	}
+	return ""
+}
+
+func (x *RecordEventRequest) GetTransactionId() string {
+	if x != nil && x.TransactionId != nil {
+		return *x.TransactionId
+	}
+	return ""
+}
+
+func (x *RecordEventRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *RecordEventRequest) GetActorId() string {
+	if x != nil && x.ActorId != nil {
+		return *x.ActorId
+	}
+	return ""
+}
+
+func (x *RecordEventRequest) GetActionType() string {
+	if x != nil {
+		return x.ActionType
+	}
+	return ""
+}
+
+func (x *RecordEventRequest) GetResourceType() string {
+	if x != nil {
+		return x.ResourceType
+	}
+	return ""
+}
+
+func (x *RecordEventRequest) GetResourceId() string {
+	if x != nil {
+		return x.ResourceId
+	}
+	return ""
+}
+
+func (x *RecordEventRequest) GetServiceSource() string {
+	if x != nil {
+		return x.ServiceSource
+	}
+	return ""
+}
+
+func (x *RecordEventRequest) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+func (x *RecordEventRequest) GetResult() string {
+	if x != nil {
+		return x.Result
+	}
+	return ""
+}
+
+func (x *RecordEventRequest) GetFailureReason() string {
+	if x != nil && x.FailureReason != nil {
+		return *x.FailureReason
+	}
+	return ""
+}
+
+func (x *RecordEventRequest) GetIpAddress() string {
+	if x != nil {
+		return x.IpAddress
+	}
+	return ""
+}
+
+func (x *RecordEventRequest) GetGeolocation() string {
+	if x != nil && x.Geolocation != nil {
+		return *x.Geolocation
+	}
+	return ""
+}
+
+func (x *RecordEventRequest) GetUserAgent() string {
+	if x != nil && x.UserAgent != nil {
+		return *x.UserAgent
+	}
+	return ""
+}
+
+func (x *RecordEventRequest) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *RecordEventRequest) GetSessionId() string {
+	if x != nil && x.SessionId != nil {
+		return *x.SessionId
+	}
+	return ""
+}
+
+func (x *RecordEventRequest) GetMetadata() []byte {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+func (x *RecordEventRequest) GetComplianceFlags() []string {
+	if x != nil {
+		return x.ComplianceFlags
+	}
+	return nil
+}
+
+func (x *RecordEventRequest) GetRetentionCategory() string {
+	if x != nil {
+		return x.RetentionCategory
+	}
+	return ""
+}
+
+type RecordEventResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	EventId       string                 `protobuf:"bytes,1,opt,name=event_id,json=eventId,proto3" json:"event_id,omitempty"`
+	SequenceNum   int64                  `protobuf:"varint,2,opt,name=sequence_num,json=sequenceNum,proto3" json:"sequence_num,omitempty"`
+	RecordHash    string                 `protobuf:"bytes,3,opt,name=record_hash,json=recordHash,proto3" json:"record_hash,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RecordEventResponse) Reset() {
+	*x = RecordEventResponse{}
+	mi := &file_audit_v1_audit_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RecordEventResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RecordEventResponse) ProtoMessage() {}
+
+func (x *RecordEventResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_audit_v1_audit_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RecordEventResponse.ProtoReflect.Descriptor instead.
+func (*RecordEventResponse) Descriptor() ([]byte, []int) {
+	return file_audit_v1_audit_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *RecordEventResponse) GetEventId() string {
+	if x != nil {
+		return x.EventId
+	}
+	return ""
+}
+
+func (x *RecordEventResponse) GetSequenceNum() int64 {
+	if x != nil {
+		return x.SequenceNum
+	}
+	return 0
+}
+
+func (x *RecordEventResponse) GetRecordHash() string {
+	if x != nil {
+		return x.RecordHash
+	}
+	return ""
+}
+
+func (x *RecordEventResponse) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+type RecordEventsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Events        []*RecordEventResponse `protobuf:"bytes,1,rep,name=events,proto3" json:"events,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RecordEventsResponse) Reset() {
+	*x = RecordEventsResponse{}
+	mi := &file_audit_v1_audit_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RecordEventsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RecordEventsResponse) ProtoMessage() {}
+
+func (x *RecordEventsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_audit_v1_audit_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RecordEventsResponse.ProtoReflect.Descriptor instead.
+func (*RecordEventsResponse) Descriptor() ([]byte, []int) {
+	return file_audit_v1_audit_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *RecordEventsResponse) GetEvents() []*RecordEventResponse {
+	if x != nil {
+		return x.Events
+	}
+	return nil
+}
+
+type QueryEventsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        *string                `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3,oneof" json:"user_id,omitempty"`
+	ActorId       *string                `protobuf:"bytes,2,opt,name=actor_id,json=actorId,proto3,oneof" json:"actor_id,omitempty"`
+	TransactionId *string                `protobuf:"bytes,3,opt,name=transaction_id,json=transactionId,proto3,oneof" json:"transaction_id,omitempty"`
+	ActionTypes   []string               `protobuf:"bytes,4,rep,name=action_types,json=actionTypes,proto3" json:"action_types,omitempty"`
+	ResourceTypes []string               `protobuf:"bytes,5,rep,name=resource_types,json=resourceTypes,proto3" json:"resource_types,omitempty"`
+	ResourceId    *string                `protobuf:"bytes,6,opt,name=resource_id,json=resourceId,proto3,oneof" json:"resource_id,omitempty"`
+	StartTime     *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=start_time,json=startTime,proto3" json:"start_time,omitempty"`
+	EndTime       *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=end_time,json=endTime,proto3" json:"end_time,omitempty"`
+	Result        *string                `protobuf:"bytes,9,opt,name=result,proto3,oneof" json:"result,omitempty"`
+	ServiceSource *string                `protobuf:"bytes,10,opt,name=service_source,json=serviceSource,proto3,oneof" json:"service_source,omitempty"`
+	// Maximum number of events to stream; 0 streams every match.
+	Limit         int32 `protobuf:"varint,11,opt,name=limit,proto3" json:"limit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QueryEventsRequest) Reset() {
+	*x = QueryEventsRequest{}
+	mi := &file_audit_v1_audit_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QueryEventsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QueryEventsRequest) ProtoMessage() {}
+
+func (x *QueryEventsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_audit_v1_audit_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QueryEventsRequest.ProtoReflect.Descriptor instead.
+func (*QueryEventsRequest) Descriptor() ([]byte, []int) {
+	return file_audit_v1_audit_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *QueryEventsRequest) GetUserId() string {
+	if x != nil && x.UserId != nil {
+		return *x.UserId
+	}
+	return ""
+}
+
+func (x *QueryEventsRequest) GetActorId() string {
+	if x != nil && x.ActorId != nil {
+		return *x.ActorId
+	}
+	return ""
+}
+
+func (x *QueryEventsRequest) GetTransactionId() string {
+	if x != nil && x.TransactionId != nil {
+		return *x.TransactionId
+	}
+	return ""
+}
+
+func (x *QueryEventsRequest) GetActionTypes() []string {
+	if x != nil {
+		return x.ActionTypes
+	}
+	return nil
+}
+
+func (x *QueryEventsRequest) GetResourceTypes() []string {
+	if x != nil {
+		return x.ResourceTypes
+	}
+	return nil
+}
+
+func (x *QueryEventsRequest) GetResourceId() string {
+	if x != nil && x.ResourceId != nil {
+		return *x.ResourceId
+	}
+	return ""
+}
+
+func (x *QueryEventsRequest) GetStartTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.StartTime
+	}
+	return nil
+}
+
+func (x *QueryEventsRequest) GetEndTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.EndTime
+	}
+	return nil
+}
+
+func (x *QueryEventsRequest) GetResult() string {
+	if x != nil && x.Result != nil {
+		return *x.Result
+	}
+	return ""
+}
+
+func (x *QueryEventsRequest) GetServiceSource() string {
+	if x != nil && x.ServiceSource != nil {
+		return *x.ServiceSource
+	}
+	return ""
+}
+
+func (x *QueryEventsRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+type AuditEvent struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	EventId            string                 `protobuf:"bytes,1,opt,name=event_id,json=eventId,proto3" json:"event_id,omitempty"`
+	TransactionId      *string                `protobuf:"bytes,2,opt,name=transaction_id,json=transactionId,proto3,oneof" json:"transaction_id,omitempty"`
+	UserId             string                 `protobuf:"bytes,3,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	ActorId            *string                `protobuf:"bytes,4,opt,name=actor_id,json=actorId,proto3,oneof" json:"actor_id,omitempty"`
+	ActionType         string                 `protobuf:"bytes,5,opt,name=action_type,json=actionType,proto3" json:"action_type,omitempty"`
+	ResourceType       string                 `protobuf:"bytes,6,opt,name=resource_type,json=resourceType,proto3" json:"resource_type,omitempty"`
+	ResourceId         string                 `protobuf:"bytes,7,opt,name=resource_id,json=resourceId,proto3" json:"resource_id,omitempty"`
+	ServiceSource      string                 `protobuf:"bytes,8,opt,name=service_source,json=serviceSource,proto3" json:"service_source,omitempty"`
+	Timestamp          *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Result             string                 `protobuf:"bytes,10,opt,name=result,proto3" json:"result,omitempty"`
+	FailureReason      *string                `protobuf:"bytes,11,opt,name=failure_reason,json=failureReason,proto3,oneof" json:"failure_reason,omitempty"`
+	IpAddress          string                 `protobuf:"bytes,12,opt,name=ip_address,json=ipAddress,proto3" json:"ip_address,omitempty"`
+	Geolocation        *string                `protobuf:"bytes,13,opt,name=geolocation,proto3,oneof" json:"geolocation,omitempty"`
+	UserAgent          *string                `protobuf:"bytes,14,opt,name=user_agent,json=userAgent,proto3,oneof" json:"user_agent,omitempty"`
+	RequestId          string                 `protobuf:"bytes,15,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	SessionId          *string                `protobuf:"bytes,16,opt,name=session_id,json=sessionId,proto3,oneof" json:"session_id,omitempty"`
+	Metadata           []byte                 `protobuf:"bytes,17,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	ComplianceFlags    []string               `protobuf:"bytes,18,rep,name=compliance_flags,json=complianceFlags,proto3" json:"compliance_flags,omitempty"`
+	RetentionCategory  string                 `protobuf:"bytes,19,opt,name=retention_category,json=retentionCategory,proto3" json:"retention_category,omitempty"`
+	CreatedAt          *timestamppb.Timestamp `protobuf:"bytes,20,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	SequenceNum        int64                  `protobuf:"varint,21,opt,name=sequence_num,json=sequenceNum,proto3" json:"sequence_num,omitempty"`
+	PrevHash           string                 `protobuf:"bytes,22,opt,name=prev_hash,json=prevHash,proto3" json:"prev_hash,omitempty"`
+	RecordHash         string                 `protobuf:"bytes,23,opt,name=record_hash,json=recordHash,proto3" json:"record_hash,omitempty"`
+	DigitalSignature   string                 `protobuf:"bytes,24,opt,name=digital_signature,json=digitalSignature,proto3" json:"digital_signature,omitempty"`
+	SignatureAlgorithm string                 `protobuf:"bytes,25,opt,name=signature_algorithm,json=signatureAlgorithm,proto3" json:"signature_algorithm,omitempty"`
+	SigningKeyId       string                 `protobuf:"bytes,26,opt,name=signing_key_id,json=signingKeyId,proto3" json:"signing_key_id,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *AuditEvent) Reset() {
+	*x = AuditEvent{}
+	mi := &file_audit_v1_audit_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AuditEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AuditEvent) ProtoMessage() {}
+
+func (x *AuditEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_audit_v1_audit_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AuditEvent.ProtoReflect.Descriptor instead.
+func (*AuditEvent) Descriptor() ([]byte, []int) {
+	return file_audit_v1_audit_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *AuditEvent) GetEventId() string {
+	if x != nil {
+		return x.EventId
+	}
+	return ""
+}
+
+func (x *AuditEvent) GetTransactionId() string {
+	if x != nil && x.TransactionId != nil {
+		return *x.TransactionId
+	}
+	return ""
+}
+
+func (x *AuditEvent) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *AuditEvent) GetActorId() string {
+	if x != nil && x.ActorId != nil {
+		return *x.ActorId
+	}
+	return ""
+}
+
+func (x *AuditEvent) GetActionType() string {
+	if x != nil {
+		return x.ActionType
+	}
+	return ""
+}
+
+func (x *AuditEvent) GetResourceType() string {
+	if x != nil {
+		return x.ResourceType
+	}
+	return ""
+}
+
+func (x *AuditEvent) GetResourceId() string {
+	if x != nil {
+		return x.ResourceId
+	}
+	return ""
+}
+
+func (x *AuditEvent) GetServiceSource() string {
+	if x != nil {
+		return x.ServiceSource
+	}
+	return ""
+}
+
+func (x *AuditEvent) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+func (x *AuditEvent) GetResult() string {
+	if x != nil {
+		return x.Result
+	}
+	return ""
+}
+
+func (x *AuditEvent) GetFailureReason() string {
+	if x != nil && x.FailureReason != nil {
+		return *x.FailureReason
+	}
+	return ""
+}
+
+func (x *AuditEvent) GetIpAddress() string {
+	if x != nil {
+		return x.IpAddress
+	}
+	return ""
+}
+
+func (x *AuditEvent) GetGeolocation() string {
+	if x != nil && x.Geolocation != nil {
+		return *x.Geolocation
+	}
+	return ""
+}
+
+func (x *AuditEvent) GetUserAgent() string {
+	if x != nil && x.UserAgent != nil {
+		return *x.UserAgent
+	}
+	return ""
+}
+
+func (x *AuditEvent) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *AuditEvent) GetSessionId() string {
+	if x != nil && x.SessionId != nil {
+		return *x.SessionId
+	}
+	return ""
+}
+
+func (x *AuditEvent) GetMetadata() []byte {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+func (x *AuditEvent) GetComplianceFlags() []string {
+	if x != nil {
+		return x.ComplianceFlags
+	}
+	return nil
+}
+
+func (x *AuditEvent) GetRetentionCategory() string {
+	if x != nil {
+		return x.RetentionCategory
+	}
+	return ""
+}
+
+func (x *AuditEvent) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *AuditEvent) GetSequenceNum() int64 {
+	if x != nil {
+		return x.SequenceNum
+	}
+	return 0
+}
+
+func (x *AuditEvent) GetPrevHash() string {
+	if x != nil {
+		return x.PrevHash
+	}
+	return ""
+}
+
+func (x *AuditEvent) GetRecordHash() string {
+	if x != nil {
+		return x.RecordHash
+	}
+	return ""
+}
+
+func (x *AuditEvent) GetDigitalSignature() string {
+	if x != nil {
+		return x.DigitalSignature
+	}
+	return ""
+}
+
+func (x *AuditEvent) GetSignatureAlgorithm() string {
+	if x != nil {
+		return x.SignatureAlgorithm
+	}
+	return ""
+}
+
+func (x *AuditEvent) GetSigningKeyId() string {
+	if x != nil {
+		return x.SigningKeyId
+	}
+	return ""
+}
+
+type VerifyEventRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	EventId       string                 `protobuf:"bytes,1,opt,name=event_id,json=eventId,proto3" json:"event_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VerifyEventRequest) Reset() {
+	*x = VerifyEventRequest{}
+	mi := &file_audit_v1_audit_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VerifyEventRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VerifyEventRequest) ProtoMessage() {}
+
+func (x *VerifyEventRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_audit_v1_audit_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VerifyEventRequest.ProtoReflect.Descriptor instead.
+func (*VerifyEventRequest) Descriptor() ([]byte, []int) {
+	return file_audit_v1_audit_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *VerifyEventRequest) GetEventId() string {
+	if x != nil {
+		return x.EventId
+	}
+	return ""
+}
+
+type VerifyEventResponse struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	EventId string                 `protobuf:"bytes,1,opt,name=event_id,json=eventId,proto3" json:"event_id,omitempty"`
+	// False when the stored event no longer matches its signature or chain hash.
+	Valid         bool `protobuf:"varint,2,opt,name=valid,proto3" json:"valid,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VerifyEventResponse) Reset() {
+	*x = VerifyEventResponse{}
+	mi := &file_audit_v1_audit_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VerifyEventResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VerifyEventResponse) ProtoMessage() {}
+
+func (x *VerifyEventResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_audit_v1_audit_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VerifyEventResponse.ProtoReflect.Descriptor instead.
+func (*VerifyEventResponse) Descriptor() ([]byte, []int) {
+	return file_audit_v1_audit_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *VerifyEventResponse) GetEventId() string {
+	if x != nil {
+		return x.EventId
+	}
+	return ""
+}
+
+func (x *VerifyEventResponse) GetValid() bool {
+	if x != nil {
+		return x.Valid
+	}
+	return false
+}
+
+var File_audit_v1_audit_proto protoreflect.FileDescriptor
+
+const file_audit_v1_audit_proto_rawDesc = "" +
+	"\n" +
+	"\x14audit/v1/audit.proto\x12\x10banking.audit.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\xa4\x06\n" +
+	"\x12RecordEventRequest\x12\x19\n" +
+	"\bevent_id\x18\x01 \x01(\tR\aeventId\x12*\n" +
+	"\x0etransaction_id\x18\x02 \x01(\tH\x00R\rtransactionId\x88\x01\x01\x12\x17\n" +
+	"\auser_id\x18\x03 \x01(\tR\x06userId\x12\x1e\n" +
+	"\bactor_id\x18\x04 \x01(\tH\x01R\aactorId\x88\x01\x01\x12\x1f\n" +
+	"\vaction_type\x18\x05 \x01(\tR\n" +
+	"actionType\x12#\n" +
+	"\rresource_type\x18\x06 \x01(\tR\fresourceType\x12\x1f\n" +
+	"\vresource_id\x18\a \x01(\tR\n" +
+	"resourceId\x12%\n" +
+	"\x0eservice_source\x18\b \x01(\tR\rserviceSource\x128\n" +
+	"\ttimestamp\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x12\x16\n" +
+	"\x06result\x18\n" +
+	" \x01(\tR\x06result\x12*\n" +
+	"\x0efailure_reason\x18\v \x01(\tH\x02R\rfailureReason\x88\x01\x01\x12\x1d\n" +
+	"\n" +
+	"ip_address\x18\f \x01(\tR\tipAddress\x12%\n" +
+	"\vgeolocation\x18\r \x01(\tH\x03R\vgeolocation\x88\x01\x01\x12\"\n" +
+	"\n" +
+	"user_agent\x18\x0e \x01(\tH\x04R\tuserAgent\x88\x01\x01\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x0f \x01(\tR\trequestId\x12\"\n" +
+	"\n" +
+	"session_id\x18\x10 \x01(\tH\x05R\tsessionId\x88\x01\x01\x12\x1a\n" +
+	"\bmetadata\x18\x11 \x01(\fR\bmetadata\x12)\n" +
+	"\x10compliance_flags\x18\x12 \x03(\tR\x0fcomplianceFlags\x12-\n" +
+	"\x12retention_category\x18\x13 \x01(\tR\x11retentionCategoryB\x11\n" +
+	"\x0f_transaction_idB\v\n" +
+	"\t_actor_idB\x11\n" +
+	"\x0f_failure_reasonB\x0e\n" +
+	"\f_geolocationB\r\n" +
+	"\v_user_agentB\r\n" +
+	"\v_session_id\"\xaf\x01\n" +
+	"\x13RecordEventResponse\x12\x19\n" +
+	"\bevent_id\x18\x01 \x01(\tR\aeventId\x12!\n" +
+	"\fsequence_num\x18\x02 \x01(\x03R\vsequenceNum\x12\x1f\n" +
+	"\vrecord_hash\x18\x03 \x01(\tR\n" +
+	"recordHash\x129\n" +
+	"\n" +
+	"created_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\"U\n" +
+	"\x14RecordEventsResponse\x12=\n" +
+	"\x06events\x18\x01 \x03(\v2%.banking.audit.v1.RecordEventResponseR\x06events\"\x99\x04\n" +
+	"\x12QueryEventsRequest\x12\x1c\n" +
+	"\auser_id\x18\x01 \x01(\tH\x00R\x06userId\x88\x01\x01\x12\x1e\n" +
+	"\bactor_id\x18\x02 \x01(\tH\x01R\aactorId\x88\x01\x01\x12*\n" +
+	"\x0etransaction_id\x18\x03 \x01(\tH\x02R\rtransactionId\x88\x01\x01\x12!\n" +
+	"\faction_types\x18\x04 \x03(\tR\vactionTypes\x12%\n" +
+	"\x0eresource_types\x18\x05 \x03(\tR\rresourceTypes\x12$\n" +
+	"\vresource_id\x18\x06 \x01(\tH\x03R\n" +
+	"resourceId\x88\x01\x01\x129\n" +
+	"\n" +
+	"start_time\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\tstartTime\x125\n" +
+	"\bend_time\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\aendTime\x12\x1b\n" +
+	"\x06result\x18\t \x01(\tH\x04R\x06result\x88\x01\x01\x12*\n" +
+	"\x0eservice_source\x18\n" +
+	" \x01(\tH\x05R\rserviceSource\x88\x01\x01\x12\x14\n" +
+	"\x05limit\x18\v \x01(\x05R\x05limitB\n" +
+	"\n" +
+	"\b_user_idB\v\n" +
+	"\t_actor_idB\x11\n" +
+	"\x0f_transaction_idB\x0e\n" +
+	"\f_resource_idB\t\n" +
+	"\a_resultB\x11\n" +
+	"\x0f_service_source\"\xbc\b\n" +
+	"\n" +
+	"AuditEvent\x12\x19\n" +
+	"\bevent_id\x18\x01 \x01(\tR\aeventId\x12*\n" +
+	"\x0etransaction_id\x18\x02 \x01(\tH\x00R\rtransactionId\x88\x01\x01\x12\x17\n" +
+	"\auser_id\x18\x03 \x01(\tR\x06userId\x12\x1e\n" +
+	"\bactor_id\x18\x04 \x01(\tH\x01R\aactorId\x88\x01\x01\x12\x1f\n" +
+	"\vaction_type\x18\x05 \x01(\tR\n" +
+	"actionType\x12#\n" +
+	"\rresource_type\x18\x06 \x01(\tR\fresourceType\x12\x1f\n" +
+	"\vresource_id\x18\a \x01(\tR\n" +
+	"resourceId\x12%\n" +
+	"\x0eservice_source\x18\b \x01(\tR\rserviceSource\x128\n" +
+	"\ttimestamp\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x12\x16\n" +
+	"\x06result\x18\n" +
+	" \x01(\tR\x06result\x12*\n" +
+	"\x0efailure_reason\x18\v \x01(\tH\x02R\rfailureReason\x88\x01\x01\x12\x1d\n" +
+	"\n" +
+	"ip_address\x18\f \x01(\tR\tipAddress\x12%\n" +
+	"\vgeolocation\x18\r \x01(\tH\x03R\vgeolocation\x88\x01\x01\x12\"\n" +
+	"\n" +
+	"user_agent\x18\x0e \x01(\tH\x04R\tuserAgent\x88\x01\x01\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x0f \x01(\tR\trequestId\x12\"\n" +
+	"\n" +
+	"session_id\x18\x10 \x01(\tH\x05R\tsessionId\x88\x01\x01\x12\x1a\n" +
+	"\bmetadata\x18\x11 \x01(\fR\bmetadata\x12)\n" +
+	"\x10compliance_flags\x18\x12 \x03(\tR\x0fcomplianceFlags\x12-\n" +
+	"\x12retention_category\x18\x13 \x01(\tR\x11retentionCategory\x129\n" +
+	"\n" +
+	"created_at\x18\x14 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x12!\n" +
+	"\fsequence_num\x18\x15 \x01(\x03R\vsequenceNum\x12\x1b\n" +
+	"\tprev_hash\x18\x16 \x01(\tR\bprevHash\x12\x1f\n" +
+	"\vrecord_hash\x18\x17 \x01(\tR\n" +
+	"recordHash\x12+\n" +
+	"\x11digital_signature\x18\x18 \x01(\tR\x10digitalSignature\x12/\n" +
+	"\x13signature_algorithm\x18\x19 \x01(\tR\x12signatureAlgorithm\x12$\n" +
+	"\x0esigning_key_id\x18\x1a \x01(\tR\fsigningKeyIdB\x11\n" +
+	"\x0f_transaction_idB\v\n" +
+	"\t_actor_idB\x11\n" +
+	"\x0f_failure_reasonB\x0e\n" +
+	"\f_geolocationB\r\n" +
+	"\v_user_agentB\r\n" +
+	"\v_session_id\"/\n" +
+	"\x12VerifyEventRequest\x12\x19\n" +
+	"\bevent_id\x18\x01 \x01(\tR\aeventId\"F\n" +
+	"\x13VerifyEventResponse\x12\x19\n" +
+	"\bevent_id\x18\x01 \x01(\tR\aeventId\x12\x14\n" +
+	"\x05valid\x18\x02 \x01(\bR\x05valid2\xfb\x02\n" +
+	"\fAuditService\x12Z\n" +
+	"\vRecordEvent\x12$.banking.audit.v1.RecordEventRequest\x1a%.banking.audit.v1.RecordEventResponse\x12^\n" +
+	"\fRecordEvents\x12$.banking.audit.v1.RecordEventRequest\x1a&.banking.audit.v1.RecordEventsResponse(\x01\x12S\n" +
+	"\vQueryEvents\x12$.banking.audit.v1.QueryEventsRequest\x1a\x1c.banking.audit.v1.AuditEvent0\x01\x12Z\n" +
+	"\vVerifyEvent\x12$.banking.audit.v1.VerifyEventRequest\x1a%.banking.audit.v1.VerifyEventResponseB<Z:github.com/banking/audit-compliance/proto/audit/v1;auditv1b\x06proto3"
+
+var (
+	file_audit_v1_audit_proto_rawDescOnce sync.Once
+	file_audit_v1_audit_proto_rawDescData []byte
+)
+
+func file_audit_v1_audit_proto_rawDescGZIP() []byte {
+	file_audit_v1_audit_proto_rawDescOnce.Do(func() {
+		file_audit_v1_audit_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_audit_v1_audit_proto_rawDesc), len(file_audit_v1_audit_proto_rawDesc)))
+	})
+	return file_audit_v1_audit_proto_rawDescData
+}
+
+var file_audit_v1_audit_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_audit_v1_audit_proto_goTypes = []any{
+	(*RecordEventRequest)(nil),    // 0: banking.audit.v1.RecordEventRequest
+	(*RecordEventResponse)(nil),   // 1: banking.audit.v1.RecordEventResponse
+	(*RecordEventsResponse)(nil),  // 2: banking.audit.v1.RecordEventsResponse
+	(*QueryEventsRequest)(nil),    // 3: banking.audit.v1.QueryEventsRequest
+	(*AuditEvent)(nil),            // 4: banking.audit.v1.AuditEvent
+	(*VerifyEventRequest)(nil),    // 5: banking.audit.v1.VerifyEventRequest
+	(*VerifyEventResponse)(nil),   // 6: banking.audit.v1.VerifyEventResponse
+	(*timestamppb.Timestamp)(nil), // 7: google.protobuf.Timestamp
+}
+var file_audit_v1_audit_proto_depIdxs = []int32{
+	7,  // 0: banking.audit.v1.RecordEventRequest.timestamp:type_name -> google.protobuf.Timestamp
+	7,  // 1: banking.audit.v1.RecordEventResponse.created_at:type_name -> google.protobuf.Timestamp
+	1,  // 2: banking.audit.v1.RecordEventsResponse.events:type_name -> banking.audit.v1.RecordEventResponse
+	7,  // 3: banking.audit.v1.QueryEventsRequest.start_time:type_name -> google.protobuf.Timestamp
+	7,  // 4: banking.audit.v1.QueryEventsRequest.end_time:type_name -> google.protobuf.Timestamp
+	7,  // 5: banking.audit.v1.AuditEvent.timestamp:type_name -> google.protobuf.Timestamp
+	7,  // 6: banking.audit.v1.AuditEvent.created_at:type_name -> google.protobuf.Timestamp
+	0,  // 7: banking.audit.v1.AuditService.RecordEvent:input_type -> banking.audit.v1.RecordEventRequest
+	0,  // 8: banking.audit.v1.AuditService.RecordEvents:input_type -> banking.audit.v1.RecordEventRequest
+	3,  // 9: banking.audit.v1.AuditService.QueryEvents:input_type -> banking.audit.v1.QueryEventsRequest
+	5,  // 10: banking.audit.v1.AuditService.VerifyEvent:input_type -> banking.audit.v1.VerifyEventRequest
+	1,  // 11: banking.audit.v1.AuditService.RecordEvent:output_type -> banking.audit.v1.RecordEventResponse
+	2,  // 12: banking.audit.v1.AuditService.RecordEvents:output_type -> banking.audit.v1.RecordEventsResponse
+	4,  // 13: banking.audit.v1.AuditService.QueryEvents:output_type -> banking.audit.v1.AuditEvent
+	6,  // 14: banking.audit.v1.AuditService.VerifyEvent:output_type -> banking.audit.v1.VerifyEventResponse
+	11, // [11:15] is the sub-list for method output_type
+	7,  // [7:11] is the sub-list for method input_type
+	7,  // [7:7] is the sub-list for extension type_name
+	7,  // [7:7] is the sub-list for extension extendee
+	0,  // [0:7] is the sub-list for field type_name
+}
+
+func init() { file_audit_v1_audit_proto_init() }
+func file_audit_v1_audit_proto_init() {
+	if File_audit_v1_audit_proto != nil {
+		return
+	}
+	file_audit_v1_audit_proto_msgTypes[0].OneofWrappers = []any{}
+	file_audit_v1_audit_proto_msgTypes[3].OneofWrappers = []any{}
+	file_audit_v1_audit_proto_msgTypes[4].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_audit_v1_audit_proto_rawDesc), len(file_audit_v1_audit_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   7,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_audit_v1_audit_proto_goTypes,
+		DependencyIndexes: file_audit_v1_audit_proto_depIdxs,
+		MessageInfos:      file_audit_v1_audit_proto_msgTypes,
+	}.Build()
+	File_audit_v1_audit_proto = out.File
+	file_audit_v1_audit_proto_goTypes = nil
+	file_audit_v1_audit_proto_depIdxs = nil
+}
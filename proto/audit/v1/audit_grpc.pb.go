@@ -0,0 +1,250 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.29.3
+// source: audit/v1/audit.proto
+
+package auditv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	AuditService_RecordEvent_FullMethodName  = "/banking.audit.v1.AuditService/RecordEvent"
+	AuditService_RecordEvents_FullMethodName = "/banking.audit.v1.AuditService/RecordEvents"
+	AuditService_QueryEvents_FullMethodName  = "/banking.audit.v1.AuditService/QueryEvents"
+	AuditService_VerifyEvent_FullMethodName  = "/banking.audit.v1.AuditService/VerifyEvent"
+)
+
+// AuditServiceClient is the client API for AuditService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// AuditService records audit events synchronously into the ledger and queries them.
+// Callers authenticate with an "authorization: Bearer <jwt>" or "x-api-key" metadata
+// entry, or a client certificate, and reads carry an "x-access-purpose" entry.
+type AuditServiceClient interface {
+	// RecordEvent persists one event and acknowledges it once it is in the ledger.
+	RecordEvent(ctx context.Context, in *RecordEventRequest, opts ...grpc.CallOption) (*RecordEventResponse, error)
+	// RecordEvents persists a stream of events in order. Each event is committed as it
+	// arrives; on failure the error names the first event not recorded.
+	RecordEvents(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[RecordEventRequest, RecordEventsResponse], error)
+	// QueryEvents streams the events matching a filter, newest first.
+	QueryEvents(ctx context.Context, in *QueryEventsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[AuditEvent], error)
+	// VerifyEvent checks an event's signature and hash-chain link.
+	VerifyEvent(ctx context.Context, in *VerifyEventRequest, opts ...grpc.CallOption) (*VerifyEventResponse, error)
+}
+
+type auditServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAuditServiceClient(cc grpc.ClientConnInterface) AuditServiceClient {
+	return &auditServiceClient{cc}
+}
+
+func (c *auditServiceClient) RecordEvent(ctx context.Context, in *RecordEventRequest, opts ...grpc.CallOption) (*RecordEventResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RecordEventResponse)
+	err := c.cc.Invoke(ctx, AuditService_RecordEvent_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *auditServiceClient) RecordEvents(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[RecordEventRequest, RecordEventsResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &AuditService_ServiceDesc.Streams[0], AuditService_RecordEvents_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[RecordEventRequest, RecordEventsResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type AuditService_RecordEventsClient = grpc.ClientStreamingClient[RecordEventRequest, RecordEventsResponse]
+
+func (c *auditServiceClient) QueryEvents(ctx context.Context, in *QueryEventsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[AuditEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &AuditService_ServiceDesc.Streams[1], AuditService_QueryEvents_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[QueryEventsRequest, AuditEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type AuditService_QueryEventsClient = grpc.ServerStreamingClient[AuditEvent]
+
+func (c *auditServiceClient) VerifyEvent(ctx context.Context, in *VerifyEventRequest, opts ...grpc.CallOption) (*VerifyEventResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(VerifyEventResponse)
+	err := c.cc.Invoke(ctx, AuditService_VerifyEvent_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AuditServiceServer is the server API for AuditService service.
+// All implementations must embed UnimplementedAuditServiceServer
+// for forward compatibility.
+//
+// AuditService records audit events synchronously into the ledger and queries them.
+// Callers authenticate with an "authorization: Bearer <jwt>" or "x-api-key" metadata
+// entry, or a client certificate, and reads carry an "x-access-purpose" entry.
+type AuditServiceServer interface {
+	// RecordEvent persists one event and acknowledges it once it is in the ledger.
+	RecordEvent(context.Context, *RecordEventRequest) (*RecordEventResponse, error)
+	// RecordEvents persists a stream of events in order. Each event is committed as it
+	// arrives; on failure the error names the first event not recorded.
+	RecordEvents(grpc.ClientStreamingServer[RecordEventRequest, RecordEventsResponse]) error
+	// QueryEvents streams the events matching a filter, newest first.
+	QueryEvents(*QueryEventsRequest, grpc.ServerStreamingServer[AuditEvent]) error
+	// VerifyEvent checks an event's signature and hash-chain link.
+	VerifyEvent(context.Context, *VerifyEventRequest) (*VerifyEventResponse, error)
+	mustEmbedUnimplementedAuditServiceServer()
+}
+
+// UnimplementedAuditServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedAuditServiceServer struct{}
+
+func (UnimplementedAuditServiceServer) RecordEvent(context.Context, *RecordEventRequest) (*RecordEventResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RecordEvent not implemented")
+}
+func (UnimplementedAuditServiceServer) RecordEvents(grpc.ClientStreamingServer[RecordEventRequest, RecordEventsResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method RecordEvents not implemented")
+}
+func (UnimplementedAuditServiceServer) QueryEvents(*QueryEventsRequest, grpc.ServerStreamingServer[AuditEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method QueryEvents not implemented")
+}
+func (UnimplementedAuditServiceServer) VerifyEvent(context.Context, *VerifyEventRequest) (*VerifyEventResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method VerifyEvent not implemented")
+}
+func (UnimplementedAuditServiceServer) mustEmbedUnimplementedAuditServiceServer() {}
+func (UnimplementedAuditServiceServer) testEmbeddedByValue()                      {}
+
+// UnsafeAuditServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AuditServiceServer will
+// result in compilation errors.
+type UnsafeAuditServiceServer interface {
+	mustEmbedUnimplementedAuditServiceServer()
+}
+
+func RegisterAuditServiceServer(s grpc.ServiceRegistrar, srv AuditServiceServer) {
+	// If the following call pancis, it indicates UnimplementedAuditServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&AuditService_ServiceDesc, srv)
+}
+
+func _AuditService_RecordEvent_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RecordEventRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuditServiceServer).RecordEvent(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AuditService_RecordEvent_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuditServiceServer).RecordEvent(ctx, req.(*RecordEventRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AuditService_RecordEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(AuditServiceServer).RecordEvents(&grpc.GenericServerStream[RecordEventRequest, RecordEventsResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type AuditService_RecordEventsServer = grpc.ClientStreamingServer[RecordEventRequest, RecordEventsResponse]
+
+func _AuditService_QueryEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(QueryEventsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AuditServiceServer).QueryEvents(m, &grpc.GenericServerStream[QueryEventsRequest, AuditEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type AuditService_QueryEventsServer = grpc.ServerStreamingServer[AuditEvent]
+
+func _AuditService_VerifyEvent_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VerifyEventRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuditServiceServer).VerifyEvent(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AuditService_VerifyEvent_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuditServiceServer).VerifyEvent(ctx, req.(*VerifyEventRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// AuditService_ServiceDesc is the grpc.ServiceDesc for AuditService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var AuditService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "banking.audit.v1.AuditService",
+	HandlerType: (*AuditServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "RecordEvent",
+			Handler:    _AuditService_RecordEvent_Handler,
+		},
+		{
+			MethodName: "VerifyEvent",
+			Handler:    _AuditService_VerifyEvent_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "RecordEvents",
+			Handler:       _AuditService_RecordEvents_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "QueryEvents",
+			Handler:       _AuditService_QueryEvents_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "audit/v1/audit.proto",
+}
@@ -0,0 +1,4 @@
+// Package auditv1 holds the generated gRPC API of the audit service
+package auditv1
+
+//go:generate protoc -I ../.. --go_out=../.. --go_opt=paths=source_relative --go-grpc_out=../.. --go-grpc_opt=paths=source_relative audit/v1/audit.proto
@@ -0,0 +1,11 @@
+// Package schemas embeds the JSON Schemas that submitted audit payloads, CloudEvents
+// envelopes and the data of Kafka events are validated against. Files are named
+// <payload>.v<major>.json; a breaking change adds a new major.
+package schemas
+
+import "embed"
+
+// FS holds every schema file
+//
+//go:embed *.json
+var FS embed.FS
@@ -2,23 +2,33 @@ package integration
 
 import (
 	"context"
+	"encoding/hex"
+	"strconv"
 	"testing"
 	"time"
 
+	"github.com/banking/audit-compliance/internal/auth"
 	"github.com/banking/audit-compliance/internal/config"
 	"github.com/banking/audit-compliance/internal/crypto"
 	"github.com/banking/audit-compliance/internal/domain"
+	"github.com/banking/audit-compliance/internal/migrate"
 	"github.com/banking/audit-compliance/internal/repository/elasticsearch"
 	"github.com/banking/audit-compliance/internal/repository/postgres"
 	"github.com/banking/audit-compliance/internal/repository/s3"
 	"github.com/banking/audit-compliance/internal/service"
+	"github.com/banking/audit-compliance/internal/timestamp"
+	"github.com/banking/audit-compliance/internal/timestamp/timestamptest"
+	"github.com/banking/audit-compliance/migrations"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 )
 
-// TestAuditFlow requires Docker Compose environment running
+// TestAuditFlow requires Docker Compose environment running, with the dev-roles.sql
+// passwords exported as AUDIT_DATABASE_PASSWORD and AUDIT_DATABASE_OWNER_PASSWORD
 func TestAuditFlow(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping integration test in short mode")
@@ -36,10 +46,42 @@ func TestAuditFlow(t *testing.T) {
 	)
 	require.NoError(t, err)
 
+	keyring, err := crypto.NewKeyring(cfg.Encryption, encryptor)
+	require.NoError(t, err)
+
+	// Bring the schema up to date as the owner, as `server migrate` does
+	ownerPool, err := pgxpool.New(context.Background(), cfg.Database.OwnerDSN())
+	require.NoError(t, err)
+	defer ownerPool.Close()
+	loaded, err := migrate.Load(migrations.FS)
+	require.NoError(t, err)
+	_, err = migrate.NewRunner(ownerPool, loaded, logger).
+		AfterMigration(postgres.ChainBackfillMigration, postgres.BackfillHashChain).
+		Up(context.Background(), false)
+	require.NoError(t, err)
+	pending, err := migrate.NewRunner(ownerPool, loaded, logger).Up(context.Background(), true)
+	require.NoError(t, err)
+	assert.Empty(t, pending, "Dry run after migrating must find nothing pending")
+
+	// Partitions for the coming months exist, and a second pass is a no-op
+	partitions := postgres.NewPartitionManager(ownerPool)
+	_, err = partitions.EnsurePartitions(context.Background(), time.Now(), 3)
+	require.NoError(t, err)
+	created, err := partitions.EnsurePartitions(context.Background(), time.Now(), 3)
+	require.NoError(t, err)
+	assert.Empty(t, created)
+	var current *string
+	err = ownerPool.QueryRow(context.Background(),
+		`SELECT to_regclass($1)::text`, postgres.PartitionName(time.Now())).Scan(&current)
+	require.NoError(t, err)
+	assert.NotNil(t, current, "Current month must have its own partition")
+
 	pgRepo, err := postgres.NewAuditRepository(cfg.Database, encryptor)
 	require.NoError(t, err)
 	defer pgRepo.Close()
 
+	checkpointRepo := postgres.NewCheckpointRepository(pgRepo.Pool())
+
 	esRepo, err := elasticsearch.NewSearchRepository(cfg.Elasticsearch)
 	if err != nil {
 		t.Logf("Elasticsearch not available, skipping search verification: %v", err)
@@ -47,8 +89,39 @@ func TestAuditFlow(t *testing.T) {
 
 	s3Repo, err := s3.NewArchiveRepository(context.Background(), cfg.S3)
 	require.NoError(t, err)
+	require.NoError(t, s3Repo.EnsureBucket(context.Background()))
+
+	// A closed month is archived, marked archived and detached by one maintenance pass
+	archivedMonth := time.Date(2001, time.January, 1, 0, 0, 0, 0, time.UTC)
+	_, err = partitions.EnsurePartitions(context.Background(), archivedMonth, 0)
+	require.NoError(t, err)
+	require.NoError(t, service.NewPartitionService(partitions, s3Repo, 3, 12, logger).Maintain(context.Background()))
+	var archiveRef *string
+	var detached, attached bool
+	err = ownerPool.QueryRow(context.Background(), `
+		SELECT archive_ref, detached_at IS NOT NULL,
+		       EXISTS (SELECT 1 FROM pg_inherits WHERE inhrelid = to_regclass(partition_name))
+		FROM audit_partitions WHERE partition_name = $1
+	`, postgres.PartitionName(archivedMonth)).Scan(&archiveRef, &detached, &attached)
+	require.NoError(t, err)
+	require.NotNil(t, archiveRef)
+	assert.Contains(t, *archiveRef, "manifest.json")
+	assert.True(t, detached)
+	assert.False(t, attached, "An archived partition no longer belongs to audit_events")
+
+	// Local stand-in for an RFC 3161 timestamp authority
+	tsa := timestamptest.NewAuthority(t)
+	tsaClient, err := timestamp.NewClient(tsa.Config())
+	require.NoError(t, err)
+
+	auditService := service.NewAuditService(pgRepo, checkpointRepo, postgres.NewAccessLogRepository(pgRepo.Pool()), esRepo, s3Repo, encryptor, keyring, tsaClient, logger)
 
-	auditService := service.NewAuditService(pgRepo, esRepo, s3Repo, encryptor, logger)
+	// Reads of audit data are recorded against the caller
+	reader := auth.Identity{
+		Subject: "integration-test", AccessorID: auth.AccessorID("integration-test"), Role: "AUDITOR", IPAddress: "127.0.0.1",
+		Purpose: auth.Purpose{Code: "CASE", Reference: "IT-" + uuid.NewString()[:8]},
+	}
+	readCtx := auth.WithIdentity(context.Background(), reader)
 
 	// 2. Execution
 	eventID := uuid.New()
@@ -67,7 +140,7 @@ func TestAuditFlow(t *testing.T) {
 		UserID: &userID,
 		Limit:  1,
 	}
-	page, err := auditService.GetAuditTrail(context.Background(), filter)
+	page, err := auditService.GetAuditTrail(readCtx, filter)
 	require.NoError(t, err)
 	require.NotEmpty(t, page.Events)
 
@@ -76,20 +149,320 @@ func TestAuditFlow(t *testing.T) {
 	assert.Equal(t, domain.ActionTypeLogin, retrieved.ActionType)
 	assert.NotEmpty(t, retrieved.DigitalSignature)
 
-	// Verify Signature
-	valid := encryptor.VerifyDigitalSignature(
-		retrieved.EventID.String(),
-		retrieved.UserID.String(),
-		string(retrieved.ActionType),
-		retrieved.Timestamp.Format(time.RFC3339),
-		string(retrieved.Result),
-		retrieved.DigitalSignature,
+	// Verify Signature (covers every persisted field)
+	assert.Equal(t, domain.CurrentSignatureVersion, retrieved.SignatureVersion)
+	assert.Equal(t, keyring.Active().Algorithm(), retrieved.SignatureAlgorithm)
+	payload, ok := retrieved.SignaturePayload(retrieved.SignatureVersion)
+	require.True(t, ok)
+	assert.True(t, keyring.Verify(retrieved.SignatureAlgorithm, retrieved.SigningKeyID, retrieved.SequenceNum, payload, retrieved.DigitalSignature),
+		"Digital signature must be valid")
+
+	// A change to any field, not only the five legacy ones, must break the signature
+	tampered := *retrieved
+	tampered.IPAddress = "10.0.0.1"
+	tamperedPayload, _ := tampered.SignaturePayload(tampered.SignatureVersion)
+	assert.False(t, keyring.Verify(tampered.SignatureAlgorithm, tampered.SigningKeyID, tampered.SequenceNum, tamperedPayload, tampered.DigitalSignature),
+		"Tampered field must invalidate signature")
+
+	// Verify Hash Chain link
+	assert.Positive(t, retrieved.SequenceNum)
+	assert.Len(t, retrieved.PrevHash, 64)
+	assert.True(t, encryptor.VerifyHashChain(retrieved.PrevHash, retrieved.CanonicalRecord(), retrieved.RecordHash),
+		"Record hash must match the canonical encoding")
+
+	// Keyset pagination walks every event of the user exactly once
+	for i := 0; i < 2; i++ {
+		more := domain.NewAuditEvent(userID, domain.ActionTypeLogin, domain.ResourceTypeUser, userID.String())
+		more.Result = domain.AuditResultSuccess
+		require.NoError(t, auditService.ProcessAndStoreEvent(context.Background(), more))
+	}
+	firstPage, err := auditService.GetAuditTrail(readCtx, domain.AuditEventFilter{
+		UserID: &userID, Limit: 2, Count: domain.CountExact,
+	})
+	require.NoError(t, err)
+	require.NotNil(t, firstPage.TotalCount)
+	assert.EqualValues(t, 3, *firstPage.TotalCount)
+	assert.Equal(t, 1, firstPage.Page)
+	require.True(t, firstPage.HasMore)
+	cursor, err := domain.DecodeEventCursor(firstPage.NextCursor)
+	require.NoError(t, err)
+	secondPage, err := auditService.GetAuditTrail(readCtx, domain.AuditEventFilter{
+		UserID: &userID, Limit: 2, Cursor: cursor,
+	})
+	require.NoError(t, err)
+	require.Len(t, secondPage.Events, 1)
+	assert.False(t, secondPage.HasMore)
+	assert.Empty(t, secondPage.NextCursor)
+	assert.Equal(t, eventID, secondPage.Events[0].EventID, "Oldest event comes last")
+
+	// Reads without a caller identity are refused, and every read is logged
+	_, err = auditService.GetAuditTrail(context.Background(), filter)
+	assert.ErrorIs(t, err, auth.ErrNoIdentity)
+	accessLogs, err := auditService.SearchAccessLogs(readCtx, domain.AccessLogFilter{
+		PurposeCode: &reader.Purpose.Code, PurposeReference: &reader.Purpose.Reference, Limit: 100,
+	})
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, len(accessLogs), 3)
+	for _, entry := range accessLogs {
+		assert.Equal(t, reader.AccessorID, entry.AccessorID)
+		assert.Equal(t, reader.Subject, entry.AccessorSubject)
+		assert.Equal(t, domain.AccessTypeView, entry.AccessType)
+		assert.Equal(t, reader.Purpose.String(), entry.Purpose)
+	}
+
+	// Every filter field narrows the result set
+	success := domain.AuditResultSuccess
+	matching, err := auditService.GetAuditTrail(readCtx, domain.AuditEventFilter{
+		UserID: &userID, ActionTypes: []domain.ActionType{domain.ActionTypeLogout, domain.ActionTypeLogin},
+		Result: &success, IPAddress: &event.IPAddress, Limit: 10,
+	})
+	require.NoError(t, err)
+	require.Len(t, matching.Events, 1)
+	assert.Equal(t, eventID, matching.Events[0].EventID)
+	none, err := auditService.GetAuditTrail(readCtx, domain.AuditEventFilter{
+		UserID: &userID, ActionTypes: []domain.ActionType{domain.ActionTypeLogout}, Limit: 10,
+	})
+	require.NoError(t, err)
+	assert.Empty(t, none.Events)
+	flagged, err := auditService.GetAuditTrail(readCtx, domain.AuditEventFilter{
+		UserID: &userID, ComplianceFlags: []string{"NO_SUCH_FLAG"}, Limit: 10,
+	})
+	require.NoError(t, err)
+	assert.Empty(t, flagged.Events)
+
+	// A retried write with the same idempotency key returns the events first recorded
+	writer := auth.WithIdentity(context.Background(), auth.Identity{Subject: "integration-writer", Role: "SERVICE"})
+	key := uuid.NewString()
+	submit := func() []*domain.AuditEvent {
+		e := domain.NewAuditEvent(userID, domain.ActionTypeUpdate, domain.ResourceTypeUser, userID.String())
+		e.EventID = uuid.Nil
+		e.Result = domain.AuditResultSuccess
+		return []*domain.AuditEvent{e}
+	}
+	first, replayed, err := auditService.RecordEvents(writer, key, "hash-a", submit())
+	require.NoError(t, err)
+	assert.False(t, replayed)
+	retried, replayed, err := auditService.RecordEvents(writer, key, "hash-a", submit())
+	require.NoError(t, err)
+	assert.True(t, replayed)
+	assert.Equal(t, first[0].EventID, retried[0].EventID)
+	assert.Equal(t, first[0].SequenceNum, retried[0].SequenceNum)
+	_, _, err = auditService.RecordEvents(writer, key, "hash-b", submit())
+	assert.ErrorIs(t, err, service.ErrIdempotencyKeyReused)
+	assert.ErrorIs(t, auditService.ProcessAndStoreEvent(context.Background(), first[0]), service.ErrDuplicateEvent)
+
+	// Once expired, the key records a new request instead of replaying the old one
+	expired, err := pgRepo.DeleteExpiredIdempotencyKeys(context.Background(), time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, expired, int64(1))
+	reused, replayed, err := auditService.RecordEvents(writer, key, "hash-b", submit())
+	require.NoError(t, err)
+	assert.False(t, replayed)
+	assert.NotEqual(t, first[0].EventID, reused[0].EventID)
+
+	// A redelivered event is recognized; the same ID with other content is refused
+	redelivered := *retrieved
+	duplicate, err := auditService.StoreEventOnce(context.Background(), &redelivered)
+	require.NoError(t, err)
+	assert.True(t, duplicate)
+	conflicting := *retrieved
+	conflicting.Result = domain.AuditResultFailure
+	_, err = auditService.StoreEventOnce(context.Background(), &conflicting)
+	assert.ErrorIs(t, err, service.ErrConflictingEvent)
+
+	// A batch is chained in order; redeliveries within and before it are recognized
+	batch := []*domain.AuditEvent{&redelivered}
+	for i := 0; i < 3; i++ {
+		e := domain.NewAuditEvent(userID, domain.ActionTypeLogin, domain.ResourceTypeUser, userID.String())
+		e.Result = domain.AuditResultSuccess
+		e.IPAddress = "127.0.0.1"
+		batch = append(batch, e)
+	}
+	again := *batch[1]
+	batch = append(batch, &again)
+	results, err := auditService.StoreEventsOnce(context.Background(), batch)
+	require.NoError(t, err)
+	require.Len(t, results, len(batch))
+	assert.Equal(t, []bool{true, false, false, false, true}, []bool{
+		results[0].Duplicate, results[1].Duplicate, results[2].Duplicate, results[3].Duplicate, results[4].Duplicate,
+	})
+	for i := 2; i < 4; i++ {
+		assert.Equal(t, batch[i-1].SequenceNum+1, batch[i].SequenceNum)
+		assert.Equal(t, batch[i-1].RecordHash, batch[i].PrevHash)
+	}
+
+	// Dead letters are kept for inspection and can be marked replayed exactly once
+	dlqRepo := postgres.NewDLQRepository(pgRepo.Pool())
+	deadLetter := &domain.DeadLetter{
+		DLQID: uuid.New(), SourceTopic: "it." + uuid.NewString(), SourceOffset: 42, Payload: []byte(`{"event_type":`),
+		ErrorClass: domain.DeadLetterMalformed, ErrorMessage: "unexpected end of JSON input", FailedAt: time.Now().UTC(),
+	}
+	require.NoError(t, dlqRepo.SaveDeadLetter(context.Background(), deadLetter))
+	stored, err := dlqRepo.GetDeadLetters(context.Background(), []uuid.UUID{deadLetter.DLQID})
+	require.NoError(t, err)
+	require.Len(t, stored, 1)
+	assert.Equal(t, deadLetter.Payload, stored[0].Payload)
+
+	// Quarantined records keep the location of each schema violation
+	quarantined := &domain.DeadLetter{
+		DLQID: uuid.New(), SourceTopic: "it." + uuid.NewString(), SourceOffset: 43, Payload: []byte(`{"user_id":"42"}`),
+		ErrorClass: domain.DeadLetterQuarantined, ErrorMessage: "payload does not match user_event.v1.json",
+		Violations: []domain.DeadLetterViolation{{Field: "/user_id", Message: "'42' is not valid uuid"}}, FailedAt: time.Now().UTC(),
+	}
+	require.NoError(t, dlqRepo.SaveDeadLetter(context.Background(), quarantined))
+	stored, err = dlqRepo.GetDeadLetters(context.Background(), []uuid.UUID{quarantined.DLQID})
+	require.NoError(t, err)
+	require.Len(t, stored, 1)
+	assert.Equal(t, quarantined.Violations, stored[0].Violations)
+	require.NoError(t, dlqRepo.MarkReplayed(context.Background(), deadLetter.DLQID, eventID, time.Now().UTC()))
+	assert.ErrorIs(t, dlqRepo.MarkReplayed(context.Background(), deadLetter.DLQID, eventID, time.Now().UTC()), postgres.ErrDeadLetterReplayed)
+
+	// Verify the full ledger, including the event we just appended
+	report, err := auditService.VerifyLedger(readCtx, time.Time{}, time.Now().UTC())
+	require.NoError(t, err)
+	assert.True(t, report.Valid, "Ledger must verify: %+v", report)
+	assert.GreaterOrEqual(t, report.LastSequence, retrieved.SequenceNum)
+
+	// Checkpoint the ledger and prove the event is included in the signed tree
+	checkpoint, err := auditService.CreateCheckpoint(context.Background())
+	require.NoError(t, err)
+	proof, err := auditService.GetInclusionProof(readCtx, eventID, checkpoint.TreeSize)
+	require.NoError(t, err)
+
+	root, _ := hex.DecodeString(checkpoint.RootHash)
+	leaf, _ := hex.DecodeString(proof.LeafHash)
+	path := make([][]byte, len(proof.AuditPath))
+	for i, p := range proof.AuditPath {
+		path[i], _ = hex.DecodeString(p)
+	}
+	assert.True(t, crypto.VerifyMerkleInclusion(proof.LeafIndex, checkpoint.TreeSize, leaf, path, root),
+		"Inclusion proof must verify against the checkpoint root")
+
+	// The checkpoint carries a trusted timestamp that the integrity check re-verifies
+	require.NotEmpty(t, checkpoint.TimestampToken)
+	require.NotNil(t, checkpoint.TimestampedAt)
+	_, err = tsaClient.Verify(checkpoint.TimestampToken, checkpoint.TimestampDigest())
+	assert.NoError(t, err)
+	report, err = auditService.VerifyLedger(readCtx, checkpoint.CreatedAt, time.Now().UTC())
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.CheckpointsChecked)
+	assert.Empty(t, report.InvalidCheckpoints)
+
+	// Tampering by the owner, who can disable the append-only triggers, is detected: a
+	// rewritten link, and events removed from the end of the chain
+	ownerConn, err := ownerPool.Acquire(context.Background())
+	require.NoError(t, err)
+	defer ownerConn.Release()
+	tamper := func(statements ...string) {
+		t.Helper()
+		for _, stmt := range append(append([]string{
+			`ALTER TABLE audit_events DISABLE TRIGGER USER`,
+			`ALTER TABLE audit_event_index DISABLE TRIGGER USER`,
+		}, statements...),
+			`ALTER TABLE audit_events ENABLE TRIGGER USER`,
+			`ALTER TABLE audit_event_index ENABLE TRIGGER USER`,
+		) {
+			_, err := ownerConn.Exec(context.Background(), stmt)
+			require.NoError(t, err, stmt)
+		}
+	}
+
+	tamperedReader := reader
+	tamperedReader.Purpose.Reference = "IT-" + uuid.NewString()[:8]
+	tamperedCtx := auth.WithIdentity(context.Background(), tamperedReader)
+	tamper(`UPDATE audit_events SET prev_hash = repeat('f', 64) WHERE event_id = '` + eventID.String() + `'`)
+	report, err = auditService.VerifyLedger(readCtx, time.Time{}, time.Now().UTC())
+	_, readErr := auditService.GetEvent(tamperedCtx, eventID)
+	_, trailErr := auditService.GetAuditTrail(tamperedCtx, filter)
+	tamper(`UPDATE audit_events SET prev_hash = '` + retrieved.PrevHash + `' WHERE event_id = '` + eventID.String() + `'`)
+	assert.ErrorIs(t, readErr, service.ErrIntegrityFailure)
+	assert.ErrorIs(t, trailErr, service.ErrIntegrityFailure)
+	tamperedLogs, logErr := auditService.SearchAccessLogs(readCtx, domain.AccessLogFilter{
+		PurposeReference: &tamperedReader.Purpose.Reference, Limit: 10,
+	})
+	require.NoError(t, logErr)
+	assert.Len(t, tamperedLogs, 2, "Reads of tampered events are still recorded")
+	require.NoError(t, err)
+	assert.False(t, report.Valid)
+	require.NotNil(t, report.FirstBrokenLink)
+	assert.Equal(t, eventID, report.FirstBrokenLink.EventID)
+	assert.Contains(t, report.InvalidRecordHashes, eventID)
+
+	head := strconv.FormatInt(checkpoint.TreeSize, 10)
+	tamper(
+		`CREATE TEMP TABLE tail_events AS SELECT * FROM audit_events WHERE sequence_num = `+head,
+		`CREATE TEMP TABLE tail_index AS SELECT * FROM audit_event_index WHERE sequence_num = `+head,
+		`DELETE FROM audit_events WHERE sequence_num = `+head,
+		`DELETE FROM audit_event_index WHERE sequence_num = `+head,
+	)
+	report, err = auditService.VerifyLedger(readCtx, time.Time{}, time.Now().UTC())
+	tamper(
+		`INSERT INTO audit_event_index SELECT * FROM tail_index`,
+		`INSERT INTO audit_events SELECT * FROM tail_events`,
+		`DROP TABLE tail_events, tail_index`,
 	)
-	assert.True(t, valid, "Digital signature must be valid")
+	require.NoError(t, err)
+	assert.False(t, report.Valid)
+	assert.Equal(t, checkpoint.TreeSize-1, report.ChainHead)
+	require.NotEmpty(t, report.InvalidCheckpoints)
+	assert.Contains(t, report.InvalidCheckpoints[0].Reason, "truncated")
+
+	report, err = auditService.VerifyLedger(readCtx, time.Time{}, time.Now().UTC())
+	require.NoError(t, err)
+	assert.True(t, report.Valid, "Restored ledger must verify: %+v", report)
+
+	// A checkpoint's stored tsa_time must be the genTime its token was issued with
+	retime := func(tsaTime time.Time) {
+		t.Helper()
+		for _, stmt := range []string{
+			`ALTER TABLE ledger_checkpoints DISABLE TRIGGER USER`,
+			`UPDATE ledger_checkpoints SET tsa_time = '` + tsaTime.Format(time.RFC3339Nano) + `' WHERE tree_size = ` + head,
+			`ALTER TABLE ledger_checkpoints ENABLE TRIGGER USER`,
+		} {
+			_, err := ownerConn.Exec(context.Background(), stmt)
+			require.NoError(t, err, stmt)
+		}
+	}
+	retime(checkpoint.TimestampedAt.Add(-time.Hour))
+	report, err = auditService.VerifyLedger(readCtx, checkpoint.CreatedAt, time.Now().UTC())
+	retime(*checkpoint.TimestampedAt)
+	require.NoError(t, err)
+	assert.False(t, report.Valid)
+	require.NotEmpty(t, report.InvalidCheckpoints)
+	assert.Contains(t, report.InvalidCheckpoints[0].Reason, "tsa_time")
+
+	// The next checkpoint extends the stored tree, and proves the first one is its prefix
+	_, _, err = auditService.RecordEvents(writer, uuid.NewString(), "hash-c", submit())
+	require.NoError(t, err)
+	extended, err := auditService.CreateCheckpoint(context.Background())
+	require.NoError(t, err)
+	require.Greater(t, extended.TreeSize, checkpoint.TreeSize)
+	consistency, err := auditService.GetConsistencyProof(readCtx, checkpoint.TreeSize, extended.TreeSize)
+	require.NoError(t, err)
+	extendedRoot, _ := hex.DecodeString(extended.RootHash)
+	consistencyPath := make([][]byte, len(consistency.Proof))
+	for i, p := range consistency.Proof {
+		consistencyPath[i], _ = hex.DecodeString(p)
+	}
+	assert.True(t, crypto.VerifyMerkleConsistency(checkpoint.TreeSize, extended.TreeSize, root, extendedRoot, consistencyPath),
+		"Consistency proof must verify against both checkpoint roots")
+	_, err = auditService.GetInclusionProof(readCtx, eventID, extended.TreeSize)
+	require.NoError(t, err)
+
+	// 4. Verification - Immutability
+	// The service role passes the startup self-check and is refused by grants
+	require.NoError(t, pgRepo.CheckAppendOnly(context.Background()))
+	_, err = pgRepo.Pool().Exec(context.Background(),
+		`UPDATE audit_events SET result = 'FAILURE' WHERE event_id = $1`, eventID)
+	var pgErr *pgconn.PgError
+	require.ErrorAs(t, err, &pgErr, "UPDATE on audit_events must fail")
+	assert.Equal(t, "42501", pgErr.Code) // insufficient_privilege
 
-	// 4. Verification - Immutability (Attempt Update)
-	// We can't easily test SQL grants here without a separate admin connection,
-	// but logically we ensured the repo has no Update/Delete methods.
+	// The owner holds UPDATE privileges, so here the trigger is what refuses it
+	_, err = ownerPool.Exec(context.Background(),
+		`UPDATE audit_events SET result = 'FAILURE' WHERE event_id = $1`, eventID)
+	require.ErrorAs(t, err, &pgErr, "Trigger must reject UPDATE by the owner")
+	assert.Contains(t, pgErr.Message, "append-only")
 
 	t.Log("Audit Flow Integration Test Passed")
 }
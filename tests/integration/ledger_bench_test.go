@@ -0,0 +1,78 @@
+package integration
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/banking/audit-compliance/internal/config"
+	"github.com/banking/audit-compliance/internal/crypto"
+	"github.com/banking/audit-compliance/internal/domain"
+	"github.com/banking/audit-compliance/internal/repository/elasticsearch"
+	"github.com/banking/audit-compliance/internal/repository/postgres"
+	"github.com/banking/audit-compliance/internal/service"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// BenchmarkLedgerWrites compares the consumer's per-record ledger writes with batched
+// writes. Requires the Docker Compose environment with migrations applied:
+//
+//	AUDIT_DATABASE_PASSWORD=audit_app AUDIT_DATABASE_OWNER_PASSWORD=audit_owner \
+//		go test ./tests/integration -run '^$' -bench LedgerWrites -benchtime 20000x
+func BenchmarkLedgerWrites(b *testing.B) {
+	if testing.Short() {
+		b.Skip("Skipping integration benchmark in short mode")
+	}
+
+	cfg, err := config.Load()
+	require.NoError(b, err)
+	encryptor, err := crypto.NewFieldEncryptor(
+		cfg.Encryption.EncryptionKeysBase64,
+		cfg.Encryption.CurrentKeyVersion,
+		cfg.Encryption.AuditHMACSecret,
+	)
+	require.NoError(b, err)
+	keyring, err := crypto.NewKeyring(cfg.Encryption, encryptor)
+	require.NoError(b, err)
+	pgRepo, err := postgres.NewAuditRepository(cfg.Database, encryptor)
+	require.NoError(b, err)
+	defer pgRepo.Close()
+	esRepo, err := elasticsearch.NewSearchRepository(cfg.Elasticsearch)
+	if err != nil {
+		b.Logf("Elasticsearch not available, events will not be indexed: %v", err)
+	}
+
+	auditService := service.NewAuditService(pgRepo, nil, nil, esRepo, nil, encryptor, keyring, nil, zap.NewNop())
+
+	newEvent := func() *domain.AuditEvent {
+		userID := uuid.New()
+		e := domain.NewAuditEvent(userID, domain.ActionTypeLogin, domain.ResourceTypeUser, userID.String())
+		e.Result = domain.AuditResultSuccess
+		e.IPAddress = "127.0.0.1"
+		return e
+	}
+
+	b.Run("per_record", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_, err := auditService.StoreEventOnce(context.Background(), newEvent())
+			require.NoError(b, err)
+		}
+		b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "events/s")
+	})
+
+	for _, size := range []int{100, 500} {
+		b.Run("batch_"+strconv.Itoa(size), func(b *testing.B) {
+			for written := 0; written < b.N; written += size {
+				events := make([]*domain.AuditEvent, min(size, b.N-written))
+				for i := range events {
+					events[i] = newEvent()
+				}
+				_, err := auditService.StoreEventsOnce(context.Background(), events)
+				require.NoError(b, err)
+			}
+			b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "events/s")
+		})
+	}
+}